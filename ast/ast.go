@@ -79,6 +79,36 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 
+type ThrowStatement struct {
+	Token token.Token // the 'throw' token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ts.TokenLiteral() + " ")
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+// ExportStatement declara con let un nombre que el módulo expone a quienes
+// lo importan: export let nombre = valor;
+type ExportStatement struct {
+	Token     token.Token // the 'export' token
+	Statement *LetStatement
+}
+
+func (es *ExportStatement) statementNode()       {}
+func (es *ExportStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Statement.String()
+}
+
 type BreakStatement struct {
 	Token token.Token
 }
@@ -149,6 +179,34 @@ func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 
+// InterpolatedString es un string con expresiones ${...}. Parts alterna los
+// fragmentos de texto, que son *StringLiteral (posiblemente vacíos), y las
+// expresiones: empieza y termina con un fragmento, así que los de índice
+// par son texto.
+type InterpolatedString struct {
+	Token    token.Token // the STRING_HEAD token
+	Parts    []Expression
+	EndToken token.Token // the STRING_TAIL token
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+	for i, part := range is.Parts {
+		if i%2 == 0 {
+			out.WriteString(part.String())
+			continue
+		}
+		out.WriteString("${")
+		if part != nil {
+			out.WriteString(part.String())
+		}
+		out.WriteString("}")
+	}
+	return out.String()
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -231,6 +289,7 @@ type IfExpression struct {
 	Token       token.Token
 	Condition   Expression
 	Consequence *BlockStatement
+	ElseIf      *IfExpression // rama "else if (...) { }"; excluye a Alternative
 	Alternative *BlockStatement
 }
 
@@ -238,20 +297,48 @@ func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
-	out.WriteString("if")
+	out.WriteString("if (")
 	out.WriteString(ie.Condition.String())
-	out.WriteString(" ")
+	out.WriteString(") { ")
 	out.WriteString(ie.Consequence.String())
-	if ie.Alternative != nil {
-		out.WriteString("else ")
+	out.WriteString(" }")
+	if ie.ElseIf != nil {
+		out.WriteString(" else ")
+		out.WriteString(ie.ElseIf.String())
+	} else if ie.Alternative != nil {
+		out.WriteString(" else { ")
 		out.WriteString(ie.Alternative.String())
+		out.WriteString(" }")
 	}
 	return out.String()
 }
 
+// ConditionalExpression es el operador ternario: Condition ? Consequence : Alternative
+type ConditionalExpression struct {
+	Token       token.Token // the '?' token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (ce *ConditionalExpression) expressionNode()      {}
+func (ce *ConditionalExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *ConditionalExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ce.Condition.String())
+	out.WriteString(" ? ")
+	out.WriteString(ce.Consequence.String())
+	out.WriteString(" : ")
+	out.WriteString(ce.Alternative.String())
+	out.WriteString(")")
+	return out.String()
+}
+
 type BlockStatement struct {
-	Token      token.Token
+	Token      token.Token // the '{' token
 	Statements []Statement
+	EndToken   token.Token // the '}' token
 }
 
 func (bs *BlockStatement) statementNode()       {}
@@ -288,9 +375,10 @@ func (fl *FunctionLiteral) String() string {
 }
 
 type CallExpression struct {
-	Token     token.Token
+	Token     token.Token // the '(' token
 	Function  Expression
 	Arguments []Expression
+	EndToken  token.Token // the ')' token
 }
 
 func (ce *CallExpression) expressionNode()      {}
@@ -311,6 +399,7 @@ func (ce *CallExpression) String() string {
 type ArrayLiteral struct {
 	Token    token.Token // the '[' token
 	Elements []Expression
+	EndToken token.Token // the ']' token
 }
 
 func (al *ArrayLiteral) expressionNode()      {}
@@ -328,9 +417,10 @@ func (al *ArrayLiteral) String() string {
 }
 
 type IndexExpression struct {
-	Token token.Token // The [ token
-	Left  Expression
-	Index Expression
+	Token    token.Token // The [ token
+	Left     Expression
+	Index    Expression
+	EndToken token.Token // The ] token
 }
 
 func (ie *IndexExpression) expressionNode()      {}
@@ -348,6 +438,10 @@ func (ie *IndexExpression) String() string {
 type HashLiteral struct {
 	Token token.Token // the '{' token
 	Pairs map[Expression]Expression
+	// Keys son las claves de Pairs en el orden en que aparecen en el código,
+	// que es el orden en que se evalúan y en que se recorre el hash.
+	Keys     []Expression
+	EndToken token.Token // the '}' token
 }
 
 func (hl *HashLiteral) expressionNode()      {}
@@ -355,8 +449,8 @@ func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -364,6 +458,41 @@ func (hl *HashLiteral) String() string {
 	return out.String()
 }
 
+// TryExpression es try { } catch (e) { } finally { }. Catch y Finally son
+// opcionales, pero al menos uno está presente; el parámetro del catch
+// también es opcional.
+type TryExpression struct {
+	Token          token.Token // the 'try' token
+	Block          *BlockStatement
+	CatchParameter *Identifier
+	Catch          *BlockStatement
+	Finally        *BlockStatement
+}
+
+func (te *TryExpression) expressionNode()      {}
+func (te *TryExpression) TokenLiteral() string { return te.Token.Literal }
+func (te *TryExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("try { ")
+	out.WriteString(te.Block.String())
+	out.WriteString(" }")
+	if te.Catch != nil {
+		out.WriteString(" catch ")
+		if te.CatchParameter != nil {
+			out.WriteString("(" + te.CatchParameter.String() + ") ")
+		}
+		out.WriteString("{ ")
+		out.WriteString(te.Catch.String())
+		out.WriteString(" }")
+	}
+	if te.Finally != nil {
+		out.WriteString(" finally { ")
+		out.WriteString(te.Finally.String())
+		out.WriteString(" }")
+	}
+	return out.String()
+}
+
 type WhileExpression struct {
 	Token     token.Token
 	Init      Statement
@@ -382,3 +511,16 @@ func (wl *WhileExpression) String() string {
 	out.WriteString(wl.Body.String())
 	return out.String()
 }
+
+// ImportExpression carga el módulo de Path y devuelve su valor de módulo:
+// import "ruta/al/modulo.monkey"
+type ImportExpression struct {
+	Token token.Token // the 'import' token
+	Path  *StringLiteral
+}
+
+func (ie *ImportExpression) expressionNode()      {}
+func (ie *ImportExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *ImportExpression) String() string {
+	return ie.TokenLiteral() + " \"" + ie.Path.Value + "\""
+}
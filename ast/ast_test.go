@@ -2,6 +2,7 @@ package ast
 
 import (
 	"go-rilla/token"
+	"strings"
 	"testing"
 )
 
@@ -28,3 +29,31 @@ func TestString(t *testing.T) {
 			expected, program.String())
 	}
 }
+
+func TestInspect(t *testing.T) {
+	ident := func(name string) *Identifier {
+		return &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: name}, Value: name}
+	}
+	var missing *LetStatement
+	program := &Program{
+		Statements: []Statement{
+			&LetStatement{Token: token.Token{Type: token.LET, Literal: "let"}, Name: ident("a"), Value: &InfixExpression{
+				Token: token.Token{Type: token.PLUS, Literal: "+"}, Operator: "+", Left: ident("b"), Right: ident("c"),
+			}},
+			missing,
+			&ExpressionStatement{Expression: &CallExpression{Function: ident("f"), Arguments: []Expression{ident("d"), nil}}},
+		},
+	}
+
+	var names []string
+	Inspect(program, func(n Node) bool {
+		if id, ok := n.(*Identifier); ok {
+			names = append(names, id.Value)
+		}
+		_, isCall := n.(*CallExpression)
+		return !isCall
+	})
+	if got := strings.Join(names, ","); got != "a,b,c" {
+		t.Errorf("Inspect visited %q, want %q", got, "a,b,c")
+	}
+}
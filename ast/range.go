@@ -0,0 +1,176 @@
+package ast
+
+import (
+	"go-rilla/source"
+	"go-rilla/token"
+)
+
+// NodeRange devuelve el rango que ocupa node en el código fuente, desde su
+// primer token hasta el final del último. Los paréntesis de agrupación no
+// forman parte del AST, por lo que no se incluyen.
+func NodeRange(node Node) source.Range {
+	return source.Range{Start: startOf(node), End: endOf(node)}
+}
+
+func startOf(node Node) source.Position {
+	switch node := node.(type) {
+	case *Program:
+		if len(node.Statements) > 0 {
+			return startOf(node.Statements[0])
+		}
+		return source.Position{}
+	case *ExpressionStatement:
+		if node.Expression != nil {
+			return startOf(node.Expression)
+		}
+		return node.Token.Range.Start
+	case *InfixExpression:
+		return startOf(node.Left)
+	case *ConditionalExpression:
+		return startOf(node.Condition)
+	case *PostfixExpression:
+		return startOf(node.Left)
+	case *CallExpression:
+		return startOf(node.Function)
+	case *IndexExpression:
+		return startOf(node.Left)
+	case *MemberExpression:
+		return startOf(node.Object)
+	}
+	return tokenOf(node).Range.Start
+}
+
+func endOf(node Node) source.Position {
+	switch node := node.(type) {
+	case *Program:
+		if len(node.Statements) > 0 {
+			return endOf(node.Statements[len(node.Statements)-1])
+		}
+		return source.Position{}
+	case *LetStatement:
+		if node.Value != nil {
+			return endOf(node.Value)
+		}
+		return node.Name.Token.Range.End
+	case *ReturnStatement:
+		if node.ReturnValue != nil {
+			return endOf(node.ReturnValue)
+		}
+	case *ThrowStatement:
+		if node.Value != nil {
+			return endOf(node.Value)
+		}
+	case *ExportStatement:
+		return endOf(node.Statement)
+	case *ExpressionStatement:
+		if node.Expression != nil {
+			return endOf(node.Expression)
+		}
+	case *BlockStatement:
+		return node.EndToken.Range.End
+	case *PrefixExpression:
+		return endOf(node.Right)
+	case *InfixExpression:
+		return endOf(node.Right)
+	case *MemberExpression:
+		return endOf(node.Property)
+	case *ConditionalExpression:
+		return endOf(node.Alternative)
+	case *IfExpression:
+		if node.ElseIf != nil {
+			return endOf(node.ElseIf)
+		}
+		if node.Alternative != nil {
+			return endOf(node.Alternative)
+		}
+		return endOf(node.Consequence)
+	case *FunctionLiteral:
+		return endOf(node.Body)
+	case *CallExpression:
+		return node.EndToken.Range.End
+	case *ArrayLiteral:
+		return node.EndToken.Range.End
+	case *InterpolatedString:
+		return node.EndToken.Range.End
+	case *IndexExpression:
+		return node.EndToken.Range.End
+	case *HashLiteral:
+		return node.EndToken.Range.End
+	case *WhileExpression:
+		return endOf(node.Body)
+	case *ImportExpression:
+		return endOf(node.Path)
+	case *TryExpression:
+		if node.Finally != nil {
+			return endOf(node.Finally)
+		}
+		if node.Catch != nil {
+			return endOf(node.Catch)
+		}
+		return endOf(node.Block)
+	}
+	return tokenOf(node).Range.End
+}
+
+// tokenOf devuelve el token principal de node.
+func tokenOf(node Node) token.Token {
+	switch node := node.(type) {
+	case *LetStatement:
+		return node.Token
+	case *ReturnStatement:
+		return node.Token
+	case *ThrowStatement:
+		return node.Token
+	case *ExportStatement:
+		return node.Token
+	case *BreakStatement:
+		return node.Token
+	case *ContinueStatement:
+		return node.Token
+	case *ExpressionStatement:
+		return node.Token
+	case *BlockStatement:
+		return node.Token
+	case *Identifier:
+		return node.Token
+	case *IntegerLiteral:
+		return node.Token
+	case *FloatLiteral:
+		return node.Token
+	case *StringLiteral:
+		return node.Token
+	case *InterpolatedString:
+		return node.Token
+	case *Boolean:
+		return node.Token
+	case *PrefixExpression:
+		return node.Token
+	case *InfixExpression:
+		return node.Token
+	case *PostfixExpression:
+		return node.Token
+	case *MemberExpression:
+		return node.Token
+	case *IfExpression:
+		return node.Token
+	case *ConditionalExpression:
+		return node.Token
+	case *FunctionLiteral:
+		return node.Token
+	case *CallExpression:
+		return node.Token
+	case *ArrayLiteral:
+		return node.Token
+	case *IndexExpression:
+		return node.Token
+	case *HashLiteral:
+		return node.Token
+	case *WhileExpression:
+		return node.Token
+	case *TryExpression:
+		return node.Token
+	case *ImportExpression:
+		return node.Token
+	}
+	return token.Token{}
+}
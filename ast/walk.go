@@ -0,0 +1,107 @@
+package ast
+
+import (
+	"reflect"
+)
+
+// Inspect recorre en profundidad el AST a partir de node, en el orden del
+// código fuente: llama a f con cada nodo y, si f devuelve true, continúa con
+// sus hijos. Los hijos ausentes, frecuentes en programas con errores de
+// sintaxis, se saltan.
+func Inspect(node Node, f func(Node) bool) {
+	if isNil(node) || !f(node) {
+		return
+	}
+	for _, child := range Children(node) {
+		Inspect(child, f)
+	}
+}
+
+// Children devuelve los hijos directos de node en el orden del código fuente,
+// sin los ausentes.
+func Children(node Node) []Node {
+	var children []Node
+	add := func(nodes ...Node) {
+		for _, n := range nodes {
+			if !isNil(n) {
+				children = append(children, n)
+			}
+		}
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		for _, stmt := range node.Statements {
+			add(stmt)
+		}
+	case *BlockStatement:
+		for _, stmt := range node.Statements {
+			add(stmt)
+		}
+	case *LetStatement:
+		add(node.Name, node.Value)
+	case *ReturnStatement:
+		add(node.ReturnValue)
+	case *ThrowStatement:
+		add(node.Value)
+	case *ExportStatement:
+		add(node.Statement)
+	case *ExpressionStatement:
+		add(node.Expression)
+	case *PrefixExpression:
+		add(node.Right)
+	case *InfixExpression:
+		add(node.Left, node.Right)
+	case *PostfixExpression:
+		add(node.Left)
+	case *MemberExpression:
+		add(node.Object, node.Property)
+	case *IfExpression:
+		add(node.Condition, node.Consequence, node.ElseIf, node.Alternative)
+	case *ConditionalExpression:
+		add(node.Condition, node.Consequence, node.Alternative)
+	case *FunctionLiteral:
+		for _, param := range node.Parameters {
+			add(param)
+		}
+		add(node.Body)
+	case *CallExpression:
+		add(node.Function)
+		for _, arg := range node.Arguments {
+			add(arg)
+		}
+	case *ArrayLiteral:
+		for _, element := range node.Elements {
+			add(element)
+		}
+	case *InterpolatedString:
+		for _, part := range node.Parts {
+			add(part)
+		}
+	case *IndexExpression:
+		add(node.Left, node.Index)
+	case *HashLiteral:
+		for _, key := range node.Keys {
+			if !isNil(key) {
+				add(key, node.Pairs[key])
+			}
+		}
+	case *WhileExpression:
+		add(node.Init, node.Condition, node.Post, node.Body)
+	case *TryExpression:
+		add(node.Block, node.CatchParameter, node.Catch, node.Finally)
+	case *ImportExpression:
+		add(node.Path)
+	}
+	return children
+}
+
+// isNil indica si node es nil o un puntero nil guardado en la interfaz, como
+// los que deja el parser al recuperarse de un error.
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
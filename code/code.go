@@ -0,0 +1,262 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Instructions es una secuencia de bytes con opcodes y sus operandos.
+type Instructions []byte
+
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+		i += 1 + read
+	}
+	return out.String()
+}
+
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", len(operands), operandCount)
+	}
+	switch operandCount {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
+}
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+	OpDup
+	OpDupPair // duplica los dos elementos del tope
+
+	OpNull
+	OpTrue
+	OpFalse
+
+	// Operadores infijos
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpPow
+	OpEqual
+	OpNotEqual
+	OpLessThan
+	OpGreaterThan
+	OpLessEqual
+	OpGreaterEqual
+	OpAddAssign // igual que OpAdd, pero los errores muestran "+="
+	OpSubAssign // igual que OpSub, pero los errores muestran "-="
+
+	// Operadores prefijos y de actualización
+	OpMinus
+	OpBang
+	OpIncrement
+	OpDecrement
+
+	// Saltos
+	OpJump
+	OpJumpNotTruthy
+
+	// Variables, resueltas al compilar: las globales por índice en los
+	// Binding del closure, las locales por índice en el frame y las de las
+	// funciones que encierran a la actual por profundidad e índice.
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	OpGetOuter
+
+	// Colecciones
+	OpArray
+	OpInterpolate // une en un string el texto de los n elementos del tope
+	OpHash
+	OpIndex
+	OpMember // clave de un hash o método ligado; el operando es el nombre
+
+	// Asignación sobre índices: la colección y el índice quedan en la pila
+	// debajo del valor.
+	OpIndexTarget    // valida colección e índice como destino de asignación
+	OpSetIndex       // colección[índice] = valor; deja el valor
+	OpIncrementIndex // ++ sobre colección[índice]; el operando indica si deja el valor previo
+	OpDecrementIndex // -- sobre colección[índice]; ídem
+
+	// Funciones
+	OpClosure
+	OpCall
+	OpReturnValue
+	OpReturn
+
+	// Bucles
+	OpNoValue // apila nil: el valor de un bucle que todavía no produjo ninguno
+	OpLoopValue
+
+	// Errores conocidos en compilación que deben reportarse al ejecutarse
+	OpError
+
+	// Manejo de errores
+	OpTry     // registra un handler; el operando es la dirección del catch
+	OpEndTry  // descarta el handler más reciente
+	OpThrow   // lanza el valor del tope (throw)
+	OpRethrow // relanza sin cambios el error capturado del tope (tras un finally)
+
+	// Módulos
+	OpImport // apila el módulo importado; el operando es la ruta
+	OpExport // exporta un nombre del módulo; el operando es el nombre
+)
+
+// Definition describe el nombre legible de un opcode y el ancho en bytes
+// de cada uno de sus operandos.
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+	OpDup:      {"OpDup", []int{}},
+	OpDupPair:  {"OpDupPair", []int{}},
+
+	OpNull:  {"OpNull", []int{}},
+	OpTrue:  {"OpTrue", []int{}},
+	OpFalse: {"OpFalse", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpPow:          {"OpPow", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpAddAssign:    {"OpAddAssign", []int{}},
+	OpSubAssign:    {"OpSubAssign", []int{}},
+
+	OpMinus:     {"OpMinus", []int{}},
+	OpBang:      {"OpBang", []int{}},
+	OpIncrement: {"OpIncrement", []int{}},
+	OpDecrement: {"OpDecrement", []int{}},
+
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+
+	OpGetGlobal: {"OpGetGlobal", []int{2}},
+	OpSetGlobal: {"OpSetGlobal", []int{2}},
+	OpGetLocal:  {"OpGetLocal", []int{2}},
+	OpSetLocal:  {"OpSetLocal", []int{2}},
+	OpGetOuter:  {"OpGetOuter", []int{1, 2}},
+
+	OpArray:       {"OpArray", []int{2}},
+	OpInterpolate: {"OpInterpolate", []int{2}},
+	OpHash:        {"OpHash", []int{2}},
+	OpIndex:       {"OpIndex", []int{}},
+	OpMember:      {"OpMember", []int{2}},
+
+	OpIndexTarget:    {"OpIndexTarget", []int{}},
+	OpSetIndex:       {"OpSetIndex", []int{}},
+	OpIncrementIndex: {"OpIncrementIndex", []int{1}},
+	OpDecrementIndex: {"OpDecrementIndex", []int{1}},
+
+	OpClosure:     {"OpClosure", []int{2}},
+	OpCall:        {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn:      {"OpReturn", []int{}},
+
+	OpNoValue:   {"OpNoValue", []int{}},
+	OpLoopValue: {"OpLoopValue", []int{}},
+
+	OpError: {"OpError", []int{2}},
+
+	OpTry:     {"OpTry", []int{2}},
+	OpEndTry:  {"OpEndTry", []int{}},
+	OpThrow:   {"OpThrow", []int{}},
+	OpRethrow: {"OpRethrow", []int{}},
+
+	OpImport: {"OpImport", []int{2}},
+	OpExport: {"OpExport", []int{2}},
+}
+
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+	return def, nil
+}
+
+// Make codifica un opcode junto con sus operandos en big endian.
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+	return instruction
+}
+
+// ReadOperands decodifica los operandos de una instrucción y devuelve
+// también la cantidad de bytes leídos.
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 { return binary.BigEndian.Uint16(ins) }
+
+func ReadUint8(ins Instructions) uint8 { return uint8(ins[0]) }
@@ -0,0 +1,28 @@
+package code
+
+import (
+	"go-rilla/ast"
+	"sort"
+)
+
+// Position asocia el offset de una instrucción con el nodo del AST que la
+// generó.
+type Position struct {
+	Offset int
+	Node   ast.Node
+}
+
+// Positions es la tabla de posiciones de un bloque de instrucciones,
+// ordenada por offset. Permite ubicar en el código fuente los errores
+// producidos al ejecutar el bytecode.
+type Positions []Position
+
+// NodeAt devuelve el nodo que generó la instrucción en offset, o nil si
+// no hay ninguno registrado.
+func (p Positions) NodeAt(offset int) ast.Node {
+	i := sort.Search(len(p), func(i int) bool { return p[i].Offset > offset })
+	if i == 0 {
+		return nil
+	}
+	return p[i-1].Node
+}
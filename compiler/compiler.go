@@ -0,0 +1,901 @@
+package compiler
+
+import (
+	"fmt"
+	"go-rilla/ast"
+	"go-rilla/code"
+	"go-rilla/object"
+)
+
+// Bytecode es el resultado de compilar un programa: las instrucciones del
+// nivel superior, el pool de constantes que éstas referencian, los nombres
+// de las variables globales, por índice, y las locales del nivel superior
+// (sólo los parámetros de los catch), como en object.CompiledFunction.
+type Bytecode struct {
+	Instructions code.Instructions
+	Constants    []object.Object
+	Positions    code.Positions
+	Globals      []string
+	Locals       []string
+	Captured     bool
+}
+
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+// CompilationScope agrupa las instrucciones de una función en compilación.
+type CompilationScope struct {
+	instructions        code.Instructions
+	positions           code.Positions
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+}
+
+// loop registra los saltos pendientes de break/continue del bucle actual.
+type loop struct {
+	breaks    []int
+	continues []int
+}
+
+// tryContext describe un try abierto. Un break, continue o return que sale
+// de él debe descartar sus handlers activos y ejecutar su finally.
+type tryContext struct {
+	handlers  int // handlers registrados en el punto que se está compilando
+	finally   *ast.BlockStatement
+	loopDepth int // cantidad de bucles abiertos al entrar al try
+}
+
+type Compiler struct {
+	constants []object.Object
+	names     map[string]int
+
+	// variables globales, por nombre y por índice.
+	globals     map[string]int
+	globalNames []string
+
+	// locales de la función en compilación. En el nivel superior la tabla
+	// sólo tiene los parámetros de los catch; el resto es global.
+	symbols *symbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	// nodo en compilación; cada instrucción emitida queda asociada a él
+	// para ubicar en el código los errores de ejecución.
+	node ast.Node
+
+	// bucles abiertos del scope actual; las funciones arrancan sin bucles
+	// igual que applyFunction reinicia loopDepth en el evaluador.
+	loops      []*loop
+	savedLoops [][]*loop
+
+	// try abiertos del scope actual, del más externo al más interno.
+	tries      []*tryContext
+	savedTries [][]*tryContext
+}
+
+func New() *Compiler {
+	return &Compiler{
+		constants: []object.Object{},
+		names:     make(map[string]int),
+		globals:   make(map[string]int),
+		symbols:   newSymbolTable(nil),
+		scopes:    []CompilationScope{{instructions: code.Instructions{}}},
+	}
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	// Cada función guarda el pool y las globales definitivos, así un
+	// closure sigue siendo invocable desde programas compilados después
+	// (p. ej. en el REPL).
+	for _, constant := range c.constants {
+		if fn, ok := constant.(*object.CompiledFunction); ok {
+			fn.Constants = c.constants
+			fn.Globals = c.globalNames
+		}
+	}
+	return &Bytecode{
+		Instructions: c.currentInstructions(),
+		Constants:    c.constants,
+		Positions:    c.scopes[c.scopeIndex].positions,
+		Globals:      c.globalNames,
+		Locals:       c.symbols.names,
+		Captured:     c.symbols.captured,
+	}
+}
+
+func (c *Compiler) Compile(node ast.Node) error {
+	parent := c.node
+	c.node = node
+	defer func() { c.node = parent }()
+
+	switch node := node.(type) {
+
+	// Statements
+	case *ast.Program:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+	case *ast.ExpressionStatement:
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+	case *ast.LetStatement:
+		if err := c.compileLetValue(node.Value); err != nil {
+			return err
+		}
+		c.emitSet(node.Name.Value)
+	case *ast.ReturnStatement:
+		if err := c.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		if err := c.exitTries(0); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+	case *ast.ExportStatement:
+		c.emit(code.OpExport, c.addName(node.Statement.Name.Value))
+		return c.Compile(node.Statement)
+	case *ast.ThrowStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpThrow)
+	case *ast.BreakStatement:
+		if len(c.loops) == 0 {
+			c.emitError("break statement outside of loop")
+			return nil
+		}
+		if err := c.exitTries(c.loopTries()); err != nil {
+			return err
+		}
+		current := c.loops[len(c.loops)-1]
+		current.breaks = append(current.breaks, c.emit(code.OpJump, 9999))
+	case *ast.ContinueStatement:
+		if len(c.loops) == 0 {
+			c.emitError("continue statement outside of loop")
+			return nil
+		}
+		if err := c.exitTries(c.loopTries()); err != nil {
+			return err
+		}
+		current := c.loops[len(c.loops)-1]
+		current.continues = append(current.continues, c.emit(code.OpJump, 9999))
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: node.Value}))
+	case *ast.FloatLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Float{Value: node.Value}))
+	case *ast.StringLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Value}))
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+	case *ast.Identifier:
+		c.emitGet(node.Value)
+	case *ast.PrefixExpression:
+		return c.compilePrefixExpression(node)
+	case *ast.InfixExpression:
+		return c.compileInfixExpression(node)
+	case *ast.PostfixExpression:
+		if target, ok := node.Left.(*ast.IndexExpression); ok {
+			return c.compileIndexUpdate(target, node.Operator, true)
+		}
+		identifier, ok := node.Left.(*ast.Identifier)
+		if !ok {
+			c.emitError("invalid postfix target: %s", node.Left.TokenLiteral())
+			return nil
+		}
+		if err := c.Compile(identifier); err != nil {
+			return err
+		}
+		c.emit(code.OpDup)
+		c.emitUpdateOperator(node.Operator)
+		c.emitSet(identifier.Value)
+	case *ast.MemberExpression:
+		if err := c.Compile(node.Object); err != nil {
+			return err
+		}
+		c.emit(code.OpMember, c.addName(node.Property.Value))
+	case *ast.ImportExpression:
+		c.emit(code.OpImport, c.addName(node.Path.Value))
+	case *ast.IfExpression:
+		return c.compileIfExpression(node)
+
+	case *ast.ConditionalExpression:
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+		jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+		if err := c.Compile(node.Consequence); err != nil {
+			return err
+		}
+		jumpPos := c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+		if err := c.Compile(node.Alternative); err != nil {
+			return err
+		}
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+	case *ast.WhileExpression:
+		return c.compileWhileExpression(node)
+	case *ast.TryExpression:
+		return c.compileTryExpression(node)
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if err := c.Compile(el); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(node.Elements))
+	case *ast.InterpolatedString:
+		for _, part := range node.Parts {
+			if err := c.Compile(part); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpInterpolate, len(node.Parts))
+	case *ast.HashLiteral:
+		// Como en el evaluador, los pares se evalúan en el orden del
+		// código, que es el orden del hash resultante.
+		for _, k := range node.Keys {
+			if err := c.Compile(k); err != nil {
+				return err
+			}
+			if err := c.Compile(node.Pairs[k]); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpHash, len(node.Pairs)*2)
+	case *ast.IndexExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpIndex)
+	case *ast.FunctionLiteral:
+		return c.compileFunctionLiteral(node)
+	case *ast.CallExpression:
+		// OpCall lleva la cantidad de argumentos en un solo byte.
+		if len(node.Arguments) > 255 {
+			return fmt.Errorf("too many arguments in call to %s: %d (the maximum is 255)",
+				node.Function.String(), len(node.Arguments))
+		}
+		if err := c.Compile(node.Function); err != nil {
+			return err
+		}
+		for _, a := range node.Arguments {
+			if err := c.Compile(a); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpCall, len(node.Arguments))
+	default:
+		return fmt.Errorf("unsupported node %T", node)
+	}
+	return nil
+}
+
+func (c *Compiler) compilePrefixExpression(node *ast.PrefixExpression) error {
+	if node.Operator == "++" || node.Operator == "--" {
+		if target, ok := node.Right.(*ast.IndexExpression); ok {
+			return c.compileIndexUpdate(target, node.Operator, false)
+		}
+		identifier, ok := node.Right.(*ast.Identifier)
+		if !ok {
+			c.emitError("invalid prefix target: %s", node.Right.TokenLiteral())
+			return nil
+		}
+		c.compileUpdate(identifier, node.Operator, false)
+		return nil
+	}
+
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	switch node.Operator {
+	case "!":
+		c.emit(code.OpBang)
+	case "-":
+		c.emit(code.OpMinus)
+	default:
+		return fmt.Errorf("unknown operator %s", node.Operator)
+	}
+	return nil
+}
+
+var infixOpcodes = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"**": code.OpPow,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLessThan,
+	">":  code.OpGreaterThan,
+	"<=": code.OpLessEqual,
+	">=": code.OpGreaterEqual,
+}
+
+func (c *Compiler) compileInfixExpression(node *ast.InfixExpression) error {
+	switch node.TokenLiteral() {
+	case "=":
+		if target, ok := node.Left.(*ast.IndexExpression); ok {
+			return c.compileIndexAssignment(node, target)
+		}
+		identifier, ok := node.Left.(*ast.Identifier)
+		if !ok {
+			c.emitError("invalid assignment target: %s", node.Left.TokenLiteral())
+			return nil
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		c.emit(code.OpDup)
+		c.emitSet(identifier.Value)
+		return nil
+	case "+=", "-=":
+		if target, ok := node.Left.(*ast.IndexExpression); ok {
+			return c.compileIndexAssignment(node, target)
+		}
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		if node.TokenLiteral() == "+=" {
+			c.emit(code.OpAddAssign)
+		} else {
+			c.emit(code.OpSubAssign)
+		}
+		// Como en el evaluador, el destino se valida después de operar.
+		identifier, ok := node.Left.(*ast.Identifier)
+		if !ok {
+			c.emitError("invalid assignment target: %s", node.Left.TokenLiteral())
+			return nil
+		}
+		c.emit(code.OpDup)
+		c.emitSet(identifier.Value)
+		return nil
+	case "&&", "||":
+		return c.compileLogicalExpression(node)
+	}
+
+	op, ok := infixOpcodes[node.Operator]
+	if !ok {
+		return fmt.Errorf("unknown operator %s", node.Operator)
+	}
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	c.emit(op)
+	return nil
+}
+
+// compileLogicalExpression compila && y || en cortocircuito. El valor del
+// lado izquierdo queda en la pila como resultado salvo que haya que evaluar
+// el derecho:
+//
+//	a && b: a; OpDup; OpJumpNotTruthy fin; OpPop; b; fin:
+//	a || b: a; OpDup; OpJumpNotTruthy der; OpJump fin; der: OpPop; b; fin:
+func (c *Compiler) compileLogicalExpression(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+	c.emit(code.OpDup)
+	endJumpPos := c.emit(code.OpJumpNotTruthy, 9999)
+	if node.Operator == "||" {
+		jumpNotTruthyPos := endJumpPos
+		endJumpPos = c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+	}
+	c.emit(code.OpPop)
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	c.changeOperand(endJumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// compileUpdate emite ++/-- sobre identifier. Si keepPrevious es true deja
+// en la pila el valor previo; si no, el valor actualizado.
+func (c *Compiler) compileUpdate(identifier *ast.Identifier, operator string, keepPrevious bool) {
+	c.emitGet(identifier.Value)
+	if keepPrevious {
+		c.emit(code.OpDup)
+	}
+	c.emitUpdateOperator(operator)
+	if !keepPrevious {
+		c.emit(code.OpDup)
+	}
+	c.emitSet(identifier.Value)
+}
+
+func (c *Compiler) emitUpdateOperator(operator string) {
+	if operator == "++" {
+		c.emit(code.OpIncrement)
+	} else {
+		c.emit(code.OpDecrement)
+	}
+}
+
+// compileIndexAssignment compila a[i] = v, a[i] += v y a[i] -= v. Como en
+// el evaluador, el destino se valida antes de evaluar el lado derecho.
+func (c *Compiler) compileIndexAssignment(node *ast.InfixExpression, target *ast.IndexExpression) error {
+	if err := c.Compile(target.Left); err != nil {
+		return err
+	}
+	if err := c.Compile(target.Index); err != nil {
+		return err
+	}
+	c.emit(code.OpIndexTarget)
+
+	compound := node.TokenLiteral() != "="
+	if compound {
+		c.emit(code.OpDupPair)
+		c.emit(code.OpIndex)
+	}
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	switch node.TokenLiteral() {
+	case "+=":
+		c.emit(code.OpAddAssign)
+	case "-=":
+		c.emit(code.OpSubAssign)
+	}
+	c.emit(code.OpSetIndex)
+	return nil
+}
+
+// compileIndexUpdate compila ++/-- sobre a[i]. Si keepPrevious es true deja
+// en la pila el valor previo; si no, el actualizado.
+func (c *Compiler) compileIndexUpdate(target *ast.IndexExpression, operator string, keepPrevious bool) error {
+	if err := c.Compile(target.Left); err != nil {
+		return err
+	}
+	if err := c.Compile(target.Index); err != nil {
+		return err
+	}
+	keep := 0
+	if keepPrevious {
+		keep = 1
+	}
+	if operator == "++" {
+		c.emit(code.OpIncrementIndex, keep)
+	} else {
+		c.emit(code.OpDecrementIndex, keep)
+	}
+	return nil
+}
+
+// compileLetValue replica evalLetValue: `let b = ++a` actualiza a pero
+// enlaza b con el valor previo.
+func (c *Compiler) compileLetValue(expr ast.Expression) error {
+	if prefix, ok := expr.(*ast.PrefixExpression); ok && (prefix.Operator == "++" || prefix.Operator == "--") {
+		if target, ok := prefix.Right.(*ast.IndexExpression); ok {
+			return c.compileIndexUpdate(target, prefix.Operator, true)
+		}
+		identifier, ok := prefix.Right.(*ast.Identifier)
+		if !ok {
+			c.emitError("invalid prefix target: %s", prefix.Right.TokenLiteral())
+			return nil
+		}
+		c.compileUpdate(identifier, prefix.Operator, true)
+		return nil
+	}
+	return c.Compile(expr)
+}
+
+func (c *Compiler) compileIfExpression(node *ast.IfExpression) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if err := c.compileBlockValue(node.Consequence); err != nil {
+		return err
+	}
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+	switch {
+	case node.ElseIf != nil:
+		if err := c.Compile(node.ElseIf); err != nil {
+			return err
+		}
+	case node.Alternative != nil:
+		if err := c.compileBlockValue(node.Alternative); err != nil {
+			return err
+		}
+	default:
+		c.emit(code.OpNull)
+	}
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// compileBlockValue compila un bloque dejando en la pila el valor de su
+// última sentencia (o null si no es una expresión).
+func (c *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	if err := c.Compile(block); err != nil {
+		return err
+	}
+	if c.lastInstructionIs(code.OpPop) && endsWithExpression(block) {
+		c.removeLastPop()
+	} else {
+		c.emit(code.OpNull)
+	}
+	return nil
+}
+
+func endsWithExpression(block *ast.BlockStatement) bool {
+	if len(block.Statements) == 0 {
+		return false
+	}
+	_, ok := block.Statements[len(block.Statements)-1].(*ast.ExpressionStatement)
+	return ok
+}
+
+// compileWhileExpression compila while y for. El valor del bucle (el de la
+// última iteración completa, como en evalWhileExpression) vive en la pila
+// debajo de las sentencias del cuerpo. Si ninguna iteración produjo un
+// valor, queda nil, igual que en el evaluador: el REPL no muestra nada.
+func (c *Compiler) compileWhileExpression(node *ast.WhileExpression) error {
+	if node.Init != nil {
+		if err := c.Compile(node.Init); err != nil {
+			return err
+		}
+	}
+
+	c.emit(code.OpNoValue)
+	conditionPos := len(c.currentInstructions())
+
+	jumpNotTruthyPos := -1
+	if node.Condition != nil {
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+		jumpNotTruthyPos = c.emit(code.OpJumpNotTruthy, 9999)
+	}
+
+	current := &loop{}
+	c.loops = append(c.loops, current)
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	c.loops = c.loops[:len(c.loops)-1]
+
+	if c.lastInstructionIs(code.OpPop) && endsWithExpression(node.Body) {
+		c.removeLastPop()
+		c.emit(code.OpLoopValue)
+	}
+
+	postPos := len(c.currentInstructions())
+	for _, pos := range current.continues {
+		c.changeOperand(pos, postPos)
+	}
+	if node.Post != nil {
+		if err := c.Compile(node.Post); err != nil {
+			return err
+		}
+	}
+	c.emit(code.OpJump, conditionPos)
+
+	endPos := len(c.currentInstructions())
+	if jumpNotTruthyPos >= 0 {
+		c.changeOperand(jumpNotTruthyPos, endPos)
+	}
+	for _, pos := range current.breaks {
+		c.changeOperand(pos, endPos)
+	}
+	return nil
+}
+
+// compileTryExpression compila try/catch/finally. El try registra un handler
+// que salta al catch con el error capturado en la pila. Con finally se
+// registra además un handler externo que lo ejecuta y relanza el error; en
+// la salida normal el finally se ejecuta en línea y su valor se descarta.
+func (c *Compiler) compileTryExpression(node *ast.TryExpression) error {
+	current := &tryContext{finally: node.Finally, loopDepth: len(c.loops)}
+	c.tries = append(c.tries, current)
+
+	finallyHandlerPos := -1
+	if node.Finally != nil {
+		finallyHandlerPos = c.emit(code.OpTry, 9999)
+		current.handlers++
+	}
+	catchHandlerPos := -1
+	if node.Catch != nil {
+		catchHandlerPos = c.emit(code.OpTry, 9999)
+		current.handlers++
+	}
+
+	if err := c.compileBlockValue(node.Block); err != nil {
+		return err
+	}
+
+	if node.Catch != nil {
+		c.emit(code.OpEndTry)
+		current.handlers--
+		jumpPos := c.emit(code.OpJump, 9999)
+
+		c.changeOperand(catchHandlerPos, len(c.currentInstructions()))
+		// Como en el evaluador, el parámetro sólo existe dentro del catch.
+		endCatch := func() {}
+		if node.CatchParameter != nil {
+			var index int
+			index, endCatch = c.symbols.defineBlock(node.CatchParameter.Value)
+			c.emit(code.OpSetLocal, index)
+		} else {
+			c.emit(code.OpPop)
+		}
+		err := c.compileBlockValue(node.Catch)
+		endCatch()
+		if err != nil {
+			return err
+		}
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+	}
+	c.tries = c.tries[:len(c.tries)-1]
+
+	if node.Finally != nil {
+		c.emit(code.OpEndTry)
+		if err := c.Compile(node.Finally); err != nil {
+			return err
+		}
+		jumpPos := c.emit(code.OpJump, 9999)
+
+		c.changeOperand(finallyHandlerPos, len(c.currentInstructions()))
+		if err := c.Compile(node.Finally); err != nil {
+			return err
+		}
+		c.emit(code.OpRethrow)
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+	}
+	return nil
+}
+
+// loopTries devuelve el índice del primer try abierto dentro del bucle más
+// interno: los que un break o continue debe cerrar.
+func (c *Compiler) loopTries() int {
+	i := len(c.tries)
+	for i > 0 && c.tries[i-1].loopDepth == len(c.loops) {
+		i--
+	}
+	return i
+}
+
+// exitTries emite la salida de los try abiertos desde depth hacia adentro,
+// del más interno al más externo: descarta sus handlers y ejecuta en línea
+// su finally.
+func (c *Compiler) exitTries(depth int) error {
+	tries := c.tries
+	defer func() { c.tries = tries }()
+
+	for i := len(tries) - 1; i >= depth; i-- {
+		for j := 0; j < tries[i].handlers; j++ {
+			c.emit(code.OpEndTry)
+		}
+		if tries[i].finally != nil {
+			c.tries = tries[:i]
+			if err := c.Compile(tries[i].finally); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compileFunctionLiteral compila una función. Sus variables locales son los
+// parámetros y todo nombre que asigne el cuerpo; se conocen antes de
+// compilarlo, así una lectura que aparece antes de la asignación también
+// es local. Si al ejecutarse la local todavía no tiene valor, la VM la
+// busca por nombre afuera, como haría el evaluador.
+func (c *Compiler) compileFunctionLiteral(node *ast.FunctionLiteral) error {
+	c.symbols.captured = true
+	c.enterScope()
+	for _, param := range node.Parameters {
+		c.symbols.define(param.Value)
+	}
+	for _, name := range assignedNames(node.Body) {
+		if _, ok := c.symbols.index[name]; !ok {
+			c.symbols.define(name)
+		}
+	}
+
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	if c.lastInstructionIs(code.OpPop) && endsWithExpression(node.Body) {
+		c.replaceLastPopWithReturn()
+	}
+	if !c.lastInstructionIs(code.OpReturnValue) {
+		c.emit(code.OpReturn)
+	}
+	locals, captured := c.symbols.names, c.symbols.captured
+	instructions, positions := c.leaveScope()
+
+	compiledFn := &object.CompiledFunction{
+		Instructions: instructions,
+		Positions:    positions,
+		Parameters:   node.Parameters,
+		Body:         node.Body,
+		Locals:       locals,
+		Captured:     captured,
+	}
+	c.emit(code.OpClosure, c.addConstant(compiledFn))
+	return nil
+}
+
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+// emitGet emite la lectura de la variable name: local de la función en
+// curso, de una función que la encierra o, si no, global.
+func (c *Compiler) emitGet(name string) {
+	depth, index, ok := c.symbols.resolve(name)
+	switch {
+	case !ok:
+		c.emit(code.OpGetGlobal, c.addGlobal(name))
+	case depth == 0:
+		c.emit(code.OpGetLocal, index)
+	default:
+		c.emit(code.OpGetOuter, depth, index)
+	}
+}
+
+// emitSet emite la asignación del tope de la pila a name. Como en el
+// evaluador, asignar siempre define la variable en la función en curso.
+func (c *Compiler) emitSet(name string) {
+	index, ok := c.symbols.index[name]
+	if !ok && c.symbols.outer == nil {
+		c.emit(code.OpSetGlobal, c.addGlobal(name))
+		return
+	}
+	if !ok {
+		index = c.symbols.define(name)
+	}
+	c.emit(code.OpSetLocal, index)
+}
+
+// addGlobal registra name entre las variables globales una única vez.
+func (c *Compiler) addGlobal(name string) int {
+	if idx, ok := c.globals[name]; ok {
+		return idx
+	}
+	c.globalNames = append(c.globalNames, name)
+	c.globals[name] = len(c.globalNames) - 1
+	return len(c.globalNames) - 1
+}
+
+// addName registra name en el pool de constantes una única vez.
+func (c *Compiler) addName(name string) int {
+	if idx, ok := c.names[name]; ok {
+		return idx
+	}
+	idx := c.addConstant(&object.String{Value: name})
+	c.names[name] = idx
+	return idx
+}
+
+// emitError emite un error que se reporta recién al ejecutar la instrucción,
+// preservando el orden de evaluación del evaluador.
+func (c *Compiler) emitError(format string, a ...interface{}) {
+	err := &object.Error{Message: fmt.Sprintf(format, a...)}
+	c.emit(code.OpError, c.addConstant(err))
+}
+
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	ins := code.Make(op, operands...)
+	pos := c.addInstruction(ins)
+	c.setLastInstruction(op, pos)
+	return pos
+}
+
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) addInstruction(ins []byte) int {
+	posNewInstruction := len(c.currentInstructions())
+	c.scopes[c.scopeIndex].instructions = append(c.currentInstructions(), ins...)
+	if c.node != nil {
+		c.scopes[c.scopeIndex].positions = append(c.scopes[c.scopeIndex].positions,
+			code.Position{Offset: posNewInstruction, Node: c.node})
+	}
+	return posNewInstruction
+}
+
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	previous := c.scopes[c.scopeIndex].lastInstruction
+	last := EmittedInstruction{Opcode: op, Position: pos}
+	c.scopes[c.scopeIndex].previousInstruction = previous
+	c.scopes[c.scopeIndex].lastInstruction = last
+}
+
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (c *Compiler) removeLastPop() {
+	last := c.scopes[c.scopeIndex].lastInstruction
+	previous := c.scopes[c.scopeIndex].previousInstruction
+	c.scopes[c.scopeIndex].instructions = c.currentInstructions()[:last.Position]
+	c.scopes[c.scopeIndex].lastInstruction = previous
+
+	positions := c.scopes[c.scopeIndex].positions
+	for len(positions) > 0 && positions[len(positions)-1].Offset >= last.Position {
+		positions = positions[:len(positions)-1]
+	}
+	c.scopes[c.scopeIndex].positions = positions
+}
+
+func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
+	ins := c.currentInstructions()
+	for i := 0; i < len(newInstruction); i++ {
+		ins[pos+i] = newInstruction[i]
+	}
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+	lastPos := c.scopes[c.scopeIndex].lastInstruction.Position
+	c.replaceInstruction(lastPos, code.Make(code.OpReturnValue))
+	c.scopes[c.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	op := code.Opcode(c.currentInstructions()[opPos])
+	newInstruction := code.Make(op, operand)
+	c.replaceInstruction(opPos, newInstruction)
+}
+
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, CompilationScope{instructions: code.Instructions{}})
+	c.scopeIndex++
+	c.savedLoops = append(c.savedLoops, c.loops)
+	c.loops = nil
+	c.savedTries = append(c.savedTries, c.tries)
+	c.tries = nil
+	c.symbols = newSymbolTable(c.symbols)
+}
+
+func (c *Compiler) leaveScope() (code.Instructions, code.Positions) {
+	instructions := c.currentInstructions()
+	positions := c.scopes[c.scopeIndex].positions
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+	c.loops = c.savedLoops[len(c.savedLoops)-1]
+	c.savedLoops = c.savedLoops[:len(c.savedLoops)-1]
+	c.tries = c.savedTries[len(c.savedTries)-1]
+	c.savedTries = c.savedTries[:len(c.savedTries)-1]
+	c.symbols = c.symbols.outer
+	return instructions, positions
+}
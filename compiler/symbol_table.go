@@ -0,0 +1,93 @@
+package compiler
+
+import "go-rilla/ast"
+
+// symbolTable guarda las variables locales de una función en compilación.
+// La tabla del nivel superior sólo tiene los parámetros de los catch: el
+// resto de sus variables son globales.
+type symbolTable struct {
+	outer    *symbolTable
+	index    map[string]int // índice de cada nombre
+	names    []string       // nombre de cada índice
+	captured bool           // si la función crea closures que las leen
+}
+
+func newSymbolTable(outer *symbolTable) *symbolTable {
+	return &symbolTable{outer: outer, index: make(map[string]int)}
+}
+
+// define agrega una variable local y devuelve su índice. Si name ya
+// existía, a partir de ahora se refiere a la nueva.
+func (s *symbolTable) define(name string) int {
+	s.names = append(s.names, name)
+	s.index[name] = len(s.names) - 1
+	return len(s.names) - 1
+}
+
+// defineBlock agrega una variable local visible sólo hasta llamar a end,
+// que devuelve a name lo que nombraba antes. La variable no tiene nombre
+// en names, así la VM no la encuentra al buscar por nombre fuera del
+// bloque.
+func (s *symbolTable) defineBlock(name string) (index int, end func()) {
+	previous, defined := s.index[name]
+	s.names = append(s.names, "")
+	index = len(s.names) - 1
+	s.index[name] = index
+	return index, func() {
+		if defined {
+			s.index[name] = previous
+		} else {
+			delete(s.index, name)
+		}
+	}
+}
+
+// resolve busca name entre las locales de esta función y de las que la
+// encierran. depth es la cantidad de funciones que hay que salir para
+// encontrarla: 0 si es local de ésta.
+func (s *symbolTable) resolve(name string) (depth, index int, ok bool) {
+	for table := s; table != nil; table = table.outer {
+		if index, ok := table.index[name]; ok {
+			return depth, index, true
+		}
+		depth++
+	}
+	return 0, 0, false
+}
+
+// assignedNames devuelve, en orden de aparición, los nombres que body
+// asigna con let, =, +=, -=, ++ o --, sin entrar en las funciones
+// anidadas. Como en el evaluador, asignar un nombre dentro de una función
+// siempre crea una variable local.
+func assignedNames(body *ast.BlockStatement) (names []string) {
+	seen := make(map[string]bool)
+	add := func(target ast.Expression) {
+		identifier, ok := target.(*ast.Identifier)
+		if ok && identifier != nil && !seen[identifier.Value] {
+			seen[identifier.Value] = true
+			names = append(names, identifier.Value)
+		}
+	}
+
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FunctionLiteral:
+			return false
+		case *ast.LetStatement:
+			add(node.Name)
+		case *ast.InfixExpression:
+			switch node.TokenLiteral() {
+			case "=", "+=", "-=":
+				add(node.Left)
+			}
+		case *ast.PrefixExpression:
+			if node.Operator == "++" || node.Operator == "--" {
+				add(node.Right)
+			}
+		case *ast.PostfixExpression:
+			add(node.Left)
+		}
+		return true
+	})
+	return names
+}
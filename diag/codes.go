@@ -0,0 +1,340 @@
+package diag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Códigos de los diagnósticos. Cada uno tiene una entrada en el catálogo
+// que imprime "-explain CODE".
+const (
+	// Lexer
+	CodeIllegalCharacter          = "LEX001"
+	CodeMalformedFloat            = "LEX002"
+	CodeUnterminatedString        = "LEX003"
+	CodeInvalidEscape             = "LEX004"
+	CodeInvalidUTF8               = "LEX005"
+	CodeMalformedNumber           = "LEX006"
+	CodeUnterminatedComment       = "LEX007"
+	CodeEscapeRange               = "LEX008"
+	CodeUnterminatedInterpolation = "LEX009"
+
+	// Parser
+	CodeExpectedToken      = "PAR001"
+	CodeNoPrefix           = "PAR002"
+	CodeTryHandler         = "PAR003"
+	CodeEmptyInterpolation = "PAR004"
+
+	// Literales
+	CodeIntegerLiteral = "LIT001"
+	CodeFloatLiteral   = "LIT002"
+
+	// Ejecución
+	CodeRuntime = "RUN001"
+	CodeLimit   = "RUN002"
+
+	// Módulos
+	CodeImportCycle    = "MOD001"
+	CodeModuleNotFound = "MOD002"
+	CodeModuleSyntax   = "MOD003"
+
+	// Linter
+	CodeUnused      = "LNT001"
+	CodeShadowed    = "LNT002"
+	CodeUnreachable = "LNT003"
+	CodeUndefined   = "LNT004"
+	CodeArity       = "LNT005"
+)
+
+// Entry describe un código de diagnóstico.
+type Entry struct {
+	Code        string
+	Level       Level  // nivel con el que se reporta
+	Title       string // resumen de una línea
+	Explanation string // qué significa y cómo corregirlo
+	Bad         string // código que lo produce
+	Good        string // el mismo código corregido
+}
+
+// String imprime la entrada completa, como la muestra "-explain".
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s[%s]: %s\n\n%s\n", e.Level, e.Code, e.Title, e.Explanation)
+	if e.Bad != "" {
+		fmt.Fprintf(&b, "\nErroneous code example:\n\n%s\n", indent(e.Bad))
+	}
+	if e.Good != "" {
+		fmt.Fprintf(&b, "\nCorrected example:\n\n%s\n", indent(e.Good))
+	}
+	return b.String()
+}
+
+func indent(code string) string {
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "    " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Lookup devuelve la entrada del catálogo de code. No distingue mayúsculas
+// de minúsculas.
+func Lookup(code string) (Entry, bool) {
+	e, ok := catalog[strings.ToUpper(code)]
+	return e, ok
+}
+
+// Entries devuelve todas las entradas del catálogo ordenadas por código.
+func Entries() []Entry {
+	entries := make([]Entry, 0, len(catalog))
+	for _, e := range catalog {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+	return entries
+}
+
+var catalog = map[string]Entry{}
+
+func register(entries ...Entry) {
+	for _, e := range entries {
+		catalog[e.Code] = e
+	}
+}
+
+func init() {
+	register(
+		Entry{
+			Code:  CodeIllegalCharacter,
+			Level: Error,
+			Title: "illegal character",
+			Explanation: "The source contains a character that does not start any token of the language,\n" +
+				"such as '@', '$' or a single '&'. Remove it or, if it is text, put it inside a string.",
+			Bad:  "let total = 5 @ 3;",
+			Good: "let total = 5 * 3;",
+		},
+		Entry{
+			Code:  CodeMalformedFloat,
+			Level: Error,
+			Title: "malformed float literal",
+			Explanation: "A number followed by a decimal point must have at least one digit after the point.\n" +
+				"Write the fractional part, even if it is zero.",
+			Bad:  "let half = 1.;",
+			Good: "let half = 1.0;",
+		},
+		Entry{
+			Code:  CodeUnterminatedString,
+			Level: Error,
+			Title: "string without closing quote",
+			Explanation: "A string literal reached the end of the file before its closing '\"'.\n" +
+				"Add the missing quote; to include a quote inside the string, escape it as \\\".",
+			Bad:  "let greeting = \"hello;",
+			Good: "let greeting = \"hello\";",
+		},
+		Entry{
+			Code:  CodeInvalidEscape,
+			Level: Error,
+			Title: "invalid escape sequence",
+			Explanation: "Inside a string a backslash must be followed by one of the supported escapes:\n" +
+				"\\\" \\\\ \\n \\t \\r \\$, \\x with exactly two hexadecimal digits, or \\u with one to six\n" +
+				"hexadecimal digits in braces, as in \\u{1F98D}. To write a literal backslash, double it.",
+			Bad:  "let path = \"C:\\temp\\data\";",
+			Good: "let path = \"C:\\\\temp\\\\data\";",
+		},
+		Entry{
+			Code:  CodeInvalidUTF8,
+			Level: Error,
+			Title: "invalid UTF-8 byte",
+			Explanation: "Source files must be encoded in UTF-8. The file contains a byte sequence that is\n" +
+				"not valid UTF-8, usually because it was saved in another encoding such as Latin-1.\n" +
+				"Convert the file to UTF-8.",
+		},
+		Entry{
+			Code:  CodeMalformedNumber,
+			Level: Error,
+			Title: "malformed number literal",
+			Explanation: "A number is immediately followed by a letter. Identifiers cannot start with a\n" +
+				"digit and numbers have no suffixes; separate the number from the name or rename it.",
+			Bad:  "let 2nd = 2;",
+			Good: "let second = 2;",
+		},
+		Entry{
+			Code:  CodeUnterminatedComment,
+			Level: Error,
+			Title: "unterminated block comment",
+			Explanation: "A block comment opened with '/*' was not closed before the end of the file.\n" +
+				"Block comments nest, so every '/*' inside the comment needs its own '*/'.",
+			Bad:  "/* outer /* inner */\nlet x = 1;",
+			Good: "/* outer /* inner */ */\nlet x = 1;",
+		},
+		Entry{
+			Code:  CodeEscapeRange,
+			Level: Error,
+			Title: "escape sequence out of range",
+			Explanation: "The value of a \\x or \\u escape is not a valid character. \\x only covers ASCII\n" +
+				"(\\x00 to \\x7F), so that strings stay valid UTF-8; \\u accepts code points from\n" +
+				"\\u{0} to \\u{10FFFF}, except the surrogates \\u{D800} to \\u{DFFF}.",
+			Bad:  "let gorilla = \"\\xF0\\u{110000}\";",
+			Good: "let gorilla = \"\\u{1F98D}\";",
+		},
+		Entry{
+			Code:  CodeUnterminatedInterpolation,
+			Level: Error,
+			Title: "unterminated string interpolation",
+			Explanation: "The file ended inside a ${...} interpolation. Close the expression with '}' and\n" +
+				"the string with '\"'. To write a literal \"${\" in a string, escape the dollar sign as \\$.",
+			Bad:  "let msg = \"total: ${a + b",
+			Good: "let msg = \"total: ${a + b}\";",
+		},
+		Entry{
+			Code:  CodeExpectedToken,
+			Level: Error,
+			Title: "unexpected token",
+			Explanation: "The parser expected a specific token, such as a closing parenthesis, a name or\n" +
+				"'=', and found another one. The cause is often a missing ')' or '}' or an\n" +
+				"unfinished expression on the previous line.",
+			Bad:  "if (x > 1 { print(x); }",
+			Good: "if (x > 1) { print(x); }",
+		},
+		Entry{
+			Code:  CodeNoPrefix,
+			Level: Error,
+			Title: "token cannot start an expression",
+			Explanation: "An expression was expected but the token found cannot begin one, for example an\n" +
+				"operator with no left operand or a stray closing bracket.",
+			Bad:  "let x = * 2;",
+			Good: "let x = 3 * 2;",
+		},
+		Entry{
+			Code:        CodeTryHandler,
+			Level:       Error,
+			Title:       "try without catch or finally",
+			Explanation: "A try block must be followed by a catch block, a finally block or both.",
+			Bad:         "try { risky(); }",
+			Good:        "try { risky(); } catch (e) { print(e); }",
+		},
+		Entry{
+			Code:  CodeEmptyInterpolation,
+			Level: Error,
+			Title: "empty string interpolation",
+			Explanation: "A ${} inside a string has no expression. Write the expression to insert or, to\n" +
+				"write a literal \"${\", escape the dollar sign as \\$.",
+			Bad:  "let msg = \"Hello ${}\";",
+			Good: "let msg = \"Hello ${name}\";",
+		},
+		Entry{
+			Code:  CodeIntegerLiteral,
+			Level: Error,
+			Title: "invalid integer literal",
+			Explanation: "The integer literal cannot be represented as a 64-bit signed integer.\n" +
+				"Integers range from -9223372036854775808 to 9223372036854775807.",
+			Bad:  "let big = 9223372036854775808;",
+			Good: "let big = 9223372036854775807;",
+		},
+		Entry{
+			Code:  CodeFloatLiteral,
+			Level: Error,
+			Title: "invalid float literal",
+			Explanation: "The float literal is too large to be represented as a 64-bit floating point\n" +
+				"number.",
+			Bad:  "let huge = 1" + strings.Repeat("0", 400) + ".0;",
+			Good: "let huge = 1.0;",
+		},
+		Entry{
+			Code:  CodeRuntime,
+			Level: Error,
+			Title: "runtime error",
+			Explanation: "The program failed while running: an unknown identifier, an operator applied to\n" +
+				"values of the wrong type, a call to something that is not a function, a value\n" +
+				"raised with throw, and so on. The notes show the call stack. Runtime errors can be\n" +
+				"caught with try/catch.",
+			Bad:  "let n = 1 + \"one\";",
+			Good: "let n = 1 + 1;",
+		},
+		Entry{
+			Code:  CodeLimit,
+			Level: Error,
+			Title: "resource limit exceeded",
+			Explanation: "The program exceeded one of the limits set by the host (evaluation steps, call\n" +
+				"depth or collection size) or its execution was cancelled. Unlike other runtime\n" +
+				"errors it cannot be caught with try/catch. Check for infinite loops or unbounded\n" +
+				"recursion.",
+			Bad:  "let loop = fn(n) { loop(n + 1) };\nloop(0);",
+			Good: "let count = fn(n) { if (n == 0) { 0 } else { count(n - 1) } };\ncount(10);",
+		},
+		Entry{
+			Code:  CodeImportCycle,
+			Level: Error,
+			Title: "import cycle",
+			Explanation: "A module imports itself, directly or through other modules. Each module is\n" +
+				"evaluated once, so a cycle can never finish. Move the shared code to a third\n" +
+				"module that both import.",
+			Bad:  "// a.monkey\nlet b = import \"b.monkey\";\n// b.monkey\nlet a = import \"a.monkey\";",
+			Good: "// a.monkey\nlet shared = import \"shared.monkey\";\n// b.monkey\nlet shared = import \"shared.monkey\";",
+		},
+		Entry{
+			Code:  CodeModuleNotFound,
+			Level: Error,
+			Title: "module not found",
+			Explanation: "The imported file does not exist or cannot be read. Paths are relative to the\n" +
+				"file that contains the import, not to the current directory.",
+			Bad:  "let m = import \"lib/missing.monkey\";",
+			Good: "let m = import \"lib/math.monkey\";",
+		},
+		Entry{
+			Code:  CodeModuleSyntax,
+			Level: Error,
+			Title: "syntax error in imported module",
+			Explanation: "The imported module has a syntax error, so it cannot be evaluated. The message\n" +
+				"shows the position of the first error inside the module; run it with -mode parser\n" +
+				"to see all of them.",
+		},
+		Entry{
+			Code:  CodeUnused,
+			Level: Warning,
+			Title: "unused variable",
+			Explanation: "A let declares a name that is never read. Remove it, or start its name with '_'\n" +
+				"if it is intentionally unused. Exported names are not reported.",
+			Bad:  "let unused = 1;\nprint(2);",
+			Good: "let used = 1;\nprint(used);",
+		},
+		Entry{
+			Code:  CodeShadowed,
+			Level: Warning,
+			Title: "shadowed declaration",
+			Explanation: "A declaration inside a function hides a name declared in an outer scope, so the\n" +
+				"outer one cannot be used there. Rename one of them.",
+			Bad:  "let x = 1;\nlet f = fn(x) { x * 2 };",
+			Good: "let x = 1;\nlet f = fn(y) { y * 2 };",
+		},
+		Entry{
+			Code:        CodeUnreachable,
+			Level:       Warning,
+			Title:       "unreachable code",
+			Explanation: "Statements after a return, break, continue or throw in the same block never run.",
+			Bad:         "let f = fn() { return 1; print(2); };",
+			Good:        "let f = fn() { print(2); return 1; };",
+		},
+		Entry{
+			Code:  CodeUndefined,
+			Level: Warning,
+			Title: "call to undefined function",
+			Explanation: "A call names a function that is neither declared nor a Built-In. It will fail\n" +
+				"at runtime with an unknown identifier error.",
+			Bad:  "prnt(1);",
+			Good: "print(1);",
+		},
+		Entry{
+			Code:  CodeArity,
+			Level: Warning,
+			Title: "wrong number of arguments to a Built-In",
+			Explanation: "A Built-In is called with too few or too many arguments. The hint shows its\n" +
+				"signature.",
+			Bad:  "len([1, 2], [3]);",
+			Good: "len([1, 2]);",
+		},
+	)
+}
@@ -31,4 +31,13 @@ type Diagnostic struct {
 	Message string       // mensaje principal
 	Hint    string       // sugerencia opcional
 	Range   source.Range // [start,end)
+	Related []Related    // mensajes secundarios, p. ej. la pila de llamadas
+}
+
+// Related es un mensaje secundario de un diagnóstico con su propia
+// ubicación. Se imprime como una nota ("note:").
+type Related struct {
+	Message string
+	Range   source.Range
+	File    string // archivo de Range si difiere del diagnóstico
 }
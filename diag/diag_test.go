@@ -0,0 +1,133 @@
+package diag_test
+
+import (
+	"go-rilla/diag"
+	"go-rilla/lexer"
+	"go-rilla/lint"
+	"go-rilla/parser"
+	goast "go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z]{3}[0-9]{3}$`)
+
+// TestEmittedCodesAreRegistered busca en el código fuente del módulo los
+// strings con forma de código y comprueba que estén en el catálogo.
+func TestEmittedCodesAreRegistered(t *testing.T) {
+	found := map[string]string{}
+	err := filepath.WalkDir("..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasPrefix(d.Name(), ".") && path != ".." {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := goparser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return err
+		}
+		goast.Inspect(file, func(n goast.Node) bool {
+			if lit, ok := n.(*goast.BasicLit); ok && lit.Kind == token.STRING {
+				if value, err := strconv.Unquote(lit.Value); err == nil && codePattern.MatchString(value) {
+					found[value] = path
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walking sources: %v", err)
+	}
+
+	for _, prefix := range []string{"LEX", "PAR", "LIT", "RUN", "MOD", "LNT"} {
+		seen := false
+		for code := range found {
+			seen = seen || strings.HasPrefix(code, prefix)
+		}
+		if !seen {
+			t.Errorf("no %s codes found in the sources", prefix)
+		}
+	}
+	for code, path := range found {
+		if _, ok := diag.Lookup(code); !ok {
+			t.Errorf("code %s used in %s is not registered", code, path)
+		}
+	}
+}
+
+func TestEntries(t *testing.T) {
+	entries := diag.Entries()
+	for i, e := range entries {
+		if i > 0 && entries[i-1].Code >= e.Code {
+			t.Errorf("entries not sorted: %s before %s", entries[i-1].Code, e.Code)
+		}
+		if !codePattern.MatchString(e.Code) || e.Title == "" || e.Explanation == "" {
+			t.Errorf("incomplete entry %+v", e)
+		}
+		if (e.Bad == "") != (e.Good == "") {
+			t.Errorf("%s: bad and good examples must come in pairs", e.Code)
+		}
+	}
+
+	e, ok := diag.Lookup("lex004")
+	if !ok || e.Code != diag.CodeInvalidEscape || e.Level != diag.Error {
+		t.Fatalf("Lookup(lex004) wrong. got=%+v, %v", e, ok)
+	}
+	text := e.String()
+	for _, want := range []string{"error[LEX004]: invalid escape sequence", "Erroneous code example:", "Corrected example:", "    let path"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("explanation missing %q:\n%s", want, text)
+		}
+	}
+}
+
+// TestExamples comprueba que los ejemplos de los códigos que se detectan sin
+// ejecutar el programa sean correctos: el malo produce el código con su
+// nivel y el bueno no.
+func TestExamples(t *testing.T) {
+	for _, e := range diag.Entries() {
+		if e.Bad == "" || strings.HasPrefix(e.Code, "RUN") || strings.HasPrefix(e.Code, "MOD") {
+			continue
+		}
+		if d, ok := find(analyze(e.Bad), e.Code); !ok {
+			t.Errorf("%s: bad example does not report it:\n%s", e.Code, e.Bad)
+		} else if d.Level != e.Level {
+			t.Errorf("%s: reported as %s, registered as %s", e.Code, d.Level, e.Level)
+		}
+		if _, ok := find(analyze(e.Good), e.Code); ok {
+			t.Errorf("%s: good example reports it:\n%s", e.Code, e.Good)
+		}
+	}
+}
+
+// analyze devuelve los diagnósticos de sintaxis o, si no hay, los del
+// linter, como -mode lint.
+func analyze(src string) []diag.Diagnostic {
+	l := lexer.New(src)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if ds := append(l.Diagnostics(), p.Diagnostics()...); len(ds) > 0 {
+		return ds
+	}
+	return lint.Check(program)
+}
+
+func find(ds []diag.Diagnostic, code string) (diag.Diagnostic, bool) {
+	for _, d := range ds {
+		if d.Code == code {
+			return d, true
+		}
+	}
+	return diag.Diagnostic{}, false
+}
@@ -1,6 +1,9 @@
 package evaluator
 
-import "go-rilla/object"
+import (
+	"go-rilla/object"
+	"sort"
+)
 
 var builtins = map[string]*object.Builtin{
 	"len": {
@@ -96,6 +99,31 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 
+	"freeze": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `freeze` must be ARRAY, got %s", args[0].Type())
+			}
+			return object.Freeze(args[0])
+		},
+	},
+
+	"json": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			encoded, err := object.ToJSON(args[0])
+			if err != nil {
+				return newError("%s", err)
+			}
+			return &object.String{Value: string(encoded)}
+		},
+	},
+
 	"print": {
 		Fn: func(args ...object.Object) object.Object {
 			for _, arg := range args {
@@ -105,3 +133,42 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 }
+
+// BuiltinInfo documenta un Built-In para las herramientas que analizan el
+// código sin ejecutarlo, como el servidor LSP.
+type BuiltinInfo struct {
+	Name      string
+	Signature string // p. ej. "push(array, value)"
+	Doc       string
+	MinArgs   int
+	MaxArgs   int // -1 si acepta cualquier cantidad de argumentos
+}
+
+var builtinInfo = []BuiltinInfo{
+	{"len", "len(value)", "Returns the number of elements of an array or the number of bytes of a string.", 1, 1},
+	{"first", "first(array)", "Returns the first element of array, or null if it is empty.", 1, 1},
+	{"last", "last(array)", "Returns the last element of array, or null if it is empty.", 1, 1},
+	{"rest", "rest(array)", "Returns a new array with every element of array but the first, or null if it is empty.", 1, 1},
+	{"push", "push(array, value)", "Returns a new array with the elements of array followed by value.", 2, 2},
+	{"freeze", "freeze(array)", "Returns an immutable copy of array, like the arrays used as hash keys.", 1, 1},
+	{"json", "json(value)", "Returns value encoded as JSON. Hash keys must be strings and keep their insertion order.", 1, 1},
+	{"print", "print(values...)", "Prints each value on its own line and returns null.", 0, -1},
+}
+
+// Builtins devuelve la documentación de los Built-In, ordenados por nombre.
+func Builtins() []BuiltinInfo {
+	infos := make([]BuiltinInfo, len(builtinInfo))
+	copy(infos, builtinInfo)
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// LookupBuiltinInfo devuelve la documentación del Built-In name.
+func LookupBuiltinInfo(name string) (BuiltinInfo, bool) {
+	for _, info := range builtinInfo {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return BuiltinInfo{}, false
+}
@@ -1,10 +1,12 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
 	"go-rilla/ast"
 	"go-rilla/object"
 	"math"
+	"strings"
 )
 
 var (
@@ -15,79 +17,140 @@ var (
 	continueSignal = &object.Continue{}
 )
 
-var loopDepth int
+// evalContext guarda el estado de una ejecución. Cada llamada a Eval crea
+// uno propio, por lo que varios programas pueden evaluarse en paralelo
+// siempre que no compartan entornos.
+type evalContext struct {
+	loopDepth int // bucles abiertos en la función en curso
+	callDepth int // llamadas a funciones anidadas
+	steps     int // nodos evaluados
 
+	ctx    context.Context // nil si la ejecución no puede cancelarse
+	limits Limits
+}
+
+// Eval evalúa node en env. Si el resultado es un error que todavía no tiene
+// ubicación, se le asigna el rango de node: así cada error queda anclado al
+// nodo más interno que lo produjo.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	c := &evalContext{}
+	return c.eval(node, env)
+}
+
+func (c *evalContext) eval(node ast.Node, env *object.Environment) object.Object {
+	var result object.Object
+	if err := c.step(); err != nil {
+		result = err
+	} else {
+		result = c.evalNode(node, env)
+		if err := c.checkSize(result); err != nil {
+			result = err
+		}
+	}
+	if errObj, ok := result.(*object.Error); ok && !errObj.HasRange() {
+		errObj.Range = ast.NodeRange(node)
+		errObj.File = modulePath(env)
+	}
+	return result
+}
+
+func (c *evalContext) evalNode(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 
 	// Statements
 	case *ast.Program:
-		return evalProgram(node, env)
+		return c.evalProgram(node, env)
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return c.eval(node.Expression, env)
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return c.evalBlockStatement(node, env)
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
-		if isError(val) {
+		val := c.eval(node.ReturnValue, env)
+		if isAbrupt(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
 	case *ast.LetStatement:
-		val := evalLetValue(node.Value, env)
-		if isError(val) {
+		val := c.evalLetValue(node.Value, env)
+		if isAbrupt(val) {
 			return val
 		}
 		env.Set(node.Name.Value, val)
+	case *ast.ExportStatement:
+		if err := exportName(env, node.Statement.Name.Value); err != nil {
+			return err
+		}
+		return c.eval(node.Statement, env)
+	case *ast.ThrowStatement:
+		val := c.eval(node.Value, env)
+		if isAbrupt(val) {
+			return val
+		}
+		return throwValue(val)
 	case *ast.BreakStatement:
-		if loopDepth == 0 {
+		if c.loopDepth == 0 {
 			return newError("break statement outside of loop")
 		}
 		return breakSignal
 	case *ast.ContinueStatement:
-		if loopDepth == 0 {
+		if c.loopDepth == 0 {
 			return newError("continue statement outside of loop")
 		}
 		return continueSignal
 	// Expressions
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return c.evalIfExpression(node, env)
+	case *ast.ConditionalExpression:
+		return c.evalConditionalExpression(node, env)
+	case *ast.TryExpression:
+		return c.evalTryExpression(node, env)
 	case *ast.PrefixExpression:
 		if node.Operator == "++" || node.Operator == "--" {
-			return evalPrefixUpdateExpression(node, env)
+			return c.evalPrefixUpdateExpression(node, env)
 		}
-		right := Eval(node.Right, env)
-		if isError(right) {
+		right := c.eval(node.Right, env)
+		if isAbrupt(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return evalPrefixExpression(node.Operator, right, c.limits.StrictIntegers)
 	case *ast.InfixExpression:
 		if node.Operator == "=" {
-			return evalAssignmentExpression(node, env)
+			return c.evalAssignmentExpression(node, env)
 		}
 		if isCompoundAssignment(node.TokenLiteral()) {
-			return evalCompoundAssignment(node, env)
+			return c.evalCompoundAssignment(node, env)
 		}
-		left := Eval(node.Left, env)
-		if isError(left) {
+		if node.Operator == "&&" || node.Operator == "||" {
+			return c.evalLogicalExpression(node, env)
+		}
+		left := c.eval(node.Left, env)
+		if isAbrupt(left) {
 			return left
 		}
-		right := Eval(node.Right, env)
-		if isError(right) {
+		right := c.eval(node.Right, env)
+		if isAbrupt(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right, node.Operator)
+		return evalInfixExpression(node.Operator, left, right, node.Operator, c.limits.StrictIntegers)
 	case *ast.PostfixExpression:
-		return evalPostfixExpression(node, env)
+		return c.evalPostfixExpression(node, env)
+	case *ast.MemberExpression:
+		receiver := c.eval(node.Object, env)
+		if isAbrupt(receiver) {
+			return receiver
+		}
+		return evalMemberExpression(receiver, node.Property.Value)
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 	case *ast.FloatLiteral:
 		return &object.Float{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return c.evalInterpolatedString(node, env)
 	case *ast.ArrayLiteral:
-		elements := evalExpressions(node.Elements, env)
-		if len(elements) == 1 && isError(elements[0]) {
+		elements := c.evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isAbrupt(elements[0]) {
 			return elements[0]
 		}
 		return &object.Array{Elements: elements}
@@ -100,29 +163,41 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		body := node.Body
 		return &object.Function{Parameters: params, Body: body, Env: env}
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
+		function := c.eval(node.Function, env)
+		if isAbrupt(function) {
 			return function
 		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
+		args := c.evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isAbrupt(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		result := c.applyFunction(function, args)
+		if errObj, ok := result.(*object.Error); ok {
+			if _, isFunction := function.(*object.Function); isFunction {
+				errObj.Stack = append(errObj.Stack, object.StackFrame{
+					Function: CalleeName(node.Function),
+					Range:    ast.NodeRange(node),
+					File:     modulePath(env),
+				})
+			}
+		}
+		return result
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
+		left := c.eval(node.Left, env)
+		if isAbrupt(left) {
 			return left
 		}
-		index := Eval(node.Index, env)
-		if isError(index) {
+		index := c.eval(node.Index, env)
+		if isAbrupt(index) {
 			return index
 		}
 		return evalIndexExpression(left, index)
 	case *ast.HashLiteral:
-		return evalHashLiteral(node, env)
+		return c.evalHashLiteral(node, env)
+	case *ast.ImportExpression:
+		return importModule(node.Path.Value, env)
 	case *ast.WhileExpression:
-		return evalWhileExpression(node, env)
+		return c.evalWhileExpression(node, env)
 	}
 
 	return nil
@@ -135,12 +210,12 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	return FALSE
 }
 
-func evalPrefixExpression(operator string, right object.Object) object.Object {
+func evalPrefixExpression(operator string, right object.Object, strict bool) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
-		return evalMinusPrefixOperatorExpression(right)
+		return evalMinusPrefixOperatorExpression(right, strict)
 	default:
 		return newError("unknown operator: %s %s", operator, right.Type())
 	}
@@ -159,36 +234,31 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 	}
 }
 
-func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ && right.Type() != object.FLOAT_OBJ {
+func evalMinusPrefixOperatorExpression(right object.Object, strict bool) object.Object {
+	if !isNumber(right) {
 		return newError("unknown operator: -%s", right.Type())
 	}
-	if right.Type() == object.INTEGER_OBJ {
-		value := right.(*object.Integer).Value
-		return &object.Integer{Value: -value}
+	if isInteger(right) {
+		return evalIntegerNegation(right, strict)
 	} else {
 		value := right.(*object.Float).Value
 		return &object.Float{Value: -value}
 	}
 }
 
-func evalInfixExpression(operator string, left, right object.Object, display string) object.Object {
+func evalInfixExpression(operator string, left, right object.Object, display string, strict bool) object.Object {
 	if display == "" {
 		display = operator
 	}
 	switch {
-	case (left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ) || (left.Type() == object.FLOAT_OBJ && right.Type() == object.FLOAT_OBJ) || (left.Type() == object.INTEGER_OBJ && right.Type() == object.FLOAT_OBJ) || (left.Type() == object.FLOAT_OBJ && right.Type() == object.INTEGER_OBJ):
-		return evalNumberInfixExpression(operator, left, right, display)
+	case isNumber(left) && isNumber(right):
+		return evalNumberInfixExpression(operator, left, right, display, strict)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right, display)
 	case left.Type() == object.BOOLEAN_OBJ && right.Type() == object.BOOLEAN_OBJ:
 		leftVal := left.(*object.Boolean).Value
 		rightVal := right.(*object.Boolean).Value
 		switch operator {
-		case "&&":
-			return nativeBoolToBooleanObject(leftVal && rightVal)
-		case "||":
-			return nativeBoolToBooleanObject(leftVal || rightVal)
 		case "==":
 			return nativeBoolToBooleanObject(leftVal == rightVal)
 		case "!=":
@@ -197,9 +267,15 @@ func evalInfixExpression(operator string, left, right object.Object, display str
 		return newError("unknown operator: %s %s %s",
 			left.Type(), display, right.Type())
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equal(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
+	case left.Type() == object.ARRAY_OBJ && right.Type() == object.ARRAY_OBJ && isOrdering(operator):
+		result, ok := object.Compare(left, right)
+		if !ok {
+			return newError("cannot compare %s %s %s", left.Inspect(), display, right.Inspect())
+		}
+		return evalOrdering(operator, result)
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s",
 			left.Type(), display, right.Type())
@@ -213,19 +289,23 @@ func isCompoundAssignment(tokenLiteral string) bool {
 	return tokenLiteral == "+=" || tokenLiteral == "-="
 }
 
-func evalCompoundAssignment(node *ast.InfixExpression, env *object.Environment) object.Object {
-	current := Eval(node.Left, env)
-	if isError(current) {
+func (c *evalContext) evalCompoundAssignment(node *ast.InfixExpression, env *object.Environment) object.Object {
+	if target, ok := node.Left.(*ast.IndexExpression); ok {
+		return c.evalIndexAssignment(node, target, env)
+	}
+
+	current := c.eval(node.Left, env)
+	if isAbrupt(current) {
 		return current
 	}
 
-	right := Eval(node.Right, env)
-	if isError(right) {
+	right := c.eval(node.Right, env)
+	if isAbrupt(right) {
 		return right
 	}
 
-	result := evalInfixExpression(node.Operator, current, right, node.TokenLiteral())
-	if isError(result) {
+	result := evalInfixExpression(node.Operator, current, right, node.TokenLiteral(), c.limits.StrictIntegers)
+	if isAbrupt(result) {
 		return result
 	}
 
@@ -238,79 +318,72 @@ func evalCompoundAssignment(node *ast.InfixExpression, env *object.Environment)
 	return result
 }
 
-func evalNumberInfixExpression(operator string, left, right object.Object, display string) object.Object {
-	var leftVal float64
-	var rightVal float64
-
-	if left.Type() == object.INTEGER_OBJ {
-		leftVal = float64(left.(*object.Integer).Value)
-	} else {
-		leftVal = left.(*object.Float).Value
+func evalNumberInfixExpression(operator string, left, right object.Object, display string, strict bool) object.Object {
+	if isInteger(left) && isInteger(right) {
+		return evalIntegerInfixExpression(operator, left, right, display, strict)
 	}
 
-	if right.Type() == object.INTEGER_OBJ {
-		rightVal = float64(right.(*object.Integer).Value)
-	} else {
-		rightVal = right.(*object.Float).Value
+	switch operator {
+	case "<", ">", "==", "!=", "<=", ">=":
+		return evalNumberComparison(operator, left, right)
 	}
-
+	leftVal, rightVal := toFloat(left), toFloat(right)
 	switch operator {
 	case "+":
-		if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
-			return &object.Integer{Value: int64(leftVal + rightVal)}
-		}
 		return &object.Float{Value: leftVal + rightVal}
 	case "-":
-		if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
-			return &object.Integer{Value: int64(leftVal - rightVal)}
-		}
 		return &object.Float{Value: leftVal - rightVal}
 	case "*":
-		if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
-			return &object.Integer{Value: int64(leftVal * rightVal)}
-		}
 		return &object.Float{Value: leftVal * rightVal}
 	case "/":
 		if rightVal == 0 {
 			return newError("division by zero")
 		}
-		if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
-			return &object.Integer{Value: int64(leftVal / rightVal)}
-		}
 		return &object.Float{Value: leftVal / rightVal}
 	case "**":
-		result := math.Pow(leftVal, rightVal)
-		if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
-			return &object.Integer{Value: int64(result)}
-		}
-		return &object.Float{Value: result}
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
 	case "%":
 		if rightVal == 0 {
 			return newError("division by zero")
 		}
-		if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
-			return &object.Integer{Value: int64(int64(leftVal) % int64(rightVal))}
-		}
 		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), display, right.Type())
+	}
+}
+
+// evalNumberComparison compara números con un float de por medio de forma
+// exacta, como object.Compare, y no convirtiendo a float64: así un entero
+// y un float dan lo mismo solos que dentro de un array. NaN no es igual a
+// nada ni se ordena con nada.
+func evalNumberComparison(operator string, left, right object.Object) object.Object {
+	result, ok := object.Compare(left, right)
+	if !ok {
+		return nativeBoolToBooleanObject(operator == "!=")
+	}
+	switch operator {
 	case "<":
-		return nativeBoolToBooleanObject(leftVal < rightVal)
+		return nativeBoolToBooleanObject(result < 0)
 	case ">":
-		return nativeBoolToBooleanObject(leftVal > rightVal)
+		return nativeBoolToBooleanObject(result > 0)
 	case "==":
-		return nativeBoolToBooleanObject(leftVal == rightVal)
+		return nativeBoolToBooleanObject(result == 0)
 	case "!=":
-		return nativeBoolToBooleanObject(leftVal != rightVal)
+		return nativeBoolToBooleanObject(result != 0)
 	case "<=":
-		return nativeBoolToBooleanObject(leftVal <= rightVal)
-	case ">=":
-		return nativeBoolToBooleanObject(leftVal >= rightVal)
+		return nativeBoolToBooleanObject(result <= 0)
 	default:
-		return newError("unknown operator: %s %s %s",
-			left.Type(), display, right.Type())
+		return nativeBoolToBooleanObject(result >= 0)
 	}
 }
 
-func evalPrefixUpdateExpression(node *ast.PrefixExpression, env *object.Environment) object.Object {
+func (c *evalContext) evalPrefixUpdateExpression(node *ast.PrefixExpression, env *object.Environment) object.Object {
+	if target, ok := node.Right.(*ast.IndexExpression); ok {
+		_, updated := c.evalIndexUpdate(target, node.Operator, env)
+		return updated
+	}
+
 	ident, ok := node.Right.(*ast.Identifier)
 	if !ok {
 		return newError("invalid prefix target: %s", node.Right.TokenLiteral())
@@ -321,41 +394,22 @@ func evalPrefixUpdateExpression(node *ast.PrefixExpression, env *object.Environm
 		return newError("identifier not found: %s", ident.Value)
 	}
 
-	var result object.Object
-
-	switch node.Operator {
-	case "++":
-		switch current.Type() {
-		case object.INTEGER_OBJ:
-			value := current.(*object.Integer).Value + 1
-			result = &object.Integer{Value: value}
-		case object.FLOAT_OBJ:
-			value := current.(*object.Float).Value + 1
-			result = &object.Float{Value: value}
-		default:
-			return newError("unknown operator: %s%s", current.Type(), node.Operator)
-		}
-	case "--":
-		switch current.Type() {
-		case object.INTEGER_OBJ:
-			value := current.(*object.Integer).Value - 1
-			result = &object.Integer{Value: value}
-		case object.FLOAT_OBJ:
-			value := current.(*object.Float).Value - 1
-			result = &object.Float{Value: value}
-		default:
-			return newError("unknown operator: %s%s", current.Type(), node.Operator)
-		}
-	default:
-		return newError("unknown operator: %s%s", current.Type(), node.Operator)
+	result := evalUpdateOperation(node.Operator, current, c.limits.StrictIntegers)
+	if isAbrupt(result) {
+		return result
 	}
 
 	env.Set(ident.Value, result)
 	return result
 }
 
-func evalLetValue(expr ast.Expression, env *object.Environment) object.Object {
+func (c *evalContext) evalLetValue(expr ast.Expression, env *object.Environment) object.Object {
 	if prefix, ok := expr.(*ast.PrefixExpression); ok && (prefix.Operator == "++" || prefix.Operator == "--") {
+		if target, ok := prefix.Right.(*ast.IndexExpression); ok {
+			previous, _ := c.evalIndexUpdate(target, prefix.Operator, env)
+			return previous
+		}
+
 		ident, ok := prefix.Right.(*ast.Identifier)
 		if !ok {
 			return newError("invalid prefix target: %s", prefix.Right.TokenLiteral())
@@ -366,40 +420,30 @@ func evalLetValue(expr ast.Expression, env *object.Environment) object.Object {
 			return newError("identifier not found: %s", ident.Value)
 		}
 
-		var updated object.Object
-
-		switch prefix.Operator {
-		case "++":
-			switch current.Type() {
-			case object.INTEGER_OBJ:
-				value := current.(*object.Integer).Value + 1
-				updated = &object.Integer{Value: value}
-			case object.FLOAT_OBJ:
-				value := current.(*object.Float).Value + 1
-				updated = &object.Float{Value: value}
-			default:
-				return newError("unknown operator: %s%s", current.Type(), prefix.Operator)
-			}
-		case "--":
-			switch current.Type() {
-			case object.INTEGER_OBJ:
-				value := current.(*object.Integer).Value - 1
-				updated = &object.Integer{Value: value}
-			case object.FLOAT_OBJ:
-				value := current.(*object.Float).Value - 1
-				updated = &object.Float{Value: value}
-			default:
-				return newError("unknown operator: %s%s", current.Type(), prefix.Operator)
-			}
-		default:
-			return newError("unknown operator: %s%s", current.Type(), prefix.Operator)
+		updated := evalUpdateOperation(prefix.Operator, current, c.limits.StrictIntegers)
+		if isAbrupt(updated) {
+			return updated
 		}
 
 		env.Set(ident.Value, updated)
 		return current
 	}
 
-	return Eval(expr, env)
+	return c.eval(expr, env)
+}
+
+// evalInterpolatedString une el texto de cada parte: el valor de los
+// strings y el Inspect() de los demás objetos.
+func (c *evalContext) evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+	for _, part := range node.Parts {
+		value := c.eval(part, env)
+		if isAbrupt(value) {
+			return value
+		}
+		out.WriteString(value.Inspect())
+	}
+	return &object.String{Value: out.String()}
 }
 
 func evalStringInfixExpression(
@@ -408,31 +452,85 @@ func evalStringInfixExpression(
 	display string,
 ) object.Object {
 
-	if operator != "+" {
-		return newError("unknown operator: %s %s %s",
-			left.Type(), display, right.Type())
-	}
-
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
-	return &object.String{Value: leftVal + rightVal}
+	switch {
+	case operator == "+":
+		return &object.String{Value: leftVal + rightVal}
+	case operator == "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	case isOrdering(operator):
+		return evalOrdering(operator, strings.Compare(leftVal, rightVal))
+	}
+	return newError("unknown operator: %s %s %s",
+		left.Type(), display, right.Type())
+}
+
+func isOrdering(operator string) bool {
+	return operator == "<" || operator == ">" || operator == "<=" || operator == ">="
+}
+
+// evalOrdering aplica un operador de orden al resultado de una comparación
+// (negativo, cero o positivo).
+func evalOrdering(operator string, result int) object.Object {
+	switch operator {
+	case "<":
+		return nativeBoolToBooleanObject(result < 0)
+	case ">":
+		return nativeBoolToBooleanObject(result > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(result <= 0)
+	default:
+		return nativeBoolToBooleanObject(result >= 0)
+	}
 }
 
-func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(ie.Condition, env)
-	if isError(condition) {
+func (c *evalContext) evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := c.eval(ie.Condition, env)
+	if isAbrupt(condition) {
 		return condition
 	}
 
 	if isTruthy(condition) {
-		return Eval(ie.Consequence, env)
+		return c.eval(ie.Consequence, env)
+	} else if ie.ElseIf != nil {
+		return c.eval(ie.ElseIf, env)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		return c.eval(ie.Alternative, env)
 	} else {
 		return NULL
 	}
 }
 
+func (c *evalContext) evalConditionalExpression(ce *ast.ConditionalExpression, env *object.Environment) object.Object {
+	condition := c.eval(ce.Condition, env)
+	if isAbrupt(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return c.eval(ce.Consequence, env)
+	}
+	return c.eval(ce.Alternative, env)
+}
+
+// evalLogicalExpression evalúa && y || en cortocircuito: el lado derecho
+// sólo se evalúa si el izquierdo no decide el resultado. Como en las
+// condiciones, cualquier valor sirve de operando, y el resultado es el
+// último operando evaluado: `a || b` vale a si a es verdadero y b si no.
+func (c *evalContext) evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := c.eval(node.Left, env)
+	if isAbrupt(left) {
+		return left
+	}
+	if isTruthy(left) == (node.Operator == "||") {
+		return left
+	}
+	return c.eval(node.Right, env)
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
@@ -446,9 +544,9 @@ func isTruthy(obj object.Object) bool {
 	}
 }
 
-func evalWhileExpression(node *ast.WhileExpression, env *object.Environment) object.Object {
+func (c *evalContext) evalWhileExpression(node *ast.WhileExpression, env *object.Environment) object.Object {
 	if node.Init != nil {
-		result := Eval(node.Init, env)
+		result := c.eval(node.Init, env)
 		if shouldHaltLoop(result) {
 			return result
 		}
@@ -458,7 +556,7 @@ func evalWhileExpression(node *ast.WhileExpression, env *object.Environment) obj
 
 	for {
 		if node.Condition != nil {
-			condition := Eval(node.Condition, env)
+			condition := c.eval(node.Condition, env)
 			if shouldHaltLoop(condition) {
 				return condition
 			}
@@ -467,9 +565,9 @@ func evalWhileExpression(node *ast.WhileExpression, env *object.Environment) obj
 			}
 		}
 
-		loopDepth++
-		bodyResult := Eval(node.Body, env)
-		loopDepth--
+		c.loopDepth++
+		bodyResult := c.eval(node.Body, env)
+		c.loopDepth--
 
 		if isBreak(bodyResult) {
 			return loopResult
@@ -485,7 +583,7 @@ func evalWhileExpression(node *ast.WhileExpression, env *object.Environment) obj
 		}
 
 		if node.Post != nil {
-			postResult := Eval(node.Post, env)
+			postResult := c.eval(node.Post, env)
 			if shouldHaltLoop(postResult) {
 				return postResult
 			}
@@ -521,14 +619,18 @@ func isContinue(obj object.Object) bool {
 	return obj.Type() == object.CONTINUE_OBJ
 }
 
-func evalAssignmentExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+func (c *evalContext) evalAssignmentExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	if target, ok := node.Left.(*ast.IndexExpression); ok {
+		return c.evalIndexAssignment(node, target, env)
+	}
+
 	identifier, ok := node.Left.(*ast.Identifier)
 	if !ok {
 		return newError("invalid assignment target: %s", node.Left.TokenLiteral())
 	}
 
-	value := Eval(node.Right, env)
-	if isError(value) {
+	value := c.eval(node.Right, env)
+	if isAbrupt(value) {
 		return value
 	}
 
@@ -536,11 +638,11 @@ func evalAssignmentExpression(node *ast.InfixExpression, env *object.Environment
 	return value
 }
 
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+func (c *evalContext) evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, statement := range program.Statements {
-		result = Eval(statement, env)
+		result = c.eval(statement, env)
 
 		switch result := result.(type) {
 		case *object.ReturnValue:
@@ -556,11 +658,11 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	return result
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+func (c *evalContext) evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, statement := range block.Statements {
-		result = Eval(statement, env)
+		result = c.eval(statement, env)
 
 		if result != nil {
 			rt := result.Type()
@@ -579,6 +681,20 @@ func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// isAbrupt informa si obj corta la evaluación de la expresión que lo
+// produjo: un error o, desde un bloque usado como valor, un return, break o
+// continue, que sigue hasta su función o su bucle.
+func isAbrupt(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+	switch obj.Type() {
+	case object.ERROR_OBJ, object.RETURN_VALUE_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+		return true
+	}
+	return false
+}
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -601,15 +717,15 @@ func evalIdentifier(
 	return newError("identifier not found: %s", node.Value)
 }
 
-func evalExpressions(
+func (c *evalContext) evalExpressions(
 	exps []ast.Expression,
 	env *object.Environment,
 ) []object.Object {
 	var result []object.Object
 
 	for _, e := range exps {
-		evaluated := Eval(e, env)
-		if isError(evaluated) {
+		evaluated := c.eval(e, env)
+		if isAbrupt(evaluated) {
 			return []object.Object{evaluated}
 		}
 		result = append(result, evaluated)
@@ -618,14 +734,23 @@ func evalExpressions(
 	return result
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+func (c *evalContext) applyFunction(fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
+		// Como en la VM, sobrar argumentos no es un error; faltar, sí.
+		if len(args) < len(fn.Parameters) {
+			return newError("wrong number of arguments: want=%d, got=%d",
+				len(fn.Parameters), len(args))
+		}
+		defer func() { c.callDepth-- }()
+		if err := c.enterCall(); err != nil {
+			return err
+		}
 		extendedEnv := extendFunctionEnv(fn, args)
-		savedLoopDepth := loopDepth
-		loopDepth = 0
-		evaluated := Eval(fn.Body, extendedEnv)
-		loopDepth = savedLoopDepth
+		savedLoopDepth := c.loopDepth
+		c.loopDepth = 0
+		evaluated := c.eval(fn.Body, extendedEnv)
+		c.loopDepth = savedLoopDepth
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
 		return fn.Fn(args...)
@@ -670,85 +795,283 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	return arrayObject.Elements[idx]
 }
 
-func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+func (c *evalContext) evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash(len(node.Pairs))
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
-		if isError(key) {
+	for _, keyNode := range node.Keys {
+		key := c.eval(keyNode, env)
+		if isAbrupt(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.HashKeyOf(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
-		if isError(value) {
+		value := c.eval(node.Pairs[keyNode], env)
+		if isAbrupt(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey, object.HashPair{Key: key, Value: value})
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
-	key, ok := index.(object.Hashable)
+	key, ok := object.HashKeyOf(index)
 	if !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Get(key)
 	if !ok {
 		return NULL
 	}
 	return pair.Value
 }
 
-func evalPostfixExpression(node *ast.PostfixExpression, env *object.Environment) object.Object {
+func (c *evalContext) evalPostfixExpression(node *ast.PostfixExpression, env *object.Environment) object.Object {
+	if target, ok := node.Left.(*ast.IndexExpression); ok {
+		previous, _ := c.evalIndexUpdate(target, node.Operator, env)
+		return previous
+	}
+
 	identifier, ok := node.Left.(*ast.Identifier)
 	if !ok {
 		return newError("invalid postfix target: %s", node.Left.TokenLiteral())
 	}
 
-	current := Eval(node.Left, env)
-	if isError(current) {
+	current := c.eval(node.Left, env)
+	if isAbrupt(current) {
 		return current
 	}
 
-	var result object.Object
+	result := evalUpdateOperation(node.Operator, current, c.limits.StrictIntegers)
+	if isAbrupt(result) {
+		return result
+	}
+
+	env.Set(identifier.Value, result)
+	return current
+}
 
-	switch node.Operator {
+func evalUpdateOperation(operator string, current object.Object, strict bool) object.Object {
+	var delta int64
+	switch operator {
 	case "++":
-		switch current.Type() {
-		case object.INTEGER_OBJ:
-			value := current.(*object.Integer).Value + 1
-			result = &object.Integer{Value: value}
-		case object.FLOAT_OBJ:
-			value := current.(*object.Float).Value + 1
-			result = &object.Float{Value: value}
-		default:
-			return newError("unknown operator: %s%s", current.Type(), node.Operator)
-		}
+		delta = 1
 	case "--":
-		switch current.Type() {
-		case object.INTEGER_OBJ:
-			value := current.(*object.Integer).Value - 1
-			result = &object.Integer{Value: value}
-		case object.FLOAT_OBJ:
-			value := current.(*object.Float).Value - 1
-			result = &object.Float{Value: value}
-		default:
-			return newError("unknown operator: %s%s", current.Type(), node.Operator)
+		delta = -1
+	default:
+		return newError("unknown operator: %s%s", current.Type(), operator)
+	}
+
+	switch current := current.(type) {
+	case *object.Integer, *object.BigInt:
+		return evalIntegerInfixExpression("+", current, &object.Integer{Value: delta}, "+", strict)
+	case *object.Float:
+		return &object.Float{Value: current.Value + float64(delta)}
+	default:
+		return newError("unknown operator: %s%s", current.Type(), operator)
+	}
+}
+
+// evalIndexAssignment evalúa a[i] = v, a[i] += v y a[i] -= v modificando en
+// el lugar el array o hash a. El destino se valida antes de evaluar el lado
+// derecho.
+func (c *evalContext) evalIndexAssignment(node *ast.InfixExpression, target *ast.IndexExpression, env *object.Environment) object.Object {
+	collection := c.eval(target.Left, env)
+	if isAbrupt(collection) {
+		return collection
+	}
+	index := c.eval(target.Index, env)
+	if isAbrupt(index) {
+		return index
+	}
+	if err := checkIndexAssignment(collection, index); err != nil {
+		return err
+	}
+
+	var value object.Object
+	if isCompoundAssignment(node.TokenLiteral()) {
+		current := evalIndexExpression(collection, index)
+		right := c.eval(node.Right, env)
+		if isAbrupt(right) {
+			return right
+		}
+		value = evalInfixExpression(node.Operator, current, right, node.TokenLiteral(), c.limits.StrictIntegers)
+	} else {
+		value = c.eval(node.Right, env)
+	}
+	if isAbrupt(value) {
+		return value
+	}
+
+	setIndex(collection, index, value)
+	return value
+}
+
+// evalIndexUpdate aplica ++ o -- sobre a[i] y devuelve el valor previo y el
+// actualizado. Si algo falla, ambos son el error.
+func (c *evalContext) evalIndexUpdate(target *ast.IndexExpression, operator string, env *object.Environment) (previous, updated object.Object) {
+	collection := c.eval(target.Left, env)
+	if isAbrupt(collection) {
+		return collection, collection
+	}
+	index := c.eval(target.Index, env)
+	if isAbrupt(index) {
+		return index, index
+	}
+	return indexUpdate(operator, collection, index, c.limits.StrictIntegers)
+}
+
+func indexUpdate(operator string, collection, index object.Object, strict bool) (previous, updated object.Object) {
+	if err := checkIndexAssignment(collection, index); err != nil {
+		return err, err
+	}
+
+	current := evalIndexExpression(collection, index)
+	result := evalUpdateOperation(operator, current, strict)
+	if isAbrupt(result) {
+		return result, result
+	}
+
+	setIndex(collection, index, result)
+	return current, result
+}
+
+// checkIndexAssignment verifica que collection[index] sea un destino de
+// asignación válido.
+func checkIndexAssignment(collection, index object.Object) *object.Error {
+	switch collection := collection.(type) {
+	case *object.Array:
+		if collection.Frozen {
+			return newError("cannot modify a frozen array")
+		}
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(collection.Elements)) {
+			return newError("index out of range: %d (length %d)", idx.Value, len(collection.Elements))
+		}
+	case *object.Hash:
+		if _, ok := object.HashKeyOf(index); !ok {
+			return newError("unusable as hash key: %s", index.Type())
 		}
 	default:
-		return newError("unknown operator: %s%s", current.Type(), node.Operator)
+		return newError("index assignment not supported: %s", collection.Type())
 	}
+	return nil
+}
 
-	env.Set(identifier.Value, result)
-	return current
+// setIndex escribe value en collection[index], ya validado con
+// checkIndexAssignment.
+func setIndex(collection, index, value object.Object) {
+	switch collection := collection.(type) {
+	case *object.Array:
+		collection.Elements[index.(*object.Integer).Value] = value
+	case *object.Hash:
+		key, _ := object.HashKeyOf(index)
+		collection.Set(key, object.HashPair{Key: index, Value: value})
+	}
+}
+
+// evalTryExpression evalúa el bloque try; si produce un error y hay catch, el
+// error se liga al parámetro como *object.ErrorValue y el valor de la
+// expresión es el del catch. El finally se ejecuta siempre; su valor se
+// descarta salvo que sea un error, un return, un break o un continue, que
+// reemplazan al resultado.
+func (c *evalContext) evalTryExpression(node *ast.TryExpression, env *object.Environment) object.Object {
+	result := c.eval(node.Block, env)
+	if isLimitError(result) {
+		return result
+	}
+
+	if errObj, ok := result.(*object.Error); ok && node.Catch != nil {
+		// El parámetro sólo existe dentro del catch; lo demás que se
+		// asigne en él sigue llegando a env.
+		catchEnv := env
+		if node.CatchParameter != nil {
+			catchEnv = object.NewBlockEnvironment(env)
+			catchEnv.Define(node.CatchParameter.Value, &object.ErrorValue{Err: errObj})
+		}
+		result = c.eval(node.Catch, catchEnv)
+		if isLimitError(result) {
+			return result
+		}
+	}
+
+	if node.Finally != nil {
+		switch finally := c.eval(node.Finally, env).(type) {
+		case *object.Error, *object.ReturnValue, *object.Break, *object.Continue:
+			return finally
+		}
+	}
+	return result
+}
+
+// throwValue convierte el valor de un throw en el error que se propaga. Un
+// string es el mensaje; un hash puede definir "message" y "kind"; un error
+// capturado se relanza conservando su tipo y ubicación.
+func throwValue(value object.Object) *object.Error {
+	switch value := value.(type) {
+	case *object.ErrorValue:
+		return &object.Error{
+			Message: value.Err.Message,
+			Kind:    value.Err.Kind,
+			Code:    value.Err.Code,
+			Value:   value.Err.Value,
+			Range:   value.Err.Range,
+			File:    value.Err.File,
+		}
+	case *object.String:
+		return &object.Error{Message: value.Value, Kind: object.THROWN_ERROR, Value: value}
+	case *object.Hash:
+		err := &object.Error{Message: value.Inspect(), Kind: object.THROWN_ERROR, Value: value}
+		if message, ok := hashStringField(value, "message"); ok {
+			err.Message = message
+		}
+		if kind, ok := hashStringField(value, "kind"); ok {
+			err.Kind = kind
+		}
+		return err
+	}
+	return &object.Error{Message: value.Inspect(), Kind: object.THROWN_ERROR, Value: value}
+}
+
+func hashStringField(hash *object.Hash, name string) (string, bool) {
+	key := &object.String{Value: name}
+	pair, ok := hash.Get(key.HashKey())
+	if !ok {
+		return "", false
+	}
+	str, ok := pair.Value.(*object.String)
+	if !ok {
+		return "", false
+	}
+	return str.Value, true
+}
+
+// errorMember devuelve el miembro name de un error capturado.
+func errorMember(err *object.Error, name string) (object.Object, bool) {
+	switch name {
+	case "message":
+		return &object.String{Value: err.Message}, true
+	case "kind":
+		return &object.String{Value: err.KindName()}, true
+	case "line":
+		return &object.Integer{Value: int64(err.Range.Start.Line)}, true
+	case "column":
+		return &object.Integer{Value: int64(err.Range.Start.Column)}, true
+	case "value":
+		if err.Value == nil {
+			return NULL, true
+		}
+		return err.Value, true
+	}
+	return nil, false
 }
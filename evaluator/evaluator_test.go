@@ -1,10 +1,17 @@
 package evaluator
 
 import (
+	"context"
+	"go-rilla/ast"
 	"go-rilla/lexer"
 	"go-rilla/object"
 	"go-rilla/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -37,11 +44,40 @@ func TestEvalIntegerExpression(t *testing.T) {
 }
 
 func testEval(input string) object.Object {
+	return testEvalLimits(input, Limits{})
+}
+
+// testEvalLimits evalúa input como testEval, pero con limits.
+func testEvalLimits(input string, limits Limits) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
 	env := object.NewEnvironment()
-	return Eval(program, env)
+	return testExecBackend(context.Background(), program, env, limits)
+}
+
+// testExecBackend es el backend usado por testEval; vm_backend_test.go lo
+// reemplaza para correr estos mismos casos sobre la VM.
+var testExecBackend = func(ctx context.Context, program *ast.Program, env *object.Environment, limits Limits) object.Object {
+	return EvalContext(ctx, program, env, limits)
+}
+
+// testEvalModules ejecuta el archivo main de files, cuyos imports se
+// resuelven contra files en lugar del sistema de archivos.
+func testEvalModules(files map[string]string, main string) object.Object {
+	loader := NewLoader()
+	loader.Exec = func(program *ast.Program, env *object.Environment) object.Object {
+		return testExecBackend(context.Background(), program, env, Limits{})
+	}
+	loader.ReadFile = func(path string) ([]byte, error) {
+		src, ok := files[filepath.ToSlash(path)]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(src), nil
+	}
+	program := parser.New(lexer.New(files[main])).ParseProgram()
+	return loader.Exec(program, loader.NewEnvironment(main))
 }
 
 func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
@@ -58,6 +94,238 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func TestStructuralComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"abc" == "abc"`, true},
+		{`"abc" != "abd"`, true},
+		{`"abc" < "abd"`, true},
+		{`"b" > "abc"`, true},
+		{`"ab" <= "ab"`, true},
+		{`"" >= "a"`, false},
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, 2] == [1.0, 2.0]", true},
+		{"9007199254740993 == 9007199254740992.0", false},
+		{"[9007199254740993] == [9007199254740992.0]", false},
+		{"9007199254740993 > 9007199254740992.0", true},
+		{"2 ** 70 == 2.0 ** 70", true},
+		{`[1, "2"] == [1, 2]`, false},
+		{"[] == []", true},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"a": 1, "b": 2}`, false},
+		{"{} == []", false},
+		{"if (false) { 1 } == if (false) { 2 }", true},
+		{"if (false) { 1 } == 0", false},
+		{"[1, 2] < [1, 3]", true},
+		{"[1, 2] < [1, 2, 0]", true},
+		{"[2] > [1, 9]", true},
+		{`[["a"], 1] <= [["a"], 1]`, true},
+		{"[1, 2.5] >= [1, 2]", true},
+		{"let f = fn() {}; f == f", true},
+		{"fn() {} == fn() {}", false},
+		{"let a = [1]; a[0] = a; let b = [1]; b[0] = b; a == b", true},
+		{"let a = [1]; a.push(a); let b = [1]; b.push(b); a == b", true},
+		{"let a = [1]; a.push(a); let b = [2]; b.push(b); a == b", false},
+		{`let h = {}; h["self"] = h; let g = {}; g["self"] = g; h == g`, true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Errorf("input: %s", tt.input)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1] < ["a"]`, `cannot compare [1] < [a]`},
+		{"[true] > [false]", "cannot compare [true] > [false]"},
+		{`"a" < 1`, "type mismatch: STRING < INTEGER"},
+		{`"a" - "b"`, "unknown operator: STRING - STRING"},
+		{"{} < {}", "unknown operator: HASH < HASH"},
+	}
+	for _, tt := range errorTests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got %v", tt.input, tt.expected, testEval(tt.input))
+		}
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": 2, "c": 3}`, "{b: 1, a: 2, c: 3}"},
+		{`{3: "x", 1: "y", 2: "z"}.keys()`, "[3, 1, 2]"},
+		{`{3: "x", 1: "y", 2: "z"}.values()`, "[x, y, z]"},
+		{`let h = {"z": 1}; h["a"] = 2; h["m"] = 3; h`, "{z: 1, a: 2, m: 3}"},
+		{`let h = {"z": 1, "a": 2}; h["z"] = 10; h`, "{z: 10, a: 2}"},
+		{`let h = {"a": 1, "b": 2, "a": 3}; h`, "{a: 3, b: 2}"},
+		{`let log = []; let f = fn(x) { log.push(x); x }; {f("b"): f(1), f("a"): f(2)}; log`, "[b, 1, a, 2]"},
+		{`json({"b": [1, 2.5, true], "a": {"x": "<\"y\">"}, "1": if (false) { 1 }})`, `{"b":[1,2.5,true],"a":{"x":"<\"y\">"},"1":null}`},
+		{`json(2 ** 70)`, "1180591620717411303424"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"json(fn(x) { x })", "cannot convert FUNCTION to JSON"},
+		{`json({1: "a", "1": "b"})`, "cannot convert a hash key of type INTEGER to JSON"},
+		{"let a = [1]; a.push(a); json(a)", "cannot convert a cyclic value to JSON"},
+		{"json()", "wrong number of arguments. got=0, want=1"},
+	}
+	for _, tt := range errorTests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got %v", tt.input, tt.expected, testEval(tt.input))
+		}
+	}
+}
+
+func TestHashableKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{1.5: "x"}[1.5]`, "x"},
+		{`{1: "int"}[1.0]`, "int"},
+		{`let h = {1.0: "a"}; h[1] = "b"; h`, "{1.000000: b}"},
+		{`{-0.0: "zero"}[0]`, "zero"},
+		{`{2 ** 70: "big"}[1180591620717411303424.0]`, "big"},
+		{`{[1, 2]: "pair"}[[1, 2]]`, "pair"},
+		{`{[1, 2]: "pair"}[[2, 1]]`, "null"},
+		{`{[1, [2, "a"]]: 1}[[1.0, [2, "a"]]]`, "1"},
+		{`{["a", "b"]: 1, ["ab"]: 2, ["a", "b", ""]: 3}.len()`, "3"},
+		{`let grid = {}; let x = 1; let y = 2; grid[[x, y]] = "#"; grid[[1, 2]]`, "#"},
+		{`let k = [1]; let h = {k: "v"}; k.push(2); h[[1]]`, "v"},
+		{`let k = [1]; let h = {k: "v"}; k[0] = 5; h.keys()`, "[[1]]"},
+		{`{[1]: 1}.has([1])`, "true"},
+		{`let p = freeze([1, 2]); p == [1, 2]`, "true"},
+		{`let a = [1]; a.push(a); freeze(a)[0]`, "1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`let h = {[1]: 1}; let k = h.keys()[0]; k[0] = 2`, "cannot modify a frozen array"},
+		{`let h = {[1]: 1}; h.keys()[0].push(2)`, "cannot modify a frozen array"},
+		{`let p = freeze([1]); p.pop()`, "cannot modify a frozen array"},
+		{`let p = freeze([1]); p[0]++`, "cannot modify a frozen array"},
+		{`let a = [1]; a.push(a); {a: 1}`, "unusable as hash key: ARRAY"},
+		{`freeze(1)`, "argument to `freeze` must be ARRAY, got INTEGER"},
+	}
+	for _, tt := range errorTests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got %v", tt.input, tt.expected, testEval(tt.input))
+		}
+	}
+}
+
+func TestExactIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // Inspect() del resultado
+		bigInt   bool
+	}{
+		{"9007199254740993 + 0", "9007199254740993", false},
+		{"9007199254740993 * 1 == 9007199254740992", "false", false},
+		{"2 ** 62", "4611686018427387904", false},
+		{"2 ** 70", "1180591620717411303424", true},
+		{"9223372036854775807 + 1", "9223372036854775808", true},
+		{"-9223372036854775807 - 2", "-9223372036854775809", true},
+		{"4294967296 * 4294967296", "18446744073709551616", true},
+		{"(9223372036854775807 + 1) - 1", "9223372036854775807", false},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808", true},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808", true},
+		{"let x = 9223372036854775807; x++; x", "9223372036854775808", true},
+		{"let x = 9223372036854775807; x += 2; x", "9223372036854775809", true},
+		{"2 ** 64 / 2 ** 60", "16", false},
+		{"2 ** 64 % 10", "6", false},
+		{"2 ** 64 == 2 ** 64", "true", false},
+		{"2 ** 64 > 9223372036854775807", "true", false},
+		{"2 ** 64 == 18446744073709551616.0", "true", false},
+		{"{2 ** 64: 1}[2 ** 64]", "1", false},
+		{"7 / -2", "-3", false},
+		{"2 ** -1", "0", false},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got %s (%T)", tt.input, tt.expected, evaluated.Inspect(), evaluated)
+		}
+		if _, ok := evaluated.(*object.BigInt); ok != tt.bigInt {
+			t.Errorf("%s: expected BigInt=%t, got %T", tt.input, tt.bigInt, evaluated)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 ** 64 / 0", "division by zero"},
+		{"2 ** 64 + true", "type mismatch: BIGINT + BOOLEAN"},
+		{"2 ** 9999999", "integer too large: 2 ** 9999999"},
+		{"8 ** 4611686018427387904", "integer too large: 8 ** 4611686018427387904"},
+	}
+	for _, tt := range errorTests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got %v", tt.input, tt.expected, testEval(tt.input))
+		}
+	}
+}
+
+func TestStrictIntegers(t *testing.T) {
+	strict := Limits{StrictIntegers: true}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "integer overflow: 9223372036854775807 + 1"},
+		{"2 ** 70", "integer overflow: 2 ** 70"},
+		{"-(-9223372036854775807 - 1)", "integer overflow: --9223372036854775808"},
+		{"let x = 9223372036854775807; x += 1", "integer overflow: 9223372036854775807 += 1"},
+		{"let x = 9223372036854775807; x++", "integer overflow: 9223372036854775807 + 1"},
+		{"let a = [9223372036854775807]; a[0]++", "integer overflow: 9223372036854775807 + 1"},
+	}
+	for _, tt := range tests {
+		errObj, ok := testEvalLimits(tt.input, strict).(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got %v", tt.input, tt.expected, testEvalLimits(tt.input, strict))
+		}
+	}
+	testIntegerObject(t, testEvalLimits("9223372036854775806 + 1", strict), 9223372036854775807)
+
+	// Sin StrictIntegers el mismo programa promueve el resultado.
+	if _, ok := testEval("9223372036854775807 + 1").(*object.BigInt); !ok {
+		t.Errorf("expected BigInt without StrictIntegers, got %v", testEval("9223372036854775807 + 1"))
+	}
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -159,6 +427,55 @@ func testNullObject(t *testing.T, obj object.Object) bool {
 	return true
 }
 
+func TestElseIfExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (false) { 10 } else if (true) { 20 } else { 30 }", 20},
+		{"if (false) { 10 } else if (false) { 20 } else { 30 }", 30},
+		{"if (true) { 10 } else if (true) { 20 } else { 30 }", 10},
+		{"if (false) { 10 } else if (false) { 20 }", nil},
+		{"let x = 5; if (x < 0) { -1 } else if (x == 0) { 0 } else if (x < 10) { 1 } else { 2 }", 1},
+		{"let sign = fn(x) { if (x < 0) { return -1; } else if (x > 0) { return 1; } 0 }; sign(-3) + sign(4) + sign(0)", 0},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestConditionalExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"true ? 1 : 2", 1},
+		{"false ? 1 : 2", 2},
+		{"1 < 2 ? 10 + 1 : 20", 11},
+		{"if (false) { 1 } ? 1 : 2", 2},
+		{"let x = 0; x == 0 ? 5 : 10 / x", 5},
+		{"let n = 15; n < 10 ? 1 : n < 20 ? 2 : 3", 2},
+		{"let abs = fn(x) { x < 0 ? -x : x }; abs(-7) + abs(3)", 10},
+		{"let x = 0; let c = false; x = c ? 1 : 2; x", 2},
+		{"let x = 0; x = true ? 7 : 8; x", 7},
+		{"let x = 0; let n = 15; x = n < 10 ? 1 : n < 20 ? 2 : 3; x", 2},
+		{"let x = 1; x += false ? 10 : 20; x", 21},
+		{"let a = 0; let b = 0; a = b = 4; a + b", 8},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -176,6 +493,12 @@ func TestReturnStatements(t *testing.T) {
 			
 			return 1;
 		}`, 10},
+		// return dentro de un bloque usado como valor sale de la función.
+		{"let f = fn() { let r = if (true) { return 5; }; 99 }; f()", 5},
+		{"let f = fn() { let r = try { return 5; } catch (e) { 0 }; 99 }; f()", 5},
+		{"let f = fn() { 1 + if (true) { return 5; } }; f()", 5},
+		{"let f = fn() { [1, if (true) { return 5; }] }; f()", 5},
+		{"let f = fn(x) { x }; let g = fn() { f(if (true) { return 5; }); 99 }; g()", 5},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -271,6 +594,52 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
+func TestErrorLocation(t *testing.T) {
+	input := `let inner = fn(x) {
+  x + y
+};
+let outer = fn() { inner(1) };
+outer();`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "identifier not found: y" {
+		t.Fatalf("wrong error message. got=%q", errObj.Message)
+	}
+
+	start, end := errObj.Range.Start, errObj.Range.End
+	if start.Line != 2 || start.Column != 7 || end.Line != 2 || end.Column != 8 {
+		t.Errorf("wrong error range. got=%d:%d-%d:%d",
+			start.Line, start.Column, end.Line, end.Column)
+	}
+
+	expectedStack := []struct {
+		function string
+		line     int
+		column   int
+	}{
+		{"inner", 4, 20},
+		{"outer", 5, 1},
+	}
+	if len(errObj.Stack) != len(expectedStack) {
+		t.Fatalf("wrong stack length. want=%d, got=%d (%+v)",
+			len(expectedStack), len(errObj.Stack), errObj.Stack)
+	}
+	for i, expected := range expectedStack {
+		frame := errObj.Stack[i]
+		if frame.Function != expected.function {
+			t.Errorf("stack[%d]: wrong function. want=%q, got=%q", i, expected.function, frame.Function)
+		}
+		if frame.Range.Start.Line != expected.line || frame.Range.Start.Column != expected.column {
+			t.Errorf("stack[%d]: wrong position. want=%d:%d, got=%d:%d", i,
+				expected.line, expected.column, frame.Range.Start.Line, frame.Range.Start.Column)
+		}
+	}
+}
+
 func TestLetStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -323,6 +692,24 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestWrongNumberOfArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(a, b) { a + b }; add(1)", "wrong number of arguments: want=2, got=1"},
+		{"fn(x) { x }()", "wrong number of arguments: want=1, got=0"},
+	}
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got %v", tt.input, tt.expected, testEval(tt.input))
+		}
+	}
+	// Los argumentos de más se ignoran.
+	testIntegerObject(t, testEval("fn(x) { x }(1, 2)"), 1)
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 		let newAdder = fn(x) {
@@ -334,6 +721,42 @@ func TestClosures(t *testing.T) {
 	testIntegerObject(t, testEval(input), 4)
 }
 
+func TestVariableScopes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// Las funciones ven las variables de afuera al llamarse, no al crearse.
+		{"let f = fn() { g() }; let g = fn() { 1 }; f()", 1},
+		{"let x = 1; let f = fn() { x }; x = 2; f()", 2},
+		{"let f = fn() { let x = 1; let g = fn() { x }; x = 2; g() }; f()", 2},
+		{"let f = fn(a) { fn(b) { fn(c) { a + b + c } } }; f(1)(2)(3)", 6},
+		// Asignar dentro de una función crea una variable local.
+		{"let x = 1; let f = fn() { x = 5; x }; f() * 10 + x", 51},
+		{"let x = 1; let f = fn() { x += 1; x }; f() * 10 + x", 21},
+		{"let x = 1; let f = fn() { let y = x; let x = 2; y * 10 + x }; f()", 12},
+		{"let f = fn() { let g = fn() { x = 3 }; g(); x }; f()", "identifier not found: x"},
+		// Los argumentos de más no se confunden con las locales.
+		{"let f = fn(a) { let b = b + 1; b }; let b = 10; f(1, 100)", 11},
+		{"let f = fn(n) { if (n == 0) { return 0 } let m = n - 1; f(m) + 1 }; f(5)", 5},
+		{"let f = fn() { let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5) }; f()", 120},
+		{"let len = fn(x) { 7 }; len([1])", 7},
+		{"let f = fn() { let len = fn(x) { 7 }; len([1]) }; f() + len([1])", 8},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || errObj.Message != expected {
+				t.Errorf("%s: expected error %q, got %v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
+
 func TestStringLiteral(t *testing.T) {
 	input := `"Hello World!"`
 	evaluated := testEval(input)
@@ -346,6 +769,59 @@ func TestStringLiteral(t *testing.T) {
 	}
 }
 
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a\nb"`, "a\nb"},
+		{`"say \"hi\"\t\\"`, "say \"hi\"\t\\"},
+		{`"\u{1F98D}" + "\x21"`, "🦍!"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+	testIntegerObject(t, testEval(`len("\n")`), 1)
+}
+
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "Ana"; let age = 30; "Hello ${name}, you are ${age + 1}"`, "Hello Ana, you are 31"},
+		{`"${1.5} ${true} ${[1, "a"]} ${if (false) { 1 }}"`, "1.500000 true [1, a] null"},
+		{`let x = "in"; "out ${"mid ${x}"} ${ {"k": 2}["k"] }"`, "out mid in 2"},
+		{`"\${x} costs $5"`, "${x} costs $5"},
+		{`let f = fn(n) { "n=${n}" }; f(3)`, "n=3"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+
+	errObj, ok := testEval("let s = \"a\";\n\"x ${s - 1}\"").(*object.Error)
+	if !ok {
+		t.Fatalf("expected an error from the interpolated expression")
+	}
+	if start := errObj.Range.Start; start.Line != 2 || start.Column != 6 {
+		t.Errorf("error should point inside the string. got=%d:%d (offset %d)", start.Line, start.Column, start.Offset)
+	}
+}
+
 func TestStringConcatenation(t *testing.T) {
 	input := `"Hello" + " " + "World!"`
 	evaluated := testEval(input)
@@ -575,6 +1051,47 @@ func TestOrOperator(t *testing.T) {
 	}
 }
 
+func TestShortCircuit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1 && 2", 2},
+		{"0 && 2", 2},
+		{`"" || "default"`, ""},
+		{"if (false) { 1 } || 5", 5},
+		{"false || 5", 5},
+		{"false && 5", false},
+		{"true || undefinedName", true},
+		{"false && undefinedName", false},
+		{"let calls = [0]; let f = fn() { calls[0] += 1; true }; false && f(); true || f(); calls[0]", 0},
+		{"let calls = [0]; let f = fn() { calls[0] += 1; true }; true && f(); false || f(); calls[0]", 2},
+		{"let h = {}; let name = h[\"name\"] || \"anonymous\"; name", "anonymous"},
+		{"let a = [1]; len(a) > 0 && a[0] == 1", true},
+		{"false || false || 3", 3},
+		{"1 && false || 4", 4},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok || str.Value != expected {
+				t.Errorf("%s: expected %q, got %s", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+
+	errObj, ok := testEval("true && undefinedName").(*object.Error)
+	if !ok || errObj.Message != "identifier not found: undefinedName" {
+		t.Errorf("expected the right side to be evaluated, got %v", errObj)
+	}
+}
+
 func TestCompoundOperators(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -656,6 +1173,13 @@ func TestWhileExpression(t *testing.T) {
 		evaluated := testEval(tt.input)
 		testIntegerObject(t, evaluated, tt.expected)
 	}
+
+	// Un bucle que no produjo ningún valor no deja nada, ni siquiera null.
+	for _, input := range []string{"while (false) { 1 }", "let i = 0; while (i < 3) { i += 1; let last = i; }"} {
+		if evaluated := testEval(input); evaluated != nil {
+			t.Errorf("%q: expected no value, got=%T (%+v)", input, evaluated, evaluated)
+		}
+	}
 }
 
 func TestAssignmentExpression(t *testing.T) {
@@ -668,6 +1192,231 @@ func TestAssignmentExpression(t *testing.T) {
 	testIntegerObject(t, evaluated, 5)
 }
 
+func TestIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = [1, 2, 3]; a[0] = 5; a[0]", 5},
+		{"let a = [1, 2, 3]; a[1 + 1] = 10", 10},
+		{"let a = [1, 2, 3]; a[2] += 4; a[2]", 7},
+		{"let a = [1, 2, 3]; a[0] -= 3", -2},
+		{"let a = [1, 2, 3]; a[1]++; a[1]", 3},
+		{"let a = [1, 2, 3]; a[1]++", 2},
+		{"let a = [1, 2, 3]; ++a[1]", 3},
+		{"let a = [1, 2, 3]; --a[0]; a[0]", 0},
+		{"let a = [1, 2, 3]; let b = ++a[2]; b * 10 + a[2]", 34},
+		{"let a = [1, 2, 3]; let b = a; b[0] = 9; a[0]", 9},
+		{"let a = [[1], [2]]; a[1][0] = 7; a[1][0]", 7},
+		{`let h = {"k": 1}; h["k"] = 2; h["k"]`, 2},
+		{`let h = {"k": 1}; h["k"] += 1; h["k"]`, 2},
+		{`let h = {}; h["new"] = 1; h["new"]`, 1},
+		{`let h = {}; h[true] = 1; h[true]++; h[true]`, 2},
+		{`let h = {"n": 0}; let i = 0; while (i < 3) { h["n"] += i; i++ }; h["n"]`, 3},
+		{"let a = [1, 2]; let f = fn(arr) { arr[0] = 100 }; f(a); a[0]", 100},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, int64(tt.expected.(int)))
+	}
+}
+
+func TestIndexAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"let a = [1, 2]; a[2] = 3", "index out of range: 2 (length 2)"},
+		{"let a = [1, 2]; a[-1] += 3", "index out of range: -1 (length 2)"},
+		{"let a = [1, 2]; a[5]++", "index out of range: 5 (length 2)"},
+		{`let a = [1, 2]; a["x"] = 3`, "array index must be INTEGER, got STRING"},
+		{`let h = {}; h[[1, {}]] = 3`, "unusable as hash key: ARRAY"},
+		{`let h = {}; h[fn(x) { x }] += 1`, "unusable as hash key: FUNCTION"},
+		{`let s = "abc"; s[0] = "z"`, "index assignment not supported: STRING"},
+		{`let h = {}; h["k"] += 1`, "type mismatch: NULL += INTEGER"},
+		{`let a = [1, 2]; a[5] = missing`, "index out of range: 5 (length 2)"},
+		{`let a = ["x"]; a[0]++`, "unknown operator: STRING++"},
+		{`missing[0] = 1`, "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q",
+				tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestMemberExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"abc".upper()`, "ABC"},
+		{`"AbC".lower()`, "abc"},
+		{`"  hi ".trim().len()`, 2},
+		{`"a,b,c".split(",").join("-")`, "a-b-c"},
+		{`"monkey".contains("key")`, true},
+		{`let a = [1, 2]; a.push(3); a`, "[1, 2, 3]"},
+		{`[1, 2].push(3).push(4).len()`, 4},
+		{`let a = [1, 2, 3]; a.pop() * 10 + a.len()`, 32},
+		{`[].pop()`, nil},
+		{`let h = {"name": "go-rilla", "age": 3}; h.name`, "go-rilla"},
+		{`let h = {"n": 1}; h["n"] += h.n; h.n`, 2},
+		{`let h = {"n": 1}; h.missing`, nil},
+		{`let h = {"n": 1}; h.keys()`, "[n]"},
+		{`let h = {"n": 1}; h.values()`, "[1]"},
+		{`{"a": 1, "b": 2}.len()`, 2},
+		{`{"a": 1}.has("a")`, true},
+		{`let h = {"len": 10}; h.len`, 10},
+		{`let h = {"double": fn(x) { x * 2 }}; h.double(21)`, 42},
+		{`let up = "abc".upper; up()`, "ABC"},
+		{`[1, [2, 3], "x"].contains([2, 3])`, true},
+		{`[1, 2].contains(3)`, false},
+		{`[1, 2, 1.0].indexOf(1.0)`, 0},
+		{`["a"].indexOf("b")`, -1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if evaluated == nil || evaluated.Inspect() != expected {
+				t.Errorf("wrong value for %q. expected=%q, got=%v", tt.input, expected, evaluated)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestMemberExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`let n = 5; n.upper()`, "unknown member: INTEGER.upper"},
+		{`"abc".push(1)`, "unknown member: STRING.push"},
+		{`"abc".upper(1)`, "wrong number of arguments to `upper`. got=1, want=0"},
+		{`[1].join(1)`, "argument to `join` must be STRING, got INTEGER"},
+		{`{}.has([len])`, "unusable as hash key: ARRAY"},
+		{`missing.len()`, "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q",
+				tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestTryExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`try { 1 / 0 } catch (e) { 2 }`, 2},
+		{`try { throw "bad" } catch (e) { e.message }`, "bad"},
+		{`try { throw "bad" } catch (e) { e.kind }`, "Error"},
+		{`try { missing } catch (e) { e.message }`, "identifier not found: missing"},
+		{`try { missing } catch (e) { e.kind }`, "RuntimeError"},
+		{`try { throw {"kind": "ValueError", "message": "negative"} } catch (e) { e.kind + ": " + e.message }`, "ValueError: negative"},
+		{`try { throw 42 } catch (e) { e.value + 1 }`, 43},
+		{`try { 1 / 0 } catch (e) { e.value }`, nil},
+		{"try {\n  let x = 1;\n  x + true\n} catch (e) { e.line * 100 + e.column }", 303},
+		{`try { throw "x" } catch { 7 }`, 7},
+		{`let log = []; try { log.push(1) } finally { log.push(2) }; log`, "[1, 2]"},
+		{`let log = []; try { throw "x" } catch (e) { log.push(e.message) } finally { log.push("f") }; log`, "[x, f]"},
+		{`let r = try { 10 } finally { 20 }; r`, 10},
+		{`let f = fn() { try { return 1 } finally { 2 } }; f()`, 1},
+		{`let f = fn() { try { return 1 } finally { return 2 } }; f()`, 2},
+		{`let log = []; let f = fn() { try { return 1 } finally { log.push("f") } }; f(); log`, "[f]"},
+		{`let inner = fn() { throw "deep" }; let outer = fn() { inner() }; try { outer() } catch (e) { e.message }`, "deep"},
+		{`let safe = fn(x) { try { 10 / x } catch (e) { -1 } }; safe(0) + safe(5)`, 1},
+		{`try { try { throw "inner" } catch (e) { throw e } } catch (e) { e.message }`, "inner"},
+		{`try { try { throw "inner" } finally { 1 } } catch (e) { e.message }`, "inner"},
+		{`try { try { throw "a" } catch (e) { throw "b" } finally { 0 } } catch (e) { e.message }`, "b"},
+		{`let i = 0; let log = []; while (i < 3) { i++; try { if (i == 2) { continue } log.push(i) } finally { log.push("f") } }; log`, "[1, f, f, 3, f]"},
+		{`let i = 0; while (true) { try { i++; if (i == 3) { break } } catch (e) { 0 } }; i`, 3},
+		{`let n = 0; let i = 0; while (i < 5) { i++; try { if (i % 2 == 0) { throw "even" } n += i } catch (e) { n += 100 } }; n`, 209},
+		{`try { throw "x" } catch (e) { e }`, "Error: x"},
+		{`let e = 5; try { throw "x" } catch (e) { e = 1 }; e`, 5},
+		{`let n = 0; try { throw "x" } catch (e) { let n = 1; let m = 2 }; n + m`, 3},
+		{`let f = fn() { try { throw "x" } catch (e) { fn() { e.message } } }; f()()`, "x"},
+		{`let f = fn() { let e = 5; try { throw "x" } catch (e) { 0 }; e }; f()`, 5},
+		{`let f = fn() { try { throw "x" } catch (e) { 0 }; let e = e; e }; let e = 7; f()`, 7},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if evaluated == nil || evaluated.Inspect() != expected {
+				t.Errorf("wrong value for %q. expected=%q, got=%v", tt.input, expected, evaluated)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestUncaughtThrow(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedKind    string
+	}{
+		{`throw "bad input"`, "bad input", "Error"},
+		{`throw {"kind": "ValueError", "message": "negative"}`, "negative", "ValueError"},
+		{`throw [1, 2]`, "[1, 2]", "Error"},
+		{`try { throw "a" } catch (e) { throw "b" }`, "b", "Error"},
+		{`try { 1 } finally { 1 / 0 }`, "division by zero", "RuntimeError"},
+		{`try { throw "a" } finally { 0 }`, "a", "Error"},
+		{`try { 1 / 0 } catch (e) { throw e }`, "division by zero", "RuntimeError"},
+		{`throw missing`, "identifier not found: missing", "RuntimeError"},
+		{`try { throw "x" } catch (e) { 1 }; e`, "identifier not found: e", "RuntimeError"},
+		{`let f = fn() { try { throw "x" } catch (e) { 1 }; e }; f()`, "identifier not found: e", "RuntimeError"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q",
+				tt.input, tt.expectedMessage, errObj.Message)
+		}
+		if errObj.KindName() != tt.expectedKind {
+			t.Errorf("wrong error kind for %q. expected=%q, got=%q",
+				tt.input, tt.expectedKind, errObj.KindName())
+		}
+	}
+}
+
 func TestBreakStatementEvaluation(t *testing.T) {
 	input := `
 		let i = 0;
@@ -827,3 +1576,214 @@ func TestIncrementDecrementPrefix(t *testing.T) {
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
+
+func TestImports(t *testing.T) {
+	files := map[string]string{
+		"lib/math.monkey": `
+			let helper = import "helper.monkey";
+			export let square = fn(x) { helper.mul(x, x) };
+			export let pi = 3;
+			let hidden = 1;`,
+		"lib/helper.monkey":  `export let mul = fn(a, b) { a * b };`,
+		"lib/counter.monkey": `export let calls = []; calls.push(1);`,
+	}
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let m = import "lib/math.monkey"; m.square(4)`, 16},
+		{`let m = import "./lib/math.monkey"; m.pi`, 3},
+		{`import "lib/math.monkey"`, "<module lib/math.monkey>"},
+		{`let a = import "lib/math.monkey"; let b = import "lib/../lib/math.monkey"; a == b`, "true"},
+		{`let a = import "lib/counter.monkey"; let b = import "lib/counter.monkey"; len(b.calls)`, 1},
+		{`let m = import "lib/math.monkey"; m.hidden`, "module lib/math.monkey does not export hidden"},
+		{`let m = import "lib/math.monkey"; m.helper`, "module lib/math.monkey does not export helper"},
+		{`import "lib/missing.monkey"`, "module not found: lib/missing.monkey"},
+		{`let f = fn() { export let x = 1; }; f()`, "export is only allowed at the top level of a module"},
+		{`export let x = 2; x`, 2},
+	}
+
+	for _, tt := range tests {
+		files["main.monkey"] = tt.input
+		evaluated := testEvalModules(files, "main.monkey")
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			got := ""
+			if errObj, ok := evaluated.(*object.Error); ok {
+				got = errObj.Message
+			} else if evaluated != nil {
+				got = evaluated.Inspect()
+			}
+			if got != expected {
+				t.Errorf("wrong value for %q. expected=%q, got=%q", tt.input, expected, got)
+			}
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		files           map[string]string
+		expectedCode    string
+		expectedMessage string
+		expectedFile    string
+	}{
+		{
+			map[string]string{
+				"main.monkey":  `let a = import "a.monkey";`,
+				"a.monkey":     `let b = import "sub/b.monkey";`,
+				"sub/b.monkey": `let a = import "../a.monkey";`,
+			},
+			"MOD001", "import cycle: a.monkey -> sub/b.monkey -> a.monkey", "sub/b.monkey",
+		},
+		{
+			map[string]string{
+				"main.monkey": `let a = import "a.monkey";`,
+				"a.monkey":    `let m = import "main.monkey";`,
+			},
+			"MOD001", "import cycle: main.monkey -> a.monkey -> main.monkey", "a.monkey",
+		},
+		{
+			map[string]string{
+				"main.monkey": `let a = import "a.monkey";`,
+				"a.monkey":    "let x = 1;\nlet = 1;",
+			},
+			"MOD003", "parse error in module a.monkey:2:5: Expected next token to be IDENTIFIER, got = instead", "main.monkey",
+		},
+		{
+			map[string]string{
+				"main.monkey": `let a = import "a.monkey";`,
+				"a.monkey":    "let x = 1;\nx + true;",
+			},
+			"RUN001", "type mismatch: INTEGER + BOOLEAN", "a.monkey",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalModules(tt.files, "main.monkey")
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+		if code := errObj.Diagnostic().Code; code != tt.expectedCode {
+			t.Errorf("wrong error code for %q. expected=%q, got=%q", errObj.Message, tt.expectedCode, code)
+		}
+		if filepath.ToSlash(errObj.File) != tt.expectedFile {
+			t.Errorf("wrong error file for %q. expected=%q, got=%q", errObj.Message, tt.expectedFile, errObj.File)
+		}
+	}
+}
+
+// TestConcurrentEvaluation evalúa programas independientes en paralelo; con
+// -race detecta estado compartido entre ejecuciones.
+func TestConcurrentEvaluation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`let i = 0; while (true) { i++; if (i == 50) { break } }; i`, 50},
+		{`let n = 0; for (let i = 0; i < 40; i++) { if (i % 2 == 0) { continue } n += i }; n`, 400},
+		{`let f = fn(x) { let i = 0; while (i < x) { i++ }; i }; let t = 0; for (let j = 0; j < 10; j++) { t += f(j) }; t`, 45},
+		{`let h = {}; let i = 0; while (i < 20) { h[i] = i * i; i++ }; h[19]`, 361},
+		{`let a = [1, 2, 3]; a.push(4); try { throw "x" } catch (e) { len(a) }`, 4},
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 10; round++ {
+				for _, tt := range tests {
+					evaluated := testEval(tt.input)
+					result, ok := evaluated.(*object.Integer)
+					if !ok || result.Value != tt.expected {
+						t.Errorf("wrong result for %q. expected=%d, got=%v", tt.input, tt.expected, evaluated)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if result := testEval(`break`); !isError(result) {
+		t.Errorf("break outside of loop should still fail after concurrent runs. got=%v", result)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		input           string
+		limits          Limits
+		expectedMessage string
+	}{
+		{`while (true) { }`, Limits{MaxSteps: 1000}, "maximum of 1000 evaluation steps exceeded"},
+		{`let f = fn(n) { f(n + 1) }; f(0)`, Limits{MaxCallDepth: 50}, "maximum call depth of 50 exceeded"},
+		{`let f = fn(n) { f(n + 1) }; f(0)`, Limits{MaxCallDepth: 3000}, "maximum call depth of 3000 exceeded"},
+		{`let a = []; while (true) { a.push(1) }`, Limits{MaxCollectionSize: 100}, "ARRAY of size 101 exceeds the maximum collection size of 100"},
+		{`let h = {}; let i = 0; while (true) { h[i] = i; i++; h }`, Limits{MaxCollectionSize: 10}, "HASH of size 11 exceeds the maximum collection size of 10"},
+		{`let s = "ab"; while (true) { s = s + s }`, Limits{MaxCollectionSize: 64}, "STRING of size 128 exceeds the maximum collection size of 64"},
+		{`try { while (true) { } } catch (e) { 1 }`, Limits{MaxSteps: 100}, "maximum of 100 evaluation steps exceeded"},
+		{`try { while (true) { } } finally { 1 }`, Limits{MaxSteps: 100}, "maximum of 100 evaluation steps exceeded"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalLimits(tt.input, tt.limits)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+		if errObj.KindName() != object.LIMIT_ERROR || errObj.Diagnostic().Code != "RUN002" {
+			t.Errorf("wrong error kind for %q. got=%q (%s)", tt.input, errObj.KindName(), errObj.Diagnostic().Code)
+		}
+	}
+
+	within := `let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(20)`
+	testIntegerObject(t, testEvalLimits(within, Limits{MaxCallDepth: 21, MaxSteps: 10000}), 0)
+
+	// Sin límite, la profundidad no depende del backend.
+	deep := `let f = fn(n) { let m = n - 1; if (n == 0) { 0 } else { 1 + f(m) } }; f(5000)`
+	testIntegerObject(t, testEval(deep), 5000)
+}
+
+func TestEvalContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	program := parser.New(lexer.New(`while (true) { }`)).ParseProgram()
+	evaluated := testExecBackend(ctx, program, object.NewEnvironment(), Limits{})
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "execution stopped: context deadline exceeded" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestBuiltinInfo(t *testing.T) {
+	infos := Builtins()
+	if len(infos) != len(builtins) {
+		t.Errorf("documented %d builtins, registered %d", len(infos), len(builtins))
+	}
+	for name := range builtins {
+		info, ok := LookupBuiltinInfo(name)
+		if !ok {
+			t.Errorf("builtin %s is not documented", name)
+			continue
+		}
+		if !strings.HasPrefix(info.Signature, name+"(") || info.Doc == "" {
+			t.Errorf("documentation of %s incomplete: %+v", name, info)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package evaluator
+
+import (
+	"context"
+	"go-rilla/ast"
+	"go-rilla/object"
+)
+
+// SetTestExec reemplaza el backend usado por testEval y devuelve una función
+// que restaura el anterior.
+func SetTestExec(fn func(ctx context.Context, program *ast.Program, env *object.Environment, limits Limits) object.Object) (restore func()) {
+	previous := testExecBackend
+	testExecBackend = fn
+	return func() { testExecBackend = previous }
+}
@@ -0,0 +1,212 @@
+package evaluator
+
+import (
+	"go-rilla/object"
+	"math"
+	"math/big"
+)
+
+// Las operaciones entre enteros son exactas: se hacen en int64 y, si el
+// resultado no entra, con math/big. Un resultado que no entra en 64 bits es
+// un object.BigInt, salvo en modo estricto (strict, que viene de
+// Limits.StrictIntegers), donde es el error "integer overflow".
+
+// maxBigIntBits acota el tamaño del resultado de "**", que de otro modo
+// podría agotar la memoria con una sola operación.
+const maxBigIntBits = 1 << 20
+
+func isInteger(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.BIGINT_OBJ
+}
+
+func isNumber(obj object.Object) bool {
+	return isInteger(obj) || obj.Type() == object.FLOAT_OBJ
+}
+
+func toBig(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInt:
+		return obj.Value
+	}
+	return nil
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInt:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	case *object.Float:
+		return obj.Value
+	}
+	return 0
+}
+
+// NewInteger devuelve value como un Integer si entra en 64 bits o como un
+// BigInt si no.
+func NewInteger(value *big.Int) object.Object {
+	if value.IsInt64() {
+		return &object.Integer{Value: value.Int64()}
+	}
+	return &object.BigInt{Value: value}
+}
+
+func evalIntegerInfixExpression(operator string, left, right object.Object, display string, strict bool) object.Object {
+	l, lok := left.(*object.Integer)
+	r, rok := right.(*object.Integer)
+	if lok && rok {
+		if result, ok := int64Operation(operator, l.Value, r.Value); ok {
+			return result
+		}
+	}
+	return bigOperation(operator, left, right, display, strict)
+}
+
+// int64Operation resuelve la operación en int64. Devuelve false si el
+// resultado no entra o si hay que reportar un error, casos que resuelve
+// bigOperation.
+func int64Operation(operator string, a, b int64) (object.Object, bool) {
+	switch operator {
+	case "+":
+		if sum := a + b; (sum > a) == (b > 0) {
+			return &object.Integer{Value: sum}, true
+		}
+	case "-":
+		if diff := a - b; (diff < a) == (b > 0) {
+			return &object.Integer{Value: diff}, true
+		}
+	case "*":
+		if product, ok := mulInt64(a, b); ok {
+			return &object.Integer{Value: product}, true
+		}
+	case "/":
+		if b != 0 && !(a == math.MinInt64 && b == -1) {
+			return &object.Integer{Value: a / b}, true
+		}
+	case "%":
+		if b != 0 {
+			return &object.Integer{Value: a % b}, true
+		}
+	case "**":
+		if power, ok := powInt64(a, b); ok {
+			return &object.Integer{Value: power}, true
+		}
+	case "<":
+		return nativeBoolToBooleanObject(a < b), true
+	case ">":
+		return nativeBoolToBooleanObject(a > b), true
+	case "==":
+		return nativeBoolToBooleanObject(a == b), true
+	case "!=":
+		return nativeBoolToBooleanObject(a != b), true
+	case "<=":
+		return nativeBoolToBooleanObject(a <= b), true
+	case ">=":
+		return nativeBoolToBooleanObject(a >= b), true
+	}
+	return nil, false
+}
+
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+// powInt64 calcula a ** b por cuadrados sucesivos. Con un exponente
+// negativo el resultado, como antes, es la potencia real truncada.
+func powInt64(a, b int64) (int64, bool) {
+	if b < 0 {
+		return int64(math.Pow(float64(a), float64(b))), true
+	}
+	result := int64(1)
+	var ok bool
+	for ; b > 0; b >>= 1 {
+		if b&1 == 1 {
+			if result, ok = mulInt64(result, a); !ok {
+				return 0, false
+			}
+		}
+		if b > 1 {
+			if a, ok = mulInt64(a, a); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// bigOperation resuelve la operación con math/big, cuando algún operando es
+// un BigInt o el resultado no entra en int64.
+func bigOperation(operator string, left, right object.Object, display string, strict bool) object.Object {
+	a, b := toBig(left), toBig(right)
+	result := new(big.Int)
+	switch operator {
+	case "+":
+		result.Add(a, b)
+	case "-":
+		result.Sub(a, b)
+	case "*":
+		result.Mul(a, b)
+	case "/":
+		if b.Sign() == 0 {
+			return newError("division by zero")
+		}
+		result.Quo(a, b)
+	case "%":
+		if b.Sign() == 0 {
+			return newError("division by zero")
+		}
+		result.Rem(a, b)
+	case "**":
+		if b.Sign() < 0 {
+			return &object.Integer{Value: int64(math.Pow(toFloat(left), toFloat(right)))}
+		}
+		// Como |a| > 1, a.BitLen()-1 >= 1. Se divide en vez de multiplicar
+		// para que un exponente enorme no desborde la cuenta.
+		if a.CmpAbs(big.NewInt(1)) > 0 && (!b.IsInt64() || b.Int64() > maxBigIntBits/int64(a.BitLen()-1)) {
+			return newError("integer too large: %s %s %s", left.Inspect(), display, right.Inspect())
+		}
+		result.Exp(a, b, nil)
+	case "<":
+		return nativeBoolToBooleanObject(a.Cmp(b) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(a.Cmp(b) > 0)
+	case "==":
+		return nativeBoolToBooleanObject(a.Cmp(b) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(a.Cmp(b) != 0)
+	case "<=":
+		return nativeBoolToBooleanObject(a.Cmp(b) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(a.Cmp(b) >= 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), display, right.Type())
+	}
+
+	if strict && !result.IsInt64() {
+		return newError("integer overflow: %s %s %s", left.Inspect(), display, right.Inspect())
+	}
+	return NewInteger(result)
+}
+
+// evalIntegerNegation calcula -right para un entero.
+func evalIntegerNegation(right object.Object, strict bool) object.Object {
+	if i, ok := right.(*object.Integer); ok && i.Value != math.MinInt64 {
+		return &object.Integer{Value: -i.Value}
+	}
+	result := new(big.Int).Neg(toBig(right))
+	if strict && !result.IsInt64() {
+		return newError("integer overflow: -%s", right.Inspect())
+	}
+	return NewInteger(result)
+}
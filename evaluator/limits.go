@@ -0,0 +1,119 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"go-rilla/ast"
+	"go-rilla/diag"
+	"go-rilla/object"
+)
+
+// Limits acota los recursos que puede usar una ejecución, para correr
+// scripts que no son de confianza. Un campo en cero no impone límite.
+type Limits struct {
+	MaxSteps          int // nodos del AST evaluados
+	MaxCallDepth      int // llamadas a funciones anidadas
+	MaxCollectionSize int // elementos de un array o hash, o bytes de un string
+
+	// StrictIntegers hace que un entero que no entra en 64 bits sea el
+	// error "integer overflow" en vez de un object.BigInt.
+	StrictIntegers bool
+}
+
+// contextCheckInterval es cada cuántos pasos se consulta si el
+// context.Context fue cancelado.
+const contextCheckInterval = 256
+
+// limitError crea el error de un límite excedido. A diferencia de los demás
+// errores no puede capturarse con try/catch: aborta la ejecución.
+func limitError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Kind: object.LIMIT_ERROR, Code: diag.CodeLimit, Message: fmt.Sprintf(format, a...)}
+}
+
+// step cuenta un paso de evaluación y devuelve un error si se agotó el
+// presupuesto de pasos o se canceló el contexto.
+func (c *evalContext) step() *object.Error {
+	c.steps++
+	return c.limits.CheckSteps(c.ctx, c.steps)
+}
+
+// enterCall cuenta una llamada a función y devuelve un error si se supera
+// la profundidad máxima.
+func (c *evalContext) enterCall() *object.Error {
+	c.callDepth++
+	return c.limits.CheckCallDepth(c.callDepth)
+}
+
+// checkSize devuelve un error si obj es una colección más grande que lo
+// permitido.
+func (c *evalContext) checkSize(obj object.Object) *object.Error {
+	return c.limits.CheckSize(obj)
+}
+
+// CheckSteps devuelve un error de límite si steps, los pasos ejecutados
+// hasta ahora, superan MaxSteps o si se canceló ctx (que puede ser nil).
+// ctx se consulta sólo cada contextCheckInterval pasos.
+func (l Limits) CheckSteps(ctx context.Context, steps int) *object.Error {
+	if l.MaxSteps > 0 && steps > l.MaxSteps {
+		return limitError("maximum of %d evaluation steps exceeded", l.MaxSteps)
+	}
+	if ctx != nil && steps%contextCheckInterval == 0 {
+		if err := ctx.Err(); err != nil {
+			return limitError("execution stopped: %v", err)
+		}
+	}
+	return nil
+}
+
+// CheckCallDepth devuelve un error de límite si depth llamadas anidadas
+// superan MaxCallDepth.
+func (l Limits) CheckCallDepth(depth int) *object.Error {
+	if l.MaxCallDepth > 0 && depth > l.MaxCallDepth {
+		return limitError("maximum call depth of %d exceeded", l.MaxCallDepth)
+	}
+	return nil
+}
+
+// CheckSize devuelve un error de límite si obj es un array, hash o string
+// más grande que MaxCollectionSize.
+func (l Limits) CheckSize(obj object.Object) *object.Error {
+	if l.MaxCollectionSize <= 0 {
+		return nil
+	}
+	var size int
+	switch obj := obj.(type) {
+	case *object.Array:
+		size = len(obj.Elements)
+	case *object.Hash:
+		size = obj.Len()
+	case *object.String:
+		size = len(obj.Value)
+	default:
+		return nil
+	}
+	if size > l.MaxCollectionSize {
+		return limitError("%s of size %d exceeds the maximum collection size of %d",
+			obj.Type(), size, l.MaxCollectionSize)
+	}
+	return nil
+}
+
+// EvalContext evalúa node en env como Eval, pero se detiene con un error de
+// tipo LimitExceeded si se cancela ctx o se supera alguno de limits.
+func EvalContext(ctx context.Context, node ast.Node, env *object.Environment, limits Limits) object.Object {
+	c := &evalContext{ctx: ctx, limits: limits}
+	return c.eval(node, env)
+}
+
+// ApplyFunctionContext llama a fn (una función o un Built-In) con args,
+// respetando ctx y limits.
+func ApplyFunctionContext(ctx context.Context, fn object.Object, args []object.Object, limits Limits) object.Object {
+	c := &evalContext{ctx: ctx, limits: limits}
+	return c.applyFunction(fn, args)
+}
+
+// isLimitError indica si obj es un error de límite excedido.
+func isLimitError(obj object.Object) bool {
+	errObj, ok := obj.(*object.Error)
+	return ok && errObj.Kind == object.LIMIT_ERROR
+}
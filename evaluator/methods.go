@@ -0,0 +1,252 @@
+package evaluator
+
+import (
+	"go-rilla/object"
+	"strings"
+	"sync"
+)
+
+// Method es una función nativa que se invoca sobre un valor:
+// receiver.name(args...).
+type Method func(receiver object.Object, args ...object.Object) object.Object
+
+// methods guarda, para cada tipo, los métodos que expone. methodsMu lo
+// protege para que se puedan registrar métodos mientras otros programas se
+// evalúan.
+var (
+	methods   = map[object.ObjectType]map[string]Method{}
+	methodsMu sync.RWMutex
+)
+
+// RegisterMethod declara el método name para los valores de tipo t. Si ya
+// existía uno con el mismo nombre, lo reemplaza.
+func RegisterMethod(t object.ObjectType, name string, fn Method) {
+	methodsMu.Lock()
+	defer methodsMu.Unlock()
+	if methods[t] == nil {
+		methods[t] = map[string]Method{}
+	}
+	methods[t][name] = fn
+}
+
+// LookupMethod devuelve el método name del tipo t.
+func LookupMethod(t object.ObjectType, name string) (Method, bool) {
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+	fn, ok := methods[t][name]
+	return fn, ok
+}
+
+// evalMemberExpression resuelve receiver.name. En un hash, las claves tienen
+// prioridad sobre los métodos y una clave inexistente vale null, como con
+// el operador de índice; un error capturado expone sus datos. Un método se
+// devuelve ligado a su receptor, listo para llamarse.
+func evalMemberExpression(receiver object.Object, name string) object.Object {
+	if hash, ok := receiver.(*object.Hash); ok {
+		key := &object.String{Value: name}
+		if pair, ok := hash.Get(key.HashKey()); ok {
+			return pair.Value
+		}
+	}
+
+	if module, ok := receiver.(*object.Module); ok {
+		if value, ok := module.Member(name); ok {
+			return value
+		}
+		return newError("module %s does not export %s", module.Path, name)
+	}
+
+	if caught, ok := receiver.(*object.ErrorValue); ok {
+		if value, ok := errorMember(caught.Err, name); ok {
+			return value
+		}
+	}
+
+	method, ok := LookupMethod(receiver.Type(), name)
+	if !ok {
+		if receiver.Type() == object.HASH_OBJ {
+			return NULL
+		}
+		return newError("unknown member: %s.%s", receiver.Type(), name)
+	}
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		return method(receiver, args...)
+	}}
+}
+
+func checkArgs(name string, args []object.Object, want int) *object.Error {
+	if len(args) != want {
+		return newError("wrong number of arguments to `%s`. got=%d, want=%d", name, len(args), want)
+	}
+	return nil
+}
+
+func init() {
+	// STRING
+	RegisterMethod(object.STRING_OBJ, "len", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("len", args, 0); err != nil {
+			return err
+		}
+		return &object.Integer{Value: int64(len(receiver.(*object.String).Value))}
+	})
+	RegisterMethod(object.STRING_OBJ, "upper", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("upper", args, 0); err != nil {
+			return err
+		}
+		return &object.String{Value: strings.ToUpper(receiver.(*object.String).Value)}
+	})
+	RegisterMethod(object.STRING_OBJ, "lower", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("lower", args, 0); err != nil {
+			return err
+		}
+		return &object.String{Value: strings.ToLower(receiver.(*object.String).Value)}
+	})
+	RegisterMethod(object.STRING_OBJ, "trim", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("trim", args, 0); err != nil {
+			return err
+		}
+		return &object.String{Value: strings.TrimSpace(receiver.(*object.String).Value)}
+	})
+	RegisterMethod(object.STRING_OBJ, "contains", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("contains", args, 1); err != nil {
+			return err
+		}
+		substr, ok := args[0].(*object.String)
+		if !ok {
+			return newError("argument to `contains` must be STRING, got %s", args[0].Type())
+		}
+		return nativeBoolToBooleanObject(strings.Contains(receiver.(*object.String).Value, substr.Value))
+	})
+	RegisterMethod(object.STRING_OBJ, "split", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("split", args, 1); err != nil {
+			return err
+		}
+		sep, ok := args[0].(*object.String)
+		if !ok {
+			return newError("argument to `split` must be STRING, got %s", args[0].Type())
+		}
+		parts := strings.Split(receiver.(*object.String).Value, sep.Value)
+		elements := make([]object.Object, len(parts))
+		for i, part := range parts {
+			elements[i] = &object.String{Value: part}
+		}
+		return &object.Array{Elements: elements}
+	})
+
+	// ARRAY
+	RegisterMethod(object.ARRAY_OBJ, "len", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("len", args, 0); err != nil {
+			return err
+		}
+		return &object.Integer{Value: int64(len(receiver.(*object.Array).Elements))}
+	})
+	// push agrega al final del propio array (a diferencia del Built-In push,
+	// que devuelve una copia) y lo devuelve para poder encadenar llamadas.
+	RegisterMethod(object.ARRAY_OBJ, "push", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("push", args, 1); err != nil {
+			return err
+		}
+		array := receiver.(*object.Array)
+		if array.Frozen {
+			return newError("cannot modify a frozen array")
+		}
+		array.Elements = append(array.Elements, args[0])
+		return array
+	})
+	RegisterMethod(object.ARRAY_OBJ, "pop", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("pop", args, 0); err != nil {
+			return err
+		}
+		array := receiver.(*object.Array)
+		if array.Frozen {
+			return newError("cannot modify a frozen array")
+		}
+		length := len(array.Elements)
+		if length == 0 {
+			return NULL
+		}
+		last := array.Elements[length-1]
+		array.Elements = array.Elements[:length-1]
+		return last
+	})
+	RegisterMethod(object.ARRAY_OBJ, "join", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("join", args, 1); err != nil {
+			return err
+		}
+		sep, ok := args[0].(*object.String)
+		if !ok {
+			return newError("argument to `join` must be STRING, got %s", args[0].Type())
+		}
+		elements := receiver.(*object.Array).Elements
+		parts := make([]string, len(elements))
+		for i, e := range elements {
+			parts[i] = e.Inspect()
+		}
+		return &object.String{Value: strings.Join(parts, sep.Value)}
+	})
+	// contains y indexOf comparan con object.Equal, como ==.
+	RegisterMethod(object.ARRAY_OBJ, "contains", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("contains", args, 1); err != nil {
+			return err
+		}
+		return nativeBoolToBooleanObject(indexOf(receiver.(*object.Array), args[0]) >= 0)
+	})
+	RegisterMethod(object.ARRAY_OBJ, "indexOf", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("indexOf", args, 1); err != nil {
+			return err
+		}
+		return &object.Integer{Value: int64(indexOf(receiver.(*object.Array), args[0]))}
+	})
+
+	// HASH
+	RegisterMethod(object.HASH_OBJ, "len", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("len", args, 0); err != nil {
+			return err
+		}
+		return &object.Integer{Value: int64(receiver.(*object.Hash).Len())}
+	})
+	RegisterMethod(object.HASH_OBJ, "keys", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("keys", args, 0); err != nil {
+			return err
+		}
+		pairs := receiver.(*object.Hash).OrderedPairs()
+		keys := make([]object.Object, len(pairs))
+		for i, pair := range pairs {
+			keys[i] = pair.Key
+		}
+		return &object.Array{Elements: keys}
+	})
+	RegisterMethod(object.HASH_OBJ, "values", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("values", args, 0); err != nil {
+			return err
+		}
+		pairs := receiver.(*object.Hash).OrderedPairs()
+		values := make([]object.Object, len(pairs))
+		for i, pair := range pairs {
+			values[i] = pair.Value
+		}
+		return &object.Array{Elements: values}
+	})
+	RegisterMethod(object.HASH_OBJ, "has", func(receiver object.Object, args ...object.Object) object.Object {
+		if err := checkArgs("has", args, 1); err != nil {
+			return err
+		}
+		key, ok := object.HashKeyOf(args[0])
+		if !ok {
+			return newError("unusable as hash key: %s", args[0].Type())
+		}
+		_, found := receiver.(*object.Hash).Get(key)
+		return nativeBoolToBooleanObject(found)
+	})
+}
+
+// indexOf devuelve la posición del primer elemento de array igual a value,
+// o -1 si no está.
+func indexOf(array *object.Array, value object.Object) int {
+	for i, e := range array.Elements {
+		if object.Equal(e, value) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,165 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-rilla/ast"
+	"go-rilla/diag"
+	"go-rilla/lexer"
+	"go-rilla/object"
+	"go-rilla/parser"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Loader carga los módulos importados por un programa. Cada archivo se
+// evalúa una sola vez en su propio entorno y queda en caché para los
+// imports siguientes. Las rutas se resuelven relativas al módulo que
+// importa. Un Loader pertenece a una sola ejecución y no debe usarse desde
+// varias goroutines a la vez.
+type Loader struct {
+	// ReadFile lee el código de un módulo. Por defecto os.ReadFile.
+	ReadFile func(path string) ([]byte, error)
+	// Exec ejecuta el programa de un módulo en su entorno. Por defecto
+	// EvalContext con Context y Limits; el backend de la VM la reemplaza
+	// para compilar los módulos.
+	Exec func(program *ast.Program, env *object.Environment) object.Object
+	// Context y Limits acotan la evaluación de cada módulo importado.
+	Context context.Context
+	Limits  Limits
+	// Globals, si no es nil, es el entorno exterior de todos los módulos:
+	// los nombres que define el programa anfitrión.
+	Globals *object.Environment
+
+	modules map[string]*object.Module // módulos ya cargados, por ruta absoluta
+	loading []*object.Module          // módulos en evaluación, el principal primero
+}
+
+func NewLoader() *Loader {
+	l := &Loader{
+		ReadFile: os.ReadFile,
+		modules:  make(map[string]*object.Module),
+	}
+	l.Exec = func(program *ast.Program, env *object.Environment) object.Object {
+		return EvalContext(l.Context, program, env, l.Limits)
+	}
+	return l
+}
+
+// NewEnvironment crea el entorno del programa principal, ubicado en path.
+// Con path vacío (el REPL) los imports se resuelven desde el directorio
+// actual.
+func (l *Loader) NewEnvironment(path string) *object.Environment {
+	module := &object.Module{Path: path, Importer: l}
+	env := object.NewModuleEnvironment(module, l.Globals)
+	if path != "" {
+		l.loading = append(l.loading, module)
+	}
+	return env
+}
+
+// Import implementa object.Importer.
+func (l *Loader) Import(from *object.Module, path string) object.Object {
+	resolved := filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		resolved = filepath.Join(filepath.Dir(from.Path), path)
+	}
+	key := moduleKey(resolved)
+
+	for i, loading := range l.loading {
+		if moduleKey(loading.Path) != key {
+			continue
+		}
+		cycle := []string{}
+		for _, m := range l.loading[i:] {
+			cycle = append(cycle, m.Path)
+		}
+		cycle = append(cycle, resolved)
+		return &object.Error{Code: diag.CodeImportCycle, Message: "import cycle: " + strings.Join(cycle, " -> ")}
+	}
+
+	if module, ok := l.modules[key]; ok {
+		return module
+	}
+
+	src, err := l.ReadFile(resolved)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &object.Error{Code: diag.CodeModuleNotFound, Message: fmt.Sprintf("module not found: %s", resolved)}
+		}
+		return &object.Error{Code: diag.CodeModuleNotFound, Message: fmt.Sprintf("cannot read module %s: %v", resolved, err)}
+	}
+
+	lex := lexer.New(string(src))
+	p := parser.New(lex)
+	program := p.ParseProgram()
+	if first, ok := firstError(append(lex.Diagnostics(), p.Diagnostics()...)); ok {
+		return &object.Error{Code: diag.CodeModuleSyntax, Message: fmt.Sprintf("parse error in module %s:%d:%d: %s",
+			resolved, first.Range.Start.Line, first.Range.Start.Column, first.Message)}
+	}
+
+	module := &object.Module{Path: resolved, Importer: l}
+	env := object.NewModuleEnvironment(module, l.Globals)
+	l.loading = append(l.loading, module)
+	result := l.Exec(program, env)
+	l.loading = l.loading[:len(l.loading)-1]
+	if isError(result) {
+		return result
+	}
+
+	l.modules[key] = module
+	return module
+}
+
+// moduleKey identifica un archivo independientemente de cómo se escribió
+// su ruta.
+func moduleKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// importModule carga el módulo path importado desde el módulo de env.
+func importModule(path string, env *object.Environment) object.Object {
+	module := env.Module()
+	if module == nil || module.Importer == nil {
+		return newError("import is not available outside of a module")
+	}
+	return module.Importer.Import(module, path)
+}
+
+// exportName marca name como exportado por el módulo de env. Sólo se puede
+// exportar desde el entorno global del módulo.
+func exportName(env *object.Environment, name string) object.Object {
+	module := env.Module()
+	if module == nil {
+		return nil
+	}
+	if module.Env != env {
+		return newError("export is only allowed at the top level of a module")
+	}
+	module.Export(name)
+	return nil
+}
+
+// modulePath devuelve la ruta del módulo al que pertenece env.
+func modulePath(env *object.Environment) string {
+	if module := env.Module(); module != nil {
+		return module.Path
+	}
+	return ""
+}
+
+// firstError devuelve el primer diagnóstico de nivel Error de ds.
+func firstError(ds []diag.Diagnostic) (diag.Diagnostic, bool) {
+	for _, d := range ds {
+		if d.Level == diag.Error {
+			return d, true
+		}
+	}
+	return diag.Diagnostic{}, false
+}
@@ -0,0 +1,110 @@
+package evaluator
+
+import (
+	"go-rilla/ast"
+	"go-rilla/object"
+)
+
+// Este archivo expone la semántica de operadores del evaluador para que
+// otros backends (p. ej. el paquete vm) produzcan exactamente los mismos
+// valores y mensajes de error que Eval.
+
+// PrefixOperation aplica un operador prefijo ("!" o "-") a right. strict
+// es Limits.StrictIntegers.
+func PrefixOperation(operator string, right object.Object, strict bool) object.Object {
+	return evalPrefixExpression(operator, right, strict)
+}
+
+// InfixOperation aplica un operador infijo. display es el operador tal como
+// se escribió en el código (p. ej. "+=" para una asignación compuesta) y se
+// usa en los mensajes de error.
+func InfixOperation(operator string, left, right object.Object, display string, strict bool) object.Object {
+	return evalInfixExpression(operator, left, right, display, strict)
+}
+
+// UpdateOperation calcula el nuevo valor de current para "++" o "--".
+func UpdateOperation(operator string, current object.Object, strict bool) object.Object {
+	return evalUpdateOperation(operator, current, strict)
+}
+
+// IndexOperation evalúa left[index].
+func IndexOperation(left, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+// CheckIndexAssignment devuelve un error si collection[index] no es un
+// destino de asignación válido, o nil en caso contrario.
+func CheckIndexAssignment(collection, index object.Object) object.Object {
+	if err := checkIndexAssignment(collection, index); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetIndexOperation escribe value en collection[index], que debe haber
+// pasado CheckIndexAssignment.
+func SetIndexOperation(collection, index, value object.Object) {
+	setIndex(collection, index, value)
+}
+
+// IndexUpdateOperation aplica "++" o "--" sobre collection[index] y devuelve
+// el valor previo y el actualizado (ambos el error si la operación falla).
+func IndexUpdateOperation(operator string, collection, index object.Object, strict bool) (previous, updated object.Object) {
+	return indexUpdate(operator, collection, index, strict)
+}
+
+// NewHash construye un Hash a partir de pares clave/valor ya evaluados.
+func NewHash(pairs []object.HashPair) object.Object {
+	hash := object.NewHash(len(pairs))
+	for _, pair := range pairs {
+		hashKey, ok := object.HashKeyOf(pair.Key)
+		if !ok {
+			return newError("unusable as hash key: %s", pair.Key.Type())
+		}
+		hash.Set(hashKey, pair)
+	}
+	return hash
+}
+
+// MemberOperation evalúa receiver.name: una clave de un hash o un método
+// del tipo de receiver.
+func MemberOperation(receiver object.Object, name string) object.Object {
+	return evalMemberExpression(receiver, name)
+}
+
+// ThrowOperation devuelve el error que propaga throw value.
+func ThrowOperation(value object.Object) *object.Error {
+	return throwValue(value)
+}
+
+// ImportOperation carga el módulo path importado desde el módulo de env.
+func ImportOperation(env *object.Environment, path string) object.Object {
+	return importModule(path, env)
+}
+
+// ExportOperation marca name como exportado por el módulo de env. Devuelve
+// un error si env no es el entorno global del módulo, o nil.
+func ExportOperation(env *object.Environment, name string) object.Object {
+	return exportName(env, name)
+}
+
+// IsTruthy indica si obj se considera verdadero en una condición.
+func IsTruthy(obj object.Object) bool { return isTruthy(obj) }
+
+// LookupBuiltin devuelve la función Built-In registrada con name.
+func LookupBuiltin(name string) (*object.Builtin, bool) {
+	builtin, ok := builtins[name]
+	return builtin, ok
+}
+
+// CalleeName devuelve el nombre con el que se muestra una llamada a fn en la
+// pila de un error.
+func CalleeName(fn ast.Expression) string {
+	switch fn := fn.(type) {
+	case *ast.Identifier:
+		return fn.Value
+	case *ast.MemberExpression:
+		return fn.String()
+	}
+	return "<anonymous>"
+}
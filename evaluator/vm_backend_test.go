@@ -0,0 +1,102 @@
+package evaluator_test
+
+import (
+	"context"
+	"go-rilla/ast"
+	"go-rilla/compiler"
+	"go-rilla/evaluator"
+	"go-rilla/object"
+	"go-rilla/vm"
+	"testing"
+)
+
+// vmExec compila program y lo ejecuta en la VM sobre env, devolviendo el
+// mismo valor (o *object.Error) que devolvería evaluator.EvalContext.
+func vmExec(ctx context.Context, program *ast.Program, env *object.Environment, limits evaluator.Limits) object.Object {
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		return &object.Error{Message: err.Error()}
+	}
+
+	machine := vm.New(comp.Bytecode(), env)
+	machine.Context, machine.Limits = ctx, limits
+	if err := machine.Run(); err != nil {
+		if errObj, ok := err.(*object.Error); ok {
+			return errObj
+		}
+		return &object.Error{Message: err.Error()}
+	}
+	return machine.LastPoppedStackElem()
+}
+
+// TestVMBackend corre los casos de evaluator_test.go sobre la VM para
+// garantizar que ambos backends se comporten igual. TestFunctionObject
+// queda afuera porque inspecciona la representación interna del
+// *object.Function del evaluador.
+func TestVMBackend(t *testing.T) {
+	restore := evaluator.SetTestExec(vmExec)
+	defer restore()
+
+	tests := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{"EvalIntegerExpression", evaluator.TestEvalIntegerExpression},
+		{"StructuralComparison", evaluator.TestStructuralComparison},
+		{"HashOrder", evaluator.TestHashOrder},
+		{"HashableKeys", evaluator.TestHashableKeys},
+		{"WrongNumberOfArguments", evaluator.TestWrongNumberOfArguments},
+		{"ExactIntegers", evaluator.TestExactIntegers},
+		{"StrictIntegers", evaluator.TestStrictIntegers},
+		{"EvalBooleanExpression", evaluator.TestEvalBooleanExpression},
+		{"BangOperator", evaluator.TestBangOperator},
+		{"IfElseExpressions", evaluator.TestIfElseExpressions},
+		{"ElseIfExpressions", evaluator.TestElseIfExpressions},
+		{"ConditionalExpressions", evaluator.TestConditionalExpressions},
+		{"ReturnStatements", evaluator.TestReturnStatements},
+		{"ErrorHandling", evaluator.TestErrorHandling},
+		{"ErrorLocation", evaluator.TestErrorLocation},
+		{"LetStatements", evaluator.TestLetStatements},
+		{"FunctionApplication", evaluator.TestFunctionApplication},
+		{"Closures", evaluator.TestClosures},
+		{"StringLiteral", evaluator.TestStringLiteral},
+		{"StringEscapes", evaluator.TestStringEscapes},
+		{"StringInterpolation", evaluator.TestStringInterpolation},
+		{"StringConcatenation", evaluator.TestStringConcatenation},
+		{"BuiltinFunctions", evaluator.TestBuiltinFunctions},
+		{"ArrayLiterals", evaluator.TestArrayLiterals},
+		{"ArrayIndexExpressions", evaluator.TestArrayIndexExpressions},
+		{"HashLiterals", evaluator.TestHashLiterals},
+		{"HashIndexExpressions", evaluator.TestHashIndexExpressions},
+		{"AndOperator", evaluator.TestAndOperator},
+		{"OrOperator", evaluator.TestOrOperator},
+		{"ShortCircuit", evaluator.TestShortCircuit},
+		{"CompoundOperators", evaluator.TestCompoundOperators},
+		{"EvalFloatExpression", evaluator.TestEvalFloatExpression},
+		{"WhileExpression", evaluator.TestWhileExpression},
+		{"AssignmentExpression", evaluator.TestAssignmentExpression},
+		{"IndexAssignment", evaluator.TestIndexAssignment},
+		{"IndexAssignmentErrors", evaluator.TestIndexAssignmentErrors},
+		{"MemberExpressions", evaluator.TestMemberExpressions},
+		{"MemberExpressionErrors", evaluator.TestMemberExpressionErrors},
+		{"TryExpressions", evaluator.TestTryExpressions},
+		{"UncaughtThrow", evaluator.TestUncaughtThrow},
+		{"Imports", evaluator.TestImports},
+		{"ImportErrors", evaluator.TestImportErrors},
+		{"ConcurrentEvaluation", evaluator.TestConcurrentEvaluation},
+		{"BreakStatementEvaluation", evaluator.TestBreakStatementEvaluation},
+		{"ContinueStatementEvaluation", evaluator.TestContinueStatementEvaluation},
+		{"BreakContinueOutsideLoop", evaluator.TestBreakContinueOutsideLoop},
+		{"ForLoopEvaluation", evaluator.TestForLoopEvaluation},
+		{"PostfixOperators", evaluator.TestPostfixOperators},
+		{"ExponentiationOperator", evaluator.TestExponentiationOperator},
+		{"IncrementDecrementPrefix", evaluator.TestIncrementDecrementPrefix},
+		{"VariableScopes", evaluator.TestVariableScopes},
+		{"Limits", evaluator.TestLimits},
+		{"EvalContextCancellation", evaluator.TestEvalContextCancellation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, tt.fn)
+	}
+}
@@ -0,0 +1,736 @@
+// Package format imprime programas de go-rilla con un formato canónico:
+// indentación de cuatro espacios, un statement por línea, espacios
+// alrededor de los operadores y sólo los paréntesis necesarios. Los
+// comentarios del código original se conservan.
+package format
+
+import (
+	"fmt"
+	"go-rilla/ast"
+	"go-rilla/diag"
+	"go-rilla/lexer"
+	"go-rilla/parser"
+	"go-rilla/source"
+	"go-rilla/token"
+	"math"
+	"strings"
+)
+
+const indentUnit = "    "
+
+// Source formatea el código src. Si src tiene errores de sintaxis no se
+// formatea y se devuelve el primero.
+func Source(src []byte) ([]byte, error) {
+	l := lexer.New(string(src))
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	for _, d := range append(l.Diagnostics(), p.Diagnostics()...) {
+		if d.Level == diag.Error {
+			return nil, fmt.Errorf("%d:%d: %s", d.Range.Start.Line, d.Range.Start.Column, d.Message)
+		}
+	}
+	if errs := p.Errors(); len(errs) > 0 {
+		return nil, fmt.Errorf("%s", errs[0])
+	}
+
+	pr := &printer{comments: collectComments(string(src))}
+	pr.program(program)
+	return []byte(pr.out.String()), nil
+}
+
+// Node devuelve node con el formato canónico. Sin el código fuente no hay
+// comentarios que conservar.
+func Node(node ast.Node) string {
+	pr := &printer{}
+	switch node := node.(type) {
+	case *ast.Program:
+		pr.program(node)
+	case ast.Statement:
+		pr.statement(node)
+	case ast.Expression:
+		pr.expression(node, parser.LOWEST)
+	}
+	return pr.out.String()
+}
+
+// comment es un comentario del original. Al formatear queda pegado al
+// token que lo precede, que terminaba en after.
+type comment struct {
+	token.Comment
+	after source.Position
+}
+
+// trailing indica si el comentario estaba en la misma línea que el token
+// que lo precede.
+func (c comment) trailing() bool { return c.Range.Start.Line == c.after.Line }
+
+// collectComments devuelve, en orden, todos los comentarios de src.
+func collectComments(src string) []comment {
+	var comments []comment
+	var after source.Position
+	l := lexer.New(src)
+	for {
+		tok := l.NextToken()
+		for _, c := range tok.Comments {
+			comments = append(comments, comment{Comment: c, after: after})
+		}
+		if tok.Type == token.EOF {
+			return comments
+		}
+		after = tok.Range.End
+	}
+}
+
+type printer struct {
+	out      strings.Builder
+	indent   int
+	comments []comment
+	next     int // índice del próximo comentario sin imprimir
+}
+
+func (p *printer) write(s string) { p.out.WriteString(s) }
+
+func (p *printer) writeIndent() {
+	p.write(strings.Repeat(indentUnit, p.indent))
+}
+
+func (p *printer) program(program *ast.Program) {
+	p.statements(program.Statements, math.MaxInt)
+}
+
+// statements imprime una línea por statement, conservando una línea en
+// blanco donde el original tenía una o más. end es el offset del final del
+// bloque: los comentarios anteriores a él se imprimen antes de cerrarlo.
+func (p *printer) statements(stmts []ast.Statement, end int) {
+	lastLine := -1 // línea del original donde terminó lo último impreso
+	for i, stmt := range stmts {
+		r := ast.NodeRange(stmt)
+		lastLine = p.ownLineComments(r.Start.Offset, lastLine)
+		if lastLine >= 0 && r.Start.Line > lastLine+1 {
+			p.write("\n")
+		}
+
+		p.writeIndent()
+		p.statement(stmt)
+		var next ast.Statement
+		if i+1 < len(stmts) {
+			next = stmts[i+1]
+		}
+		p.write(terminator(stmt, next))
+		lastLine = p.trailingComments(r, end)
+		p.write("\n")
+	}
+	p.ownLineComments(end, lastLine)
+}
+
+// ownLineComments imprime, cada uno en su línea, los comentarios que
+// empiezan antes de offset. Devuelve la línea donde terminó el último.
+func (p *printer) ownLineComments(offset, lastLine int) int {
+	for p.next < len(p.comments) && p.comments[p.next].Range.Start.Offset < offset {
+		c := p.comments[p.next]
+		p.next++
+		if lastLine >= 0 && c.Range.Start.Line > lastLine+1 {
+			p.write("\n")
+		}
+		p.writeIndent()
+		p.write(c.Text)
+		p.write("\n")
+		lastLine = c.Range.End.Line
+	}
+	return lastLine
+}
+
+// trailingComments imprime al final de la línea del statement que ocupa r
+// los comentarios que quedaron dentro de él y los que siguen en su misma
+// línea antes de end, el final del bloque. Devuelve la línea donde termina
+// lo impreso.
+func (p *printer) trailingComments(r source.Range, end int) int {
+	lastLine := r.End.Line
+	for p.next < len(p.comments) {
+		c := p.comments[p.next]
+		if c.Range.Start.Offset >= end ||
+			c.Range.Start.Offset >= r.End.Offset && c.Range.Start.Line != r.End.Line {
+			break
+		}
+		p.next++
+		p.write(" ")
+		p.write(c.Text)
+		if c.Range.End.Line > lastLine {
+			lastLine = c.Range.End.Line
+		}
+	}
+	return lastLine
+}
+
+// lineEndComments imprime a continuación los comentarios anteriores a
+// offset que estaban en la misma línea que el token que los precede, como
+// el de "{ // ..." o el de un elemento de un literal de varias líneas.
+func (p *printer) lineEndComments(offset int) {
+	for p.next < len(p.comments) {
+		c := p.comments[p.next]
+		if c.Range.Start.Offset >= offset || !c.trailing() {
+			return
+		}
+		p.next++
+		p.write(" ")
+		p.write(c.Text)
+	}
+}
+
+// commentsBefore imprime, en medio de una expresión, los comentarios que
+// empiezan antes de pos, el comienzo del próximo token. Cada uno va en la
+// línea del token que lo precede si así estaba en el original y, si no, en
+// una línea propia. Si después de un comentario el original seguía en otra
+// línea, la expresión continúa en la siguiente con un nivel más de
+// indentación.
+func (p *printer) commentsBefore(pos source.Position) {
+	p.commentsBeforeIndent(pos, p.indent+1)
+}
+
+// commentsBeforeIndent es commentsBefore con indent como indentación de las
+// líneas que empiezan después de un comentario.
+func (p *printer) commentsBeforeIndent(pos source.Position, indent int) {
+	for p.next < len(p.comments) && p.comments[p.next].Range.Start.Offset < pos.Offset {
+		c := p.comments[p.next]
+		p.next++
+		if c.trailing() {
+			p.trimSpace()
+			p.write(" ")
+		} else {
+			p.newLine(indent)
+		}
+		p.write(c.Text)
+		if strings.HasPrefix(c.Text, "//") || c.Range.End.Line < pos.Line {
+			p.newLine(indent)
+		} else {
+			p.write(" ")
+		}
+	}
+}
+
+// keyword imprime entre espacios word, la palabra clave que separa dos
+// bloques (else, catch, finally), después de los comentarios que siguen a
+// prev, la llave que cierra el bloque anterior. Los que siguen a word
+// quedan para lo que empieza en next. Si un comentario dejó la línea
+// vacía, word la empieza.
+func (p *printer) keyword(word string, prev token.Token, next source.Position) {
+	for i := p.next; i < len(p.comments) && p.comments[i].Range.Start.Offset < next.Offset; i++ {
+		if p.comments[i].after.Offset > prev.Range.End.Offset {
+			next = p.comments[i].Range.Start
+			break
+		}
+	}
+	p.commentsBeforeIndent(next, p.indent)
+	if strings.HasSuffix(strings.TrimRight(p.out.String(), " "), "\n") {
+		p.trimSpace()
+		p.writeIndent()
+		p.write(word + " ")
+		return
+	}
+	p.trimSpace()
+	p.write(" " + word + " ")
+}
+
+// closing imprime text, el token de cierre tok, después de los comentarios
+// que lo preceden y sin espacio antes.
+func (p *printer) closing(tok token.Token, text string) {
+	p.commentsBefore(tok.Range.Start)
+	p.trimSpace()
+	p.write(text)
+}
+
+// newLine termina la línea en curso, si no está vacía, y empieza otra con
+// indent niveles de indentación.
+func (p *printer) newLine(indent int) {
+	p.trimSpace()
+	if out := p.out.String(); out != "" && !strings.HasSuffix(out, "\n") {
+		p.write("\n")
+	}
+	p.write(strings.Repeat(indentUnit, indent))
+}
+
+// trimSpace borra los espacios al final de lo impreso.
+func (p *printer) trimSpace() {
+	out := p.out.String()
+	if trimmed := strings.TrimRight(out, " "); len(trimmed) != len(out) {
+		p.out.Reset()
+		p.write(trimmed)
+	}
+}
+
+// hasComments indica si queda algún comentario sin imprimir dentro de r.
+func (p *printer) hasComments(r source.Range) bool {
+	return p.next < len(p.comments) && p.comments[p.next].Range.Start.Offset < r.End.Offset &&
+		p.comments[p.next].Range.Start.Offset >= r.Start.Offset
+}
+
+func (p *printer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		p.write("let " + stmt.Name.Value + " = ")
+		p.expression(stmt.Value, parser.LOWEST)
+	case *ast.ExportStatement:
+		p.write("export ")
+		p.statement(stmt.Statement)
+	case *ast.ReturnStatement:
+		p.write("return")
+		if stmt.ReturnValue != nil {
+			p.write(" ")
+			p.expression(stmt.ReturnValue, parser.LOWEST)
+		}
+	case *ast.ThrowStatement:
+		p.write("throw ")
+		p.expression(stmt.Value, parser.LOWEST)
+	case *ast.BreakStatement:
+		p.write("break")
+	case *ast.ContinueStatement:
+		p.write("continue")
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression, parser.LOWEST)
+	case *ast.BlockStatement:
+		p.block(stmt)
+	}
+}
+
+// terminator devuelve el ";" que cierra stmt. Los if, bucles y try no lo
+// llevan salvo que el statement siguiente empiece con un token que el parser
+// tomaría como la continuación de la expresión.
+func terminator(stmt ast.Statement, next ast.Statement) string {
+	es, ok := stmt.(*ast.ExpressionStatement)
+	if !ok || !endsWithBlock(es.Expression) {
+		return ";"
+	}
+	if nextStmt, ok := next.(*ast.ExpressionStatement); ok && continuesExpression(nextStmt.Expression) {
+		return ";"
+	}
+	return ""
+}
+
+func endsWithBlock(e ast.Expression) bool {
+	switch e.(type) {
+	case *ast.IfExpression, *ast.WhileExpression, *ast.TryExpression:
+		return true
+	}
+	return false
+}
+
+// continuesExpression indica si el código impreso de e empieza con "(",
+// "[" o un operador que también es infijo o postfijo.
+func continuesExpression(e ast.Expression) bool {
+	for {
+		if prefix, ok := e.(*ast.PrefixExpression); ok {
+			return prefix.Operator != "!"
+		}
+		left, min := leftOperand(e)
+		if left == nil {
+			_, isArray := e.(*ast.ArrayLiteral)
+			return isArray
+		}
+		if needsParens(left, min) || isMemberOfNumber(e, left) {
+			return true
+		}
+		e = left
+	}
+}
+
+// leftOperand devuelve el operando que se imprime primero en e y la
+// precedencia mínima que necesita para no ir entre paréntesis, o nil si e
+// empieza con un token propio.
+func leftOperand(e ast.Expression) (ast.Expression, int) {
+	switch e := e.(type) {
+	case *ast.InfixExpression:
+		min := parser.Precedence(e.Token.Type)
+		if rightAssociative(e.Token.Type) {
+			min++
+		}
+		return e.Left, min
+	case *ast.ConditionalExpression:
+		return e.Condition, parser.TERNARY + 1
+	case *ast.PostfixExpression:
+		return e.Left, parser.CALL
+	case *ast.CallExpression:
+		return e.Function, parser.CALL
+	case *ast.IndexExpression:
+		return e.Left, parser.CALL
+	case *ast.MemberExpression:
+		return e.Object, parser.CALL
+	}
+	return nil, 0
+}
+
+// rightAssociative indica si el operador t asocia a derecha, como en el
+// parser.
+func rightAssociative(t token.TokenType) bool {
+	switch t {
+	case token.STAR_STAR, token.ASSIGN, token.SUM_ASSIGN, token.SUB_ASSIGN:
+		return true
+	}
+	return false
+}
+
+// precedence devuelve la precedencia con la que el parser construye e. Los
+// literales y las expresiones que empiezan con una palabra clave no
+// necesitan paréntesis.
+func precedence(e ast.Expression) int {
+	switch e := e.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(e.Token.Type)
+	case *ast.ConditionalExpression:
+		return parser.TERNARY
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.PostfixExpression:
+		return parser.POSTFIX
+	case *ast.CallExpression:
+		return parser.CALL
+	case *ast.MemberExpression:
+		return parser.SELECT
+	case *ast.IndexExpression:
+		return parser.INDEX
+	}
+	return parser.POSTFIX + 1
+}
+
+func needsParens(e ast.Expression, min int) bool {
+	return precedence(e) < min
+}
+
+// isMemberOfNumber indica si e es un acceso a miembro sobre un literal
+// numérico, que sin paréntesis se leería como un float ("5.len").
+func isMemberOfNumber(e, object ast.Expression) bool {
+	if _, ok := e.(*ast.MemberExpression); !ok {
+		return false
+	}
+	switch object.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral:
+		return true
+	}
+	return false
+}
+
+// expression imprime e, entre paréntesis si su precedencia es menor que min.
+func (p *printer) expression(e ast.Expression, min int) {
+	p.commentsBefore(ast.NodeRange(e).Start)
+	if needsParens(e, min) {
+		p.write("(")
+		p.expression(e, parser.LOWEST)
+		p.write(")")
+		return
+	}
+
+	switch e := e.(type) {
+	case *ast.Identifier:
+		p.write(e.Value)
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.Boolean:
+		p.write(e.TokenLiteral())
+	case *ast.StringLiteral:
+		p.write(`"` + e.Token.Literal + `"`)
+	case *ast.InterpolatedString:
+		p.write(`"`)
+		for i, part := range e.Parts {
+			if i%2 == 0 {
+				p.write(part.(*ast.StringLiteral).Token.Literal)
+				continue
+			}
+			p.write("${")
+			p.expression(part, parser.LOWEST)
+			p.write("}")
+		}
+		p.write(`"`)
+	case *ast.PrefixExpression:
+		p.write(e.Operator)
+		if e.Operator == "-" && continuesWithMinus(e.Right) {
+			p.write("(")
+			p.expression(e.Right, parser.LOWEST)
+			p.write(")")
+			return
+		}
+		p.expression(e.Right, parser.PREFIX)
+	case *ast.PostfixExpression:
+		p.expression(e.Left, parser.CALL)
+		p.write(e.Operator)
+	case *ast.InfixExpression:
+		left, min := leftOperand(e)
+		p.expression(left, min)
+		p.write(" " + e.Token.Literal + " ")
+		rightMin := parser.Precedence(e.Token.Type) + 1
+		if rightAssociative(e.Token.Type) {
+			rightMin--
+		}
+		p.expression(e.Right, rightMin)
+	case *ast.ConditionalExpression:
+		p.expression(e.Condition, parser.TERNARY+1)
+		p.write(" ? ")
+		p.expression(e.Consequence, parser.LOWEST)
+		p.write(" : ")
+		p.expression(e.Alternative, parser.TERNARY)
+	case *ast.CallExpression:
+		p.expression(e.Function, parser.CALL)
+		p.write("(")
+		p.list(e.Arguments)
+		p.closing(e.EndToken, ")")
+	case *ast.IndexExpression:
+		p.expression(e.Left, parser.CALL)
+		p.write("[")
+		p.expression(e.Index, parser.LOWEST)
+		p.closing(e.EndToken, "]")
+	case *ast.MemberExpression:
+		if isMemberOfNumber(e, e.Object) {
+			p.write("(")
+			p.expression(e.Object, parser.LOWEST)
+			p.write(")")
+		} else {
+			p.expression(e.Object, parser.CALL)
+		}
+		p.write("." + e.Property.Value)
+	case *ast.ArrayLiteral:
+		p.arrayLiteral(e)
+	case *ast.HashLiteral:
+		p.hashLiteral(e)
+	case *ast.FunctionLiteral:
+		p.write("fn(")
+		for i, param := range e.Parameters {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.write(param.Value)
+		}
+		p.write(") ")
+		p.functionBody(e.Body)
+	case *ast.IfExpression:
+		p.ifExpression(e)
+	case *ast.WhileExpression:
+		p.loop(e)
+	case *ast.TryExpression:
+		p.write("try ")
+		p.block(e.Block)
+		if e.Catch != nil {
+			next := e.Catch.Token.Range.Start
+			if e.CatchParameter != nil {
+				next = e.CatchParameter.Token.Range.Start
+			}
+			p.keyword("catch", e.Block.EndToken, next)
+			if e.CatchParameter != nil {
+				p.write("(" + e.CatchParameter.Value + ") ")
+			}
+			p.block(e.Catch)
+		}
+		if e.Finally != nil {
+			prev := e.Block.EndToken
+			if e.Catch != nil {
+				prev = e.Catch.EndToken
+			}
+			p.keyword("finally", prev, e.Finally.Token.Range.Start)
+			p.block(e.Finally)
+		}
+	case *ast.ImportExpression:
+		p.write(`import "` + e.Path.Token.Literal + `"`)
+	}
+}
+
+// continuesWithMinus indica si e se imprime empezando con "-": después de
+// otro "-" se leería como "--".
+func continuesWithMinus(e ast.Expression) bool {
+	for {
+		if prefix, ok := e.(*ast.PrefixExpression); ok {
+			return strings.HasPrefix(prefix.Operator, "-")
+		}
+		left, min := leftOperand(e)
+		if left == nil || needsParens(left, min) || isMemberOfNumber(e, left) {
+			return false
+		}
+		e = left
+	}
+}
+
+func (p *printer) list(elements []ast.Expression) {
+	for i, element := range elements {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expression(element, parser.LOWEST)
+	}
+}
+
+func (p *printer) ifExpression(e *ast.IfExpression) {
+	p.write("if (")
+	p.expression(e.Condition, parser.LOWEST)
+	p.write(") ")
+	p.block(e.Consequence)
+	if e.ElseIf != nil {
+		p.keyword("else", e.Consequence.EndToken, e.ElseIf.Token.Range.Start)
+		p.ifExpression(e.ElseIf)
+	} else if e.Alternative != nil {
+		p.keyword("else", e.Consequence.EndToken, e.Alternative.Token.Range.Start)
+		p.block(e.Alternative)
+	}
+}
+
+// loop imprime un while o un for; ambos se representan con WhileExpression.
+func (p *printer) loop(e *ast.WhileExpression) {
+	if e.Token.Type != token.FOR {
+		p.write("while (")
+		p.expression(e.Condition, parser.LOWEST)
+		p.write(") ")
+		p.block(e.Body)
+		return
+	}
+
+	p.write("for (")
+	if e.Init != nil {
+		p.statement(e.Init)
+	}
+	p.write(";")
+	if e.Condition != nil {
+		p.write(" ")
+		p.expression(e.Condition, parser.LOWEST)
+	}
+	p.write(";")
+	if e.Post != nil {
+		p.write(" ")
+		p.statement(e.Post)
+	}
+	p.write(") ")
+	p.block(e.Body)
+}
+
+// functionBody imprime el cuerpo de una función. Si tiene un único statement
+// simple y en el original ocupaba una sola línea se mantiene en línea:
+// fn(x) { x * 2 }.
+func (p *printer) functionBody(b *ast.BlockStatement) {
+	p.commentsBeforeIndent(b.Token.Range.Start, p.indent)
+	if inline, ok := p.inlineBlock(b, ast.NodeRange(b)); ok {
+		p.write("{ " + inline + " }")
+		return
+	}
+	p.block(b)
+}
+
+// block imprime un bloque entre llaves con un statement por línea.
+func (p *printer) block(b *ast.BlockStatement) {
+	// Un comentario antes de "{" lo deja en otra línea, que no es una
+	// continuación: la llave va alineada con el resto del bloque.
+	p.commentsBeforeIndent(b.Token.Range.Start, p.indent)
+	if len(b.Statements) == 0 && !p.hasComments(ast.NodeRange(b)) {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.lineEndComments(firstOffset(b.Statements, b.EndToken))
+	p.write("\n")
+	p.indent++
+	p.statements(b.Statements, b.EndToken.Range.Start.Offset)
+	p.indent--
+	p.writeIndent()
+	p.write("}")
+}
+
+func (p *printer) inlineBlock(b *ast.BlockStatement, r source.Range) (string, bool) {
+	if len(b.Statements) != 1 || r.Start.Line != r.End.Line || p.hasComments(r) {
+		return "", false
+	}
+	switch stmt := b.Statements[0].(type) {
+	case *ast.ReturnStatement:
+	case *ast.ExpressionStatement:
+		if endsWithBlock(stmt.Expression) {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+
+	sub := &printer{indent: p.indent, comments: p.comments, next: p.next}
+	sub.statement(b.Statements[0])
+	text := sub.out.String()
+	if strings.Contains(text, "\n") {
+		return "", false
+	}
+	return text, true
+}
+
+// multiline indica si el literal ocupaba varias líneas en el original; en
+// ese caso se imprime un elemento por línea.
+func multiline(e ast.Expression, elements int) bool {
+	r := ast.NodeRange(e)
+	return elements > 0 && r.Start.Line != r.End.Line
+}
+
+// firstOffset devuelve el offset del primero de nodes o, si no hay, el de
+// end.
+func firstOffset[T ast.Node](nodes []T, end token.Token) int {
+	if len(nodes) == 0 {
+		return end.Range.Start.Offset
+	}
+	return ast.NodeRange(nodes[0]).Start.Offset
+}
+
+func (p *printer) arrayLiteral(e *ast.ArrayLiteral) {
+	if !multiline(e, len(e.Elements)) {
+		p.write("[")
+		p.list(e.Elements)
+		p.closing(e.EndToken, "]")
+		return
+	}
+
+	p.write("[")
+	p.lineEndComments(firstOffset(e.Elements, e.EndToken))
+	p.write("\n")
+	p.indent++
+	for i, element := range e.Elements {
+		p.ownLineComments(ast.NodeRange(element).Start.Offset, -1)
+		p.writeIndent()
+		p.expression(element, parser.LOWEST)
+		if i < len(e.Elements)-1 {
+			p.write(",")
+		}
+		p.lineEndComments(firstOffset(e.Elements[i+1:], e.EndToken))
+		p.write("\n")
+	}
+	p.ownLineComments(e.EndToken.Range.Start.Offset, -1)
+	p.indent--
+	p.writeIndent()
+	p.write("]")
+}
+
+func (p *printer) hashLiteral(e *ast.HashLiteral) {
+	keys := e.Keys
+
+	pair := func(key ast.Expression) {
+		p.expression(key, parser.LOWEST)
+		p.write(": ")
+		p.expression(e.Pairs[key], parser.LOWEST)
+	}
+
+	if !multiline(e, len(keys)) {
+		p.write("{")
+		for i, key := range keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			pair(key)
+		}
+		p.closing(e.EndToken, "}")
+		return
+	}
+
+	p.write("{")
+	p.lineEndComments(firstOffset(keys, e.EndToken))
+	p.write("\n")
+	p.indent++
+	for i, key := range keys {
+		p.ownLineComments(ast.NodeRange(key).Start.Offset, -1)
+		p.writeIndent()
+		pair(key)
+		if i < len(keys)-1 {
+			p.write(",")
+		}
+		p.lineEndComments(firstOffset(keys[i+1:], e.EndToken))
+		p.write("\n")
+	}
+	p.ownLineComments(e.EndToken.Range.Start.Offset, -1)
+	p.indent--
+	p.writeIndent()
+	p.write("}")
+}
@@ -0,0 +1,192 @@
+package format
+
+import (
+	"go-rilla/lexer"
+	"go-rilla/parser"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=1+2*3", "let x = 1 + 2 * 3;\n"},
+		{"(a + b) * c", "(a + b) * c;\n"},
+		{"((a * b)) + c", "a * b + c;\n"},
+		{"a - (b - c)", "a - (b - c);\n"},
+		{"(a - b) - c", "a - b - c;\n"},
+		{"2 ** 3 ** 2", "2 ** 3 ** 2;\n"},
+		{"(2 ** 3) ** 2", "(2 ** 3) ** 2;\n"},
+		{"x = c ? 1 : 2", "x = c ? 1 : 2;\n"},
+		{"(x = c) ? 1 : 2", "(x = c) ? 1 : 2;\n"},
+		{"a = (b = 1)", "a = b = 1;\n"},
+		{"-(-x)", "-(-x);\n"},
+		{"-(a + b)", "-(a + b);\n"},
+		{"(-x)++", "(-x)++;\n"},
+		{"x += 1", "x += 1;\n"},
+		{"a ? b : c ? d : e", "a ? b : c ? d : e;\n"},
+		{"(a ? b : c) ? d : e", "(a ? b : c) ? d : e;\n"},
+		{"(5).len", "(5).len;\n"},
+		{"(a + b).len()", "(a + b).len();\n"},
+		{"fn(x){x*2}(3)", "fn(x) { x * 2 }(3);\n"},
+		{`let s = "hi\n"`, "let s = \"hi\\n\";\n"},
+		{`"\u{1F98D} \x41"`, "\"\\u{1F98D} \\x41\";\n"},
+		{`"a ${ x+1 } \${b} ${ "c${d}" }"`, "\"a ${x + 1} \\${b} ${\"c${d}\"}\";\n"},
+		{"[1,2,3][0]", "[1, 2, 3][0];\n"},
+		{`{"b": 1, "a": 2}`, "{\"b\": 1, \"a\": 2};\n"},
+		{"fn(){}", "fn() {};\n"},
+		{`let m = import "lib.monkey"`, "let m = import \"lib.monkey\";\n"},
+		{"export let x = 1", "export let x = 1;\n"},
+		{"if (x) { 1 } else if (y) { 2 } else { 3 }",
+			"if (x) {\n    1;\n} else if (y) {\n    2;\n} else {\n    3;\n}\n"},
+		{"for (let i = 0; i < 3; i++) { print(i) }",
+			"for (let i = 0; i < 3; i++) {\n    print(i);\n}\n"},
+		{"for (; i < 3;) { i++ }", "for (; i < 3;) {\n    i++;\n}\n"},
+		{"while (true) { break; }", "while (true) {\n    break;\n}\n"},
+		{"try { throw 1 } catch (e) { e } finally { 2 }",
+			"try {\n    throw 1;\n} catch (e) {\n    e;\n} finally {\n    2;\n}\n"},
+		{"try { 1 } catch { 2 }", "try {\n    1;\n} catch {\n    2;\n}\n"},
+		// Sin el ";" el -1 se leería como una resta.
+		{"if (x) { 1 }; -1", "if (x) {\n    1;\n};\n-1;\n"},
+		{"if (x) { 1 }; !y", "if (x) {\n    1;\n}\n!y;\n"},
+		{"let a = 1;\n\n\n\nlet b = 2;", "let a = 1;\n\nlet b = 2;\n"},
+		{"let f = fn(x) {\n  let y = x;\n  y\n}",
+			"let f = fn(x) {\n    let y = x;\n    y;\n};\n"},
+		{"let a = [\n1,\n2]", "let a = [\n    1,\n    2\n];\n"},
+	}
+
+	for _, tt := range tests {
+		got, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if string(got) != tt.expected {
+			t.Errorf("Source(%q) wrong.\nexpected=%q\ngot=     %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	input := `// cabecera
+let x = 1; // uno
+
+// suma
+let f = fn(a, b) {
+    // dentro
+    a + b /* fin */
+    // al final del bloque
+};
+// al final del archivo`
+
+	expected := `// cabecera
+let x = 1; // uno
+
+// suma
+let f = fn(a, b) {
+    // dentro
+    a + b; /* fin */
+    // al final del bloque
+};
+// al final del archivo
+`
+
+	got, err := Source([]byte(input))
+	if err != nil {
+		t.Fatalf("Source returned error: %v", err)
+	}
+	if string(got) != expected {
+		t.Errorf("Source wrong.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
+
+// TestSourceCommentPlacement comprueba que cada comentario queda pegado al
+// token que lo precede y que formatear de nuevo no lo mueve.
+func TestSourceCommentPlacement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let h = {\n    \"a\": 1, // uno\n    \"b\": 2 // dos\n};\n",
+			"let h = {\n    \"a\": 1, // uno\n    \"b\": 2 // dos\n};\n"},
+		{"let a = [ // valores\n    1, // uno\n    // dos\n    2\n];\n",
+			"let a = [ // valores\n    1, // uno\n    // dos\n    2\n];\n"},
+		{"if (x) { // por qué\n    y\n}\n", "if (x) { // por qué\n    y;\n}\n"},
+		{"if (x) { 1 } // uno\nelse { 2 }\n", "if (x) {\n    1;\n} // uno\nelse {\n    2;\n}\n"},
+		{"if (x) { 1 } else // dos\n{ 2 }\n", "if (x) {\n    1;\n} else // dos\n{\n    2;\n}\n"},
+		{"if (x) // cond\n{ y }\n", "if (x) // cond\n{\n    y;\n}\n"},
+		{"try { a } /* t */ catch (e) { b }\n", "try {\n    a;\n} /* t */ catch (e) {\n    b;\n}\n"},
+		{"let a = [1, /* uno */ 2, 3];\n", "let a = [1, /* uno */ 2, 3];\n"},
+		{"f(1, /* x */ 2);\n", "f(1, /* x */ 2);\n"},
+		{"g(1 /* al final */);\n", "g(1 /* al final */);\n"},
+		{"print(a, // primero\n    b);\n", "print(a, // primero\n    b);\n"},
+		{"let v = // valor\n    5;\n", "let v = // valor\n    5;\n"},
+	}
+
+	for _, tt := range tests {
+		got, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if string(got) != tt.expected {
+			t.Errorf("Source(%q) wrong.\nexpected=%q\ngot=     %q", tt.input, tt.expected, got)
+			continue
+		}
+		again, err := Source(got)
+		if err != nil || string(again) != string(got) {
+			t.Errorf("formatting is not idempotent for %q.\nfirst=  %q\nsecond= %q", tt.input, got, again)
+		}
+	}
+}
+
+func TestSourceSyntaxError(t *testing.T) {
+	if _, err := Source([]byte("let = 1;")); err == nil {
+		t.Fatalf("expected an error for invalid source")
+	}
+}
+
+// TestSourceRoundTrip comprueba que el código formateado se parsea al mismo
+// AST que el original y que formatearlo de nuevo no lo cambia.
+func TestSourceRoundTrip(t *testing.T) {
+	inputs := []string{
+		"let a = 1 + 2 * 3 - 4 / 5 % 6;",
+		"a = b = c; a == b != c && d || e;",
+		"-a * (b + c) ** -d ** 2;",
+		"x ? y ? 1 : 2 : z ? 3 : 4;",
+		"f(g(1), [2, 3][0], {1: a.b.c(d)})[e]++;",
+		"let r = if (a < b) { a } else { b } + 1;",
+		"if (a) { b } (c); [d];",
+		"while (i > 0) { i--; if (i == 2) { continue; } }",
+		"let m = import \"m\"; m.x.len();",
+		"try { throw \"x\" } catch (e) { e.message } finally { print(1) }",
+		"fn(a) { fn(b) { a + b } }(1)(2);",
+	}
+
+	for _, input := range inputs {
+		formatted, err := Source([]byte(input))
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %v", input, err)
+			continue
+		}
+		if parse(t, input) != parse(t, string(formatted)) {
+			t.Errorf("formatting %q changed the program.\noriginal=  %s\nformatted= %s",
+				input, parse(t, input), parse(t, string(formatted)))
+		}
+		again, err := Source(formatted)
+		if err != nil || string(again) != string(formatted) {
+			t.Errorf("formatting is not idempotent for %q.\nfirst=  %q\nsecond= %q", input, formatted, again)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) string {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program.String()
+}
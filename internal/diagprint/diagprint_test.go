@@ -0,0 +1,230 @@
+package diagprint
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go-rilla/diag"
+	"go-rilla/source"
+)
+
+// span construye un rango a partir de offsets en src.
+func span(src string, start, end int) source.Range {
+	pos := func(offset int) source.Position {
+		before := src[:offset]
+		line := strings.Count(before, "\n") + 1
+		return source.Position{Offset: offset, Line: line, Column: offset - strings.LastIndex(before, "\n")}
+	}
+	return source.Range{Start: pos(start), End: pos(end)}
+}
+
+func TestRenderRich(t *testing.T) {
+	src := "let a = 1;\nlet b = a +;\nlet c = 3;\n"
+	at := strings.Index(src, ";\nlet c")
+	d := diag.Diagnostic{
+		Level:   diag.Error,
+		Code:    "PAR002",
+		Message: "No prefix parse function for ; found",
+		Hint:    "Unexpected token",
+		Range:   span(src, at, at+1),
+		Related: []diag.Related{{Message: "a is declared here", Range: span(src, 4, 5)}},
+	}
+
+	expected := `error[PAR002]: No prefix parse function for ; found
+ --> main.monkey:2:12
+  |
+1 | let a = 1;
+2 | let b = a +;
+  |            ^
+3 | let c = 3;
+  = hint: Unexpected token
+  = note: a is declared here (main.monkey:1:5)
+
+`
+	if got := RenderRich("main.monkey", src, []diag.Diagnostic{d}, false); got != expected {
+		t.Errorf("RenderRich wrong.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
+
+func TestRenderRichMultiLine(t *testing.T) {
+	src := "let f = fn() {\n    return 1;\n    print(2);\n    print(3);\n};"
+	start := strings.Index(src, "print(2)")
+	end := strings.Index(src, "print(3)") + len("print(3)")
+	d := diag.Diagnostic{Level: diag.Warning, Code: "LNT003", Message: "unreachable code after return", Range: span(src, start, end)}
+
+	expected := `warning[LNT003]: unreachable code after return
+ --> f.monkey:3:5
+  |
+2 |     return 1;
+3 |     print(2);
+  |     ^~~~~~~~~
+4 |     print(3);
+  |     ^~~~~~~~
+5 | };
+
+`
+	if got := RenderRich("f.monkey", src, []diag.Diagnostic{d}, false); got != expected {
+		t.Errorf("RenderRich wrong.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
+
+func TestRenderRichLongSpan(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, "x;")
+	}
+	src := strings.Join(lines, "\n")
+	d := diag.Diagnostic{Level: diag.Warning, Code: "LNT003", Message: "m", Range: span(src, 0, len(src))}
+
+	got := RenderRich("f.monkey", src, []diag.Diagnostic{d}, false)
+	if !strings.Contains(got, "\n...\n") || strings.Contains(got, "10 |") {
+		t.Errorf("middle lines of a long span should be omitted:\n%s", got)
+	}
+	if !strings.Contains(got, " 3 | x;") || !strings.Contains(got, "20 | x;") {
+		t.Errorf("first and last lines of a long span should be shown:\n%s", got)
+	}
+}
+
+func TestRenderRichColor(t *testing.T) {
+	d := diag.Diagnostic{Level: diag.Warning, Code: "LNT001", Message: "x is declared but never used", Range: span("let x = 1;", 4, 5)}
+
+	colored := RenderRich("f.monkey", "let x = 1;", []diag.Diagnostic{d}, true)
+	if !strings.Contains(colored, ansiYellow+"warning[LNT001]"+ansiReset) {
+		t.Errorf("warning title should be yellow:\n%q", colored)
+	}
+	plain := RenderRich("f.monkey", "let x = 1;", []diag.Diagnostic{d}, false)
+	if strings.Contains(plain, "\x1b[") {
+		t.Errorf("output without color has ANSI codes:\n%q", plain)
+	}
+}
+
+func TestColorEnabledNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if ColorEnabled() {
+		t.Errorf("NO_COLOR should disable colors")
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	src := "let pi = 3..14;"
+	d := diag.Diagnostic{Level: diag.Error, Code: "LEX002", Message: "Malformed float literal", Hint: "Remove the extra dot", Range: span(src, 9, 14)}
+	out := RenderJSON("f.monkey", src, []diag.Diagnostic{d, d})
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per diagnostic, got %q", out)
+	}
+	var got struct {
+		File, Level, Code, Message, Hint string
+		Start, End                       struct{ Line, Column, Offset int }
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", lines[0], err)
+	}
+	if got.File != "f.monkey" || got.Level != "error" || got.Code != "LEX002" || got.Hint != "Remove the extra dot" {
+		t.Errorf("JSON diagnostic wrong. got=%+v", got)
+	}
+	if got.Start.Line != 1 || got.Start.Column != 10 || got.Start.Offset != 9 {
+		t.Errorf("start wrong. got=%+v", got.Start)
+	}
+	if got.End.Line != 1 || got.End.Column != 15 || got.End.Offset != 14 {
+		t.Errorf("end wrong. got=%+v", got.End)
+	}
+}
+
+func TestRenderSARIF(t *testing.T) {
+	src := "let x = 1;\nlet f = fn() { let x = 2; x };\n"
+	inner := strings.Index(src, "x = 2")
+	outer := strings.Index(src, "x = 1")
+	files := []File{
+		{Name: "a.monkey", Source: src, Diagnostics: []diag.Diagnostic{{
+			Level:   diag.Warning,
+			Code:    "LNT002",
+			Message: "let x shadows a declaration in an outer scope",
+			Hint:    "Rename one of them to avoid confusion",
+			Range:   span(src, inner, inner+1),
+			Related: []diag.Related{{Message: "x is declared here", Range: span(src, outer, outer+1)}},
+		}}},
+		{Name: "b.monkey", Source: "let = 1;", Diagnostics: []diag.Diagnostic{
+			{Level: diag.Error, Code: "PAR001", Message: "Expected next token to be IDENT, got = instead", Range: span("let = 1;", 4, 5)},
+		}},
+	}
+
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string
+					Rules []struct {
+						ID               string
+						ShortDescription struct{ Text string }
+					}
+				}
+			}
+			Results []struct {
+				RuleID    string
+				RuleIndex int
+				Level     string
+				Message   struct{ Text string }
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct{ StartLine, StartColumn, EndColumn, ByteOffset, ByteLength int }
+					}
+				}
+				RelatedLocations []struct{ Message struct{ Text string } }
+				Properties       map[string]string
+			}
+		}
+	}
+	out := RenderSARIF(files)
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("invalid SARIF %q: %v", out, err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("SARIF log wrong. got=%+v", log)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "go-rilla" || len(run.Tool.Driver.Rules) != 2 || len(run.Results) != 2 {
+		t.Fatalf("SARIF run wrong. got=%+v", run)
+	}
+
+	if rule := run.Tool.Driver.Rules[0]; rule.ID != "LNT002" || rule.ShortDescription.Text != "shadowed declaration" {
+		t.Errorf("rule wrong. got=%+v", rule)
+	}
+
+	first := run.Results[0]
+	if first.RuleID != "LNT002" || first.Level != "warning" || run.Tool.Driver.Rules[first.RuleIndex].ID != "LNT002" {
+		t.Errorf("result wrong. got=%+v", first)
+	}
+	if first.Properties["hint"] != "Rename one of them to avoid confusion" {
+		t.Errorf("hint wrong. got=%v", first.Properties)
+	}
+	if len(first.RelatedLocations) != 1 || first.RelatedLocations[0].Message.Text != "x is declared here" {
+		t.Errorf("related locations wrong. got=%+v", first.RelatedLocations)
+	}
+	loc := first.Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "a.monkey" || loc.Region.StartLine != 2 || loc.Region.StartColumn != 20 ||
+		loc.Region.EndColumn != 21 || loc.Region.ByteOffset != inner || loc.Region.ByteLength != 1 {
+		t.Errorf("location wrong. got=%+v", loc)
+	}
+
+	second := run.Results[1]
+	if second.Level != "error" || run.Tool.Driver.Rules[second.RuleIndex].ID != "PAR001" ||
+		second.Locations[0].PhysicalLocation.ArtifactLocation.URI != "b.monkey" {
+		t.Errorf("result wrong. got=%+v", second)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"plain", "color", "JSON", "sarif"} {
+		if _, err := ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q) returned error: %v", name, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("ParseFormat(xml) should fail")
+	}
+}
@@ -0,0 +1,69 @@
+package diagprint
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go-rilla/diag"
+)
+
+// Format es el formato de salida de los diagnósticos.
+type Format string
+
+const (
+	// Plain es el formato original de una línea por diagnóstico.
+	Plain Format = "plain"
+	// Color es el formato enriquecido de RenderRich; usa colores sólo si
+	// la salida estándar es una terminal y NO_COLOR no está definida.
+	Color Format = "color"
+	// JSON imprime un objeto JSON por diagnóstico y línea (JSON lines).
+	JSON Format = "json"
+	// SARIF imprime un log SARIF 2.1.0 para herramientas de code scanning.
+	SARIF Format = "sarif"
+)
+
+// ParseFormat valida el nombre de un formato.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case Plain, Color, JSON, SARIF:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown diagnostics format %q; valid values are %q, %q, %q or %q", name, Plain, Color, JSON, SARIF)
+}
+
+// Machine indica si f es un formato para otras herramientas, en el que no
+// debe imprimirse texto libre junto a los diagnósticos.
+func (f Format) Machine() bool { return f == JSON || f == SARIF }
+
+// File agrupa los diagnósticos de un archivo con su código fuente.
+type File struct {
+	Name        string
+	Source      string
+	Diagnostics []diag.Diagnostic
+}
+
+// Render imprime diags en el formato f.
+func Render(f Format, filename, src string, diags []diag.Diagnostic) string {
+	switch f {
+	case Color:
+		return RenderRich(filename, src, diags, ColorEnabled())
+	case JSON:
+		return RenderJSON(filename, src, diags)
+	case SARIF:
+		return RenderSARIF([]File{{Name: filename, Source: src, Diagnostics: diags}})
+	default:
+		return RenderPlain(filename, src, diags)
+	}
+}
+
+// ColorEnabled indica si conviene usar colores ANSI: la salida estándar es
+// una terminal y la variable NO_COLOR (https://no-color.org) no está
+// definida.
+func ColorEnabled() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,92 @@
+package diagprint
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"go-rilla/diag"
+	"go-rilla/source"
+)
+
+type jsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+type jsonRelated struct {
+	Message string       `json:"message"`
+	File    string       `json:"file"`
+	Start   jsonPosition `json:"start"`
+	End     jsonPosition `json:"end"`
+}
+
+type jsonDiagnostic struct {
+	File    string        `json:"file"`
+	Level   string        `json:"level"`
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Hint    string        `json:"hint,omitempty"`
+	Start   jsonPosition  `json:"start"`
+	End     jsonPosition  `json:"end"`
+	Related []jsonRelated `json:"related,omitempty"`
+}
+
+// RenderJSON imprime un objeto JSON por diagnóstico y línea (JSON lines):
+//
+//	{"file":"main.monkey","level":"error","code":"PAR002","message":"...",
+//	 "start":{"line":1,"column":9,"offset":8},"end":{...}}
+//
+// Las líneas y columnas empiezan en 1 y las columnas se cuentan en
+// caracteres; los offsets son bytes desde el comienzo del archivo.
+func RenderJSON(filename, src string, diags []diag.Diagnostic) string {
+	var out strings.Builder
+	for _, d := range diags {
+		start, end := locateRange(src, d.Range)
+		jd := jsonDiagnostic{
+			File:    filename,
+			Level:   d.Level.String(),
+			Code:    d.Code,
+			Message: d.Message,
+			Hint:    d.Hint,
+			Start:   start,
+			End:     end,
+		}
+		for _, n := range d.Related {
+			file, nsrc := filename, src
+			if n.File != "" && n.File != filename {
+				file, nsrc = n.File, ""
+			}
+			start, end := locateRange(nsrc, n.Range)
+			jd.Related = append(jd.Related, jsonRelated{Message: n.Message, File: file, Start: start, End: end})
+		}
+
+		line, _ := json.Marshal(jd)
+		out.Write(line)
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// locateRange devuelve las posiciones de r con las columnas calculadas a
+// partir de los offsets, que son exactos, cuando src los respalda. El final
+// nunca queda antes del comienzo.
+func locateRange(src string, r source.Range) (jsonPosition, jsonPosition) {
+	start, end := locatePosition(src, r.Start), locatePosition(src, r.End)
+	if end.Line < start.Line || (end.Line == start.Line && end.Column < start.Column) {
+		end = start
+	}
+	return start, end
+}
+
+func locatePosition(src string, pos source.Position) jsonPosition {
+	if pos.Offset >= 0 && pos.Offset <= len(src) {
+		before := src[:pos.Offset]
+		if line := strings.Count(before, "\n") + 1; line == pos.Line {
+			lineStart := strings.LastIndex(before, "\n") + 1
+			return jsonPosition{Line: line, Column: utf8.RuneCountInString(before[lineStart:]) + 1, Offset: pos.Offset}
+		}
+	}
+	return jsonPosition{Line: pos.Line, Column: max(1, pos.Column), Offset: pos.Offset}
+}
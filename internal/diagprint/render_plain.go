@@ -11,6 +11,7 @@ import (
 // filename:line:col: level CODE: message\n
 // <línea de código>\n
 // <espacios>^~~~\n
+// filename:line:col: note: message\n   (una línea por cada Related)
 func RenderPlain(filename, src string, diags []diag.Diagnostic) string {
 	var out strings.Builder
 	lines := strings.Split(src, "\n")
@@ -42,6 +43,13 @@ func RenderPlain(filename, src string, diags []diag.Diagnostic) string {
 			out.WriteString(strings.Repeat("~", length-1))
 			out.WriteString("\n")
 		}
+		for _, n := range d.Related {
+			file := filename
+			if n.File != "" {
+				file = n.File
+			}
+			fmt.Fprintf(&out, "%s:%d:%d: note: %s\n", file, n.Range.Start.Line, max(1, n.Range.Start.Column), n.Message)
+		}
 	}
 	return out.String()
 }
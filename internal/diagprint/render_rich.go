@@ -0,0 +1,205 @@
+package diagprint
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go-rilla/diag"
+	"go-rilla/source"
+)
+
+// Códigos ANSI del renderer enriquecido.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// maxSpanLines es la cantidad de líneas de un rango a partir de la cual se
+// omiten las del medio.
+const maxSpanLines = 8
+
+// RenderRich imprime diagnósticos con el número de cada línea, una línea de
+// contexto antes y después, todas las líneas de los rangos que abarcan
+// varias, la sugerencia (hint) y las notas:
+//
+//	error[PAR001]: Expected next token to be ), got { instead
+//	 --> main.monkey:1:10
+//	  |
+//	1 | if (true {
+//	  |          ^
+//	2 |     print(1);
+//	  = hint: Check the previous expression or a possible missing ';'
+//
+// Cada diagnóstico termina con una línea en blanco. Con color, el nivel,
+// los subrayados y el margen se resaltan con códigos ANSI.
+func RenderRich(filename, src string, diags []diag.Diagnostic, color bool) string {
+	r := &richRenderer{color: color, src: src, lines: strings.Split(src, "\n")}
+	for _, d := range diags {
+		r.diagnostic(filename, d)
+		r.out.WriteString("\n")
+	}
+	return r.out.String()
+}
+
+type richRenderer struct {
+	out   strings.Builder
+	color bool
+	src   string
+	lines []string
+}
+
+func (r *richRenderer) paint(style, text string) string {
+	if !r.color || text == "" {
+		return text
+	}
+	return style + text + ansiReset
+}
+
+func levelStyle(l diag.Level) string {
+	switch l {
+	case diag.Error:
+		return ansiBold + ansiRed
+	case diag.Warning:
+		return ansiBold + ansiYellow
+	default:
+		return ansiBold + ansiCyan
+	}
+}
+
+func (r *richRenderer) diagnostic(filename string, d diag.Diagnostic) {
+	style := levelStyle(d.Level)
+	title := d.Level.String()
+	if d.Code != "" {
+		title += "[" + d.Code + "]"
+	}
+	fmt.Fprintf(&r.out, "%s%s\n", r.paint(style, title), r.paint(ansiBold, ": "+d.Message))
+
+	start, end := r.locate(d.Range.Start), r.locate(d.Range.End)
+	if end.line < start.line || (end.line == start.line && end.col <= start.col) {
+		end = position{line: start.line, col: start.col + 1}
+	}
+	width := len(fmt.Sprint(min(end.line+1, len(r.lines))))
+	gutter := strings.Repeat(" ", width)
+
+	fmt.Fprintf(&r.out, "%s%s %s:%d:%d\n", gutter, r.paint(ansiBold+ansiBlue, "-->"), filename, start.line, start.col)
+	if start.line >= 1 && start.line <= len(r.lines) {
+		r.out.WriteString(r.paint(ansiBold+ansiBlue, gutter+" |") + "\n")
+		r.snippet(start, end, width, style)
+	}
+
+	if d.Hint != "" {
+		fmt.Fprintf(&r.out, "%s %s %s\n", gutter, r.paint(ansiBold+ansiBlue, "="), r.paint(ansiBold+ansiGreen, "hint:")+" "+d.Hint)
+	}
+	for _, n := range d.Related {
+		file := filename
+		pos := r.locate(n.Range.Start)
+		if n.File != "" && n.File != filename {
+			file = n.File
+			pos = position{line: n.Range.Start.Line, col: max(1, n.Range.Start.Column)}
+		}
+		fmt.Fprintf(&r.out, "%s %s %s %s (%s:%d:%d)\n", gutter, r.paint(ansiBold+ansiBlue, "="),
+			r.paint(ansiBold+ansiCyan, "note:"), n.Message, file, pos.line, pos.col)
+	}
+}
+
+// snippet imprime las líneas de start a end subrayadas, con una línea de
+// contexto antes y otra después.
+func (r *richRenderer) snippet(start, end position, width int, style string) {
+	last := min(end.line, len(r.lines))
+	if end.col == 1 && end.line > start.line {
+		// El rango termina justo al comienzo de una línea: no la incluye.
+		last = end.line - 1
+		end = position{line: last, col: utf8.RuneCountInString(r.lines[last-1]) + 1}
+	}
+
+	if start.line > 1 {
+		r.sourceLine(start.line-1, width)
+	}
+	for line := start.line; line <= last; line++ {
+		if last-start.line+1 > maxSpanLines && line == start.line+3 {
+			r.out.WriteString(r.paint(ansiBold+ansiBlue, "...") + "\n")
+			line = last - 3
+			continue
+		}
+		r.sourceLine(line, width)
+
+		text := r.lines[line-1]
+		from, to := 1, utf8.RuneCountInString(text)+1
+		if line == start.line {
+			from = start.col
+		} else {
+			from = firstNonSpace(text)
+		}
+		if line == end.line {
+			to = end.col
+		}
+		if to <= from {
+			to = from + 1
+		}
+		marker := "^" + strings.Repeat("~", to-from-1)
+		r.out.WriteString(r.paint(ansiBold+ansiBlue, strings.Repeat(" ", width)+" |"))
+		r.out.WriteString(" " + indentFor(text, from) + r.paint(style, marker) + "\n")
+	}
+	if last < len(r.lines) && !(last == len(r.lines)-1 && r.lines[last] == "") {
+		r.sourceLine(last+1, width)
+	}
+}
+
+func (r *richRenderer) sourceLine(line, width int) {
+	number := fmt.Sprintf("%*d |", width, line)
+	r.out.WriteString(r.paint(ansiBold+ansiBlue, number))
+	if text := r.lines[line-1]; text != "" {
+		r.out.WriteString(" " + text)
+	}
+	r.out.WriteString("\n")
+}
+
+// position es una línea y una columna en runas, ambas desde 1.
+type position struct {
+	line, col int
+}
+
+// locate calcula la línea y la columna de pos; ver locatePosition.
+func (r *richRenderer) locate(pos source.Position) position {
+	p := locatePosition(r.src, pos)
+	return position{line: p.Line, col: p.Column}
+}
+
+func firstNonSpace(text string) int {
+	col := 1
+	for _, ch := range text {
+		if ch != ' ' && ch != '\t' {
+			return col
+		}
+		col++
+	}
+	return 1
+}
+
+// indentFor devuelve el espacio hasta la columna col de text, conservando
+// los tabs para que el subrayado quede alineado.
+func indentFor(text string, col int) string {
+	var b strings.Builder
+	i := 1
+	for _, ch := range text {
+		if i >= col {
+			break
+		}
+		if ch == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+		i++
+	}
+	if i < col {
+		b.WriteString(strings.Repeat(" ", col-i))
+	}
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package diagprint
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+
+	"go-rilla/diag"
+	"go-rilla/source"
+)
+
+// Estructuras de SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/)
+// que usa RenderSARIF.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+	FullDescription  *sarifMessage `json:"fullDescription,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string            `json:"ruleId"`
+	RuleIndex        int               `json:"ruleIndex"`
+	Level            string            `json:"level"`
+	Message          sarifMessage      `json:"message"`
+	Locations        []sarifLocation   `json:"locations"`
+	RelatedLocations []sarifLocation   `json:"relatedLocations,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	ID               *int                  `json:"id,omitempty"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+	ByteOffset  int `json:"byteOffset"`
+	ByteLength  int `json:"byteLength"`
+}
+
+// RenderSARIF imprime los diagnósticos de files como un log SARIF 2.1.0 con
+// una sola ejecución, para que los muestren las herramientas de code
+// scanning. El hint de cada diagnóstico va en properties.hint.
+func RenderSARIF(files []File) string {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "go-rilla",
+			InformationURI: "https://github.com/cipriano-victor/go-rilla",
+			Rules:          []sarifRule{},
+		}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    []sarifResult{},
+	}
+
+	rules := map[string]int{}
+	for _, f := range files {
+		for _, d := range f.Diagnostics {
+			if _, ok := rules[d.Code]; !ok {
+				rules[d.Code] = 0
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRuleFor(d.Code))
+			}
+		}
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+	for i, rule := range run.Tool.Driver.Rules {
+		rules[rule.ID] = i
+	}
+
+	for _, f := range files {
+		for _, d := range f.Diagnostics {
+			result := sarifResult{
+				RuleID:    d.Code,
+				RuleIndex: rules[d.Code],
+				Level:     sarifLevel(d.Level),
+				Message:   sarifMessage{Text: d.Message},
+				Locations: []sarifLocation{sarifLocationOf(f.Name, f.Source, d.Range)},
+			}
+			if d.Hint != "" {
+				result.Properties = map[string]string{"hint": d.Hint}
+			}
+			for i, n := range d.Related {
+				name, src := f.Name, f.Source
+				if n.File != "" && n.File != f.Name {
+					name, src = n.File, ""
+				}
+				loc := sarifLocationOf(name, src, n.Range)
+				id := i + 1
+				loc.ID, loc.Message = &id, &sarifMessage{Text: n.Message}
+				result.RelatedLocations = append(result.RelatedLocations, loc)
+			}
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+	out, _ := json.MarshalIndent(log, "", "  ")
+	return string(out) + "\n"
+}
+
+// sarifRuleFor describe la regla de code con su entrada del catálogo.
+func sarifRuleFor(code string) sarifRule {
+	rule := sarifRule{ID: code}
+	if e, ok := diag.Lookup(code); ok {
+		rule.ShortDescription = &sarifMessage{Text: e.Title}
+		rule.FullDescription = &sarifMessage{Text: e.Explanation}
+	}
+	return rule
+}
+
+func sarifLevel(l diag.Level) string {
+	switch l {
+	case diag.Error:
+		return "error"
+	case diag.Warning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func sarifLocationOf(name, src string, r source.Range) sarifLocation {
+	start, end := locateRange(src, r)
+	return sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(name)},
+		Region: sarifRegion{
+			StartLine:   start.Line,
+			StartColumn: start.Column,
+			EndLine:     end.Line,
+			EndColumn:   end.Column,
+			ByteOffset:  start.Offset,
+			ByteLength:  max(end.Offset-start.Offset, 0),
+		},
+	}}
+}
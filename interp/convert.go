@@ -0,0 +1,398 @@
+package interp
+
+import (
+	"fmt"
+	"go-rilla/evaluator"
+	"go-rilla/object"
+	"math/big"
+	"reflect"
+	"sort"
+)
+
+var (
+	objectType = reflect.TypeOf((*object.Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	bigIntType = reflect.TypeOf((*big.Int)(nil))
+
+	emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// ToObject convierte un valor de Go en un object.Object:
+//
+//	nil                      -> null
+//	bool                     -> BOOLEAN
+//	int*, uint*              -> INTEGER (BIGINT si no entra en int64)
+//	*big.Int                 -> INTEGER o BIGINT
+//	float32, float64         -> FLOAT
+//	string                   -> STRING
+//	slices y arrays          -> ARRAY
+//	maps                     -> HASH (claves string, enteras o bool)
+//	funciones                -> BUILTIN (ver RegisterFunc)
+//	object.Object            -> sin cambios
+func ToObject(value interface{}) (object.Object, error) {
+	if value == nil {
+		return evaluator.NULL, nil
+	}
+	if obj, ok := value.(object.Object); ok {
+		return obj, nil
+	}
+	return toObject(reflect.ValueOf(value))
+}
+
+func toObject(v reflect.Value) (object.Object, error) {
+	if v.Type().Implements(objectType) {
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return v.Interface().(object.Object), nil
+	}
+	if v.Type() == bigIntType {
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return evaluator.NewInteger(new(big.Int).Set(v.Interface().(*big.Int))), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return evaluator.TRUE, nil
+		}
+		return evaluator.FALSE, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return evaluator.NewInteger(new(big.Int).SetUint64(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: v.Float()}, nil
+	case reflect.String:
+		return &object.String{Value: v.String()}, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return &object.Array{Elements: []object.Object{}}, nil
+		}
+		elements := make([]object.Object, v.Len())
+		for i := range elements {
+			element, err := toObject(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return &object.Array{Elements: elements}, nil
+	case reflect.Map:
+		keys := v.MapKeys()
+		// Orden estable, para que el hash resultante no dependa del
+		// recorrido aleatorio de los maps de Go.
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		pairs := make([]object.HashPair, 0, len(keys))
+		for _, key := range keys {
+			k, err := toObject(key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := toObject(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, object.HashPair{Key: k, Value: value})
+		}
+		hash := evaluator.NewHash(pairs)
+		if errObj, ok := hash.(*object.Error); ok {
+			return nil, fmt.Errorf("%s", errObj.Message)
+		}
+		return hash, nil
+	case reflect.Func:
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return wrapFunc("<go func>", v)
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return toObject(v.Elem())
+	}
+	return nil, fmt.Errorf("cannot convert %s to a go-rilla value", v.Type())
+}
+
+// FromObject convierte obj en su valor natural de Go: int64, *big.Int,
+// float64, string, bool, nil, []interface{} o map[interface{}]interface{}.
+// Como un slice no puede ser clave de un map, una clave array se convierte
+// en un array de Go ([n]interface{}). Los valores sin equivalente
+// (funciones, errores, módulos) se devuelven sin convertir.
+func FromObject(obj object.Object) interface{} {
+	switch obj := obj.(type) {
+	case nil, *object.Null:
+		return nil
+	case *object.Integer:
+		return obj.Value
+	case *object.BigInt:
+		return new(big.Int).Set(obj.Value)
+	case *object.Float:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.Array:
+		elements := make([]interface{}, len(obj.Elements))
+		for i, element := range obj.Elements {
+			elements[i] = FromObject(element)
+		}
+		return elements
+	case *object.Hash:
+		pairs := make(map[interface{}]interface{}, obj.Len())
+		for _, pair := range obj.OrderedPairs() {
+			pairs[fromKey(pair.Key)] = FromObject(pair.Value)
+		}
+		return pairs
+	}
+	return obj
+}
+
+// fromKey convierte una clave de un hash como FromObject, pero con los
+// arrays (también los anidados) como arrays de Go, que sí pueden ser claves
+// de un map.
+func fromKey(key object.Object) interface{} {
+	array, ok := key.(*object.Array)
+	if !ok {
+		return FromObject(key)
+	}
+	v := reflect.New(reflect.ArrayOf(len(array.Elements), emptyInterfaceType)).Elem()
+	for i, element := range array.Elements {
+		if element := fromKey(element); element != nil {
+			v.Index(i).Set(reflect.ValueOf(element))
+		}
+	}
+	return v.Interface()
+}
+
+// fromObject convierte obj al tipo t de un parámetro de Go.
+func fromObject(obj object.Object, t reflect.Type) (reflect.Value, error) {
+	if t == objectType {
+		return reflect.ValueOf(&obj).Elem(), nil
+	}
+	if t.Implements(objectType) && reflect.TypeOf(obj) == t {
+		return reflect.ValueOf(obj), nil
+	}
+
+	mismatch := func() (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("cannot use %s as %s", obj.Type(), t)
+	}
+
+	if t == bigIntType {
+		if !isInteger(obj) {
+			return mismatch()
+		}
+		return reflect.ValueOf(bigInteger(obj)), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return mismatch()
+		}
+		value := FromObject(obj)
+		if value == nil {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(value), nil
+	case reflect.Bool:
+		b, ok := obj.(*object.Boolean)
+		if !ok {
+			return mismatch()
+		}
+		return reflect.ValueOf(b.Value).Convert(t), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, ok := obj.(*object.BigInt); ok {
+			return reflect.Value{}, fmt.Errorf("integer %s overflows %s", obj.Inspect(), t)
+		}
+		i, ok := obj.(*object.Integer)
+		if !ok {
+			return mismatch()
+		}
+		v := reflect.New(t).Elem()
+		if v.OverflowInt(i.Value) {
+			return reflect.Value{}, fmt.Errorf("integer %d overflows %s", i.Value, t)
+		}
+		v.SetInt(i.Value)
+		return v, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !isInteger(obj) {
+			return mismatch()
+		}
+		n := bigInteger(obj)
+		v := reflect.New(t).Elem()
+		if n.Sign() < 0 || !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+			return reflect.Value{}, fmt.Errorf("integer %s overflows %s", n, t)
+		}
+		v.SetUint(n.Uint64())
+		return v, nil
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *object.Float:
+			return reflect.ValueOf(n.Value).Convert(t), nil
+		case *object.Integer:
+			return reflect.ValueOf(float64(n.Value)).Convert(t), nil
+		case *object.BigInt:
+			f, _ := new(big.Float).SetInt(n.Value).Float64()
+			return reflect.ValueOf(f).Convert(t), nil
+		}
+		return mismatch()
+	case reflect.String:
+		s, ok := obj.(*object.String)
+		if !ok {
+			return mismatch()
+		}
+		return reflect.ValueOf(s.Value).Convert(t), nil
+	case reflect.Slice:
+		array, ok := obj.(*object.Array)
+		if !ok {
+			return mismatch()
+		}
+		slice := reflect.MakeSlice(t, len(array.Elements), len(array.Elements))
+		for i, element := range array.Elements {
+			v, err := fromObject(element, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			slice.Index(i).Set(v)
+		}
+		return slice, nil
+	case reflect.Array:
+		array, ok := obj.(*object.Array)
+		if !ok || len(array.Elements) != t.Len() {
+			return mismatch()
+		}
+		v := reflect.New(t).Elem()
+		for i, element := range array.Elements {
+			e, err := fromObject(element, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(e)
+		}
+		return v, nil
+	case reflect.Map:
+		hash, ok := obj.(*object.Hash)
+		if !ok {
+			return mismatch()
+		}
+		m := reflect.MakeMapWithSize(t, hash.Len())
+		for _, pair := range hash.OrderedPairs() {
+			var k reflect.Value
+			if t.Key() == emptyInterfaceType {
+				k = reflect.ValueOf(fromKey(pair.Key))
+			} else {
+				var err error
+				if k, err = fromObject(pair.Key, t.Key()); err != nil {
+					return reflect.Value{}, err
+				}
+			}
+			v, err := fromObject(pair.Value, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			m.SetMapIndex(k, v)
+		}
+		return m, nil
+	}
+	return mismatch()
+}
+
+func isInteger(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Integer, *object.BigInt:
+		return true
+	}
+	return false
+}
+
+// bigInteger devuelve una copia del valor de un Integer o un BigInt.
+func bigInteger(obj object.Object) *big.Int {
+	if i, ok := obj.(*object.Integer); ok {
+		return big.NewInt(i.Value)
+	}
+	return new(big.Int).Set(obj.(*object.BigInt).Value)
+}
+
+// wrapFunc convierte una función de Go en un Built-In que convierte sus
+// argumentos y resultados. La función puede devolver nada, un valor, un
+// error, o un valor y un error; un error no nil, o un panic, se convierte
+// en un error de go-rilla.
+func wrapFunc(name string, fn reflect.Value) (*object.Builtin, error) {
+	t := fn.Type()
+	switch {
+	case t.NumOut() > 2:
+		return nil, fmt.Errorf("%s: functions may return at most a value and an error", name)
+	case t.NumOut() == 2 && t.Out(1) != errorType:
+		return nil, fmt.Errorf("%s: the second result must be an error", name)
+	}
+
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		in, err := convertArgs(name, t, args)
+		if err != nil {
+			return &object.Error{Message: err.Error()}
+		}
+
+		out, err := callFunc(name, fn, in)
+		if err != nil {
+			return &object.Error{Message: err.Error()}
+		}
+		if n := len(out); n > 0 && t.Out(n-1) == errorType {
+			if err, _ := out[n-1].Interface().(error); err != nil {
+				return &object.Error{Message: err.Error()}
+			}
+			out = out[:n-1]
+		}
+		if len(out) == 0 {
+			return evaluator.NULL
+		}
+		result, err := toObject(out[0])
+		if err != nil {
+			return &object.Error{Message: fmt.Sprintf("result of `%s`: %s", name, err)}
+		}
+		return result
+	}}, nil
+}
+
+// callFunc llama a fn con in y devuelve como error un panic de fn.
+func callFunc(name string, fn reflect.Value, in []reflect.Value) (out []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in `%s`: %v", name, r)
+		}
+	}()
+	return fn.Call(in), nil
+}
+
+func convertArgs(name string, t reflect.Type, args []object.Object) ([]reflect.Value, error) {
+	fixed := t.NumIn()
+	if t.IsVariadic() {
+		fixed--
+		if len(args) < fixed {
+			return nil, fmt.Errorf("wrong number of arguments to `%s`. got=%d, want at least %d", name, len(args), fixed)
+		}
+	} else if len(args) != fixed {
+		return nil, fmt.Errorf("wrong number of arguments to `%s`. got=%d, want=%d", name, len(args), fixed)
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var param reflect.Type
+		if i < fixed {
+			param = t.In(i)
+		} else {
+			param = t.In(fixed).Elem()
+		}
+		v, err := fromObject(arg, param)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d to `%s`: %s", i+1, name, err)
+		}
+		in[i] = v
+	}
+	return in, nil
+}
@@ -0,0 +1,196 @@
+// Package interp permite embeber go-rilla en programas de Go: evaluar código,
+// definir valores y funciones del anfitrión y llamar funciones del script.
+//
+//	in := interp.New()
+//	in.RegisterFunc("double", func(n int) int { return n * 2 })
+//	in.Eval(`let f = fn(x) { double(x) + 1 };`)
+//	result, err := in.Call("f", 20) // 41
+package interp
+
+import (
+	"context"
+	"fmt"
+	"go-rilla/diag"
+	"go-rilla/evaluator"
+	"go-rilla/internal/diagprint"
+	"go-rilla/lexer"
+	"go-rilla/object"
+	"go-rilla/parser"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Interpreter evalúa código go-rilla. Las variables de cada Eval persisten
+// en las siguientes, como en el REPL.
+type Interpreter struct {
+	// Stdout recibe la salida de print. Por defecto os.Stdout.
+	Stdout io.Writer
+	// Stderr recibe los diagnósticos que no impiden ejecutar el código
+	// (warnings y notas). Por defecto os.Stderr.
+	Stderr io.Writer
+	// Limits acota cada Eval y Call, incluidos los módulos que importen,
+	// y con StrictIntegers elige el modo de enteros estricto.
+	Limits evaluator.Limits
+
+	loader  *evaluator.Loader
+	globals *object.Environment // Define y RegisterFunc; visibles en todos los módulos
+	env     *object.Environment // entorno del programa principal
+}
+
+// New crea un intérprete. Los imports se resuelven desde el directorio
+// actual.
+func New() *Interpreter {
+	in := &Interpreter{
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+		loader:  evaluator.NewLoader(),
+		globals: object.NewEnvironment(),
+	}
+	in.globals.Set("print", &object.Builtin{Fn: in.print})
+	in.loader.Globals = in.globals
+	in.env = in.loader.NewEnvironment("")
+	return in
+}
+
+func (in *Interpreter) print(args ...object.Object) object.Object {
+	for _, arg := range args {
+		fmt.Fprintln(in.Stdout, arg.Inspect())
+	}
+	return evaluator.NULL
+}
+
+// Define asigna name a value, convertido con ToObject, en el entorno global.
+func (in *Interpreter) Define(name string, value interface{}) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return fmt.Errorf("define %s: %w", name, err)
+	}
+	in.globals.Set(name, obj)
+	return nil
+}
+
+// RegisterFunc expone la función de Go fn como el Built-In name. Los
+// argumentos se convierten al tipo de cada parámetro y el resultado con
+// ToObject. fn puede devolver nada, un valor, un error o un valor y un
+// error; un error no nil se convierte en un error de go-rilla, que el
+// script puede capturar con try/catch.
+func (in *Interpreter) RegisterFunc(name string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("register %s: not a function: %T", name, fn)
+	}
+	builtin, err := wrapFunc(name, v)
+	if err != nil {
+		return fmt.Errorf("register %w", err)
+	}
+	in.globals.Set(name, builtin)
+	return nil
+}
+
+// Eval evalúa src y devuelve el valor de su última sentencia. Los errores
+// de sintaxis se devuelven como *SyntaxError y los de ejecución como
+// *RuntimeError.
+func (in *Interpreter) Eval(src string) (object.Object, error) {
+	return in.EvalContext(context.Background(), src)
+}
+
+// EvalContext es como Eval pero detiene la ejecución si se cancela ctx.
+func (in *Interpreter) EvalContext(ctx context.Context, src string) (object.Object, error) {
+	l := lexer.New(src)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	var errs, others []diag.Diagnostic
+	for _, d := range append(l.Diagnostics(), p.Diagnostics()...) {
+		if d.Level == diag.Error {
+			errs = append(errs, d)
+		} else {
+			others = append(others, d)
+		}
+	}
+	if len(others) > 0 {
+		io.WriteString(in.Stderr, diagprint.RenderPlain("<eval>", src, others))
+	}
+	if len(errs) > 0 || len(p.Errors()) > 0 {
+		return nil, &SyntaxError{Diagnostics: errs, Errors: p.Errors()}
+	}
+
+	in.loader.Context, in.loader.Limits = ctx, in.Limits
+	return result(evaluator.EvalContext(ctx, program, in.env, in.Limits))
+}
+
+// Call llama a la función fnName del script (o a un Built-In) con args
+// convertidos con ToObject.
+func (in *Interpreter) Call(fnName string, args ...interface{}) (object.Object, error) {
+	return in.CallContext(context.Background(), fnName, args...)
+}
+
+// CallContext es como Call pero detiene la ejecución si se cancela ctx.
+func (in *Interpreter) CallContext(ctx context.Context, fnName string, args ...interface{}) (object.Object, error) {
+	fn, ok := in.env.Get(fnName)
+	if !ok {
+		if fn, ok = evaluator.LookupBuiltin(fnName); !ok {
+			return nil, fmt.Errorf("function not found: %s", fnName)
+		}
+	}
+
+	objects := make([]object.Object, len(args))
+	for i, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d to %s: %w", i+1, fnName, err)
+		}
+		objects[i] = obj
+	}
+	in.loader.Context, in.loader.Limits = ctx, in.Limits
+	return result(evaluator.ApplyFunctionContext(ctx, fn, objects, in.Limits))
+}
+
+// result separa el error de ejecución, si lo hay, del valor devuelto.
+func result(obj object.Object) (object.Object, error) {
+	if errObj, ok := obj.(*object.Error); ok {
+		return nil, &RuntimeError{Err: errObj}
+	}
+	if obj == nil {
+		return evaluator.NULL, nil
+	}
+	return obj, nil
+}
+
+// SyntaxError reporta los errores de escaneo y parseo de un Eval.
+type SyntaxError struct {
+	Diagnostics []diag.Diagnostic
+	Errors      []string // mensajes del parser, como parser.Errors()
+}
+
+func (e *SyntaxError) Error() string {
+	messages := e.Errors
+	if len(messages) == 0 {
+		for _, d := range e.Diagnostics {
+			messages = append(messages, d.Message)
+		}
+	}
+	return "syntax error: " + strings.Join(messages, "; ")
+}
+
+// RuntimeError es un error no capturado durante la ejecución.
+type RuntimeError struct {
+	Err *object.Error
+}
+
+func (e *RuntimeError) Error() string {
+	d := e.Err.Diagnostic()
+	if !e.Err.HasRange() {
+		return d.Message
+	}
+	file := e.Err.File
+	if file == "" {
+		file = "<eval>"
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", file, d.Range.Start.Line, d.Range.Start.Column, d.Message)
+}
+
+// Unwrap permite inspeccionar el *object.Error original con errors.As.
+func (e *RuntimeError) Unwrap() error { return e.Err }
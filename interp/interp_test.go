@@ -0,0 +1,333 @@
+package interp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go-rilla/object"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	in := New()
+	var out bytes.Buffer
+	in.Stdout = &out
+
+	if _, err := in.Eval(`let x = 40;`); err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+	result, err := in.Eval(`print("hi"); x + 2`)
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+	if got := FromObject(result); got != int64(42) {
+		t.Errorf("wrong result. got=%v", got)
+	}
+	if out.String() != "hi\n" {
+		t.Errorf("wrong stdout. got=%q", out.String())
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	in := New()
+
+	_, err := in.Eval(`let = 1;`)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected *SyntaxError, got=%T(%v)", err, err)
+	}
+	if len(syntaxErr.Diagnostics) == 0 || syntaxErr.Diagnostics[0].Code != "PAR001" {
+		t.Errorf("wrong diagnostics: %+v", syntaxErr.Diagnostics)
+	}
+
+	_, err = in.Eval("let a = 1;\na + true")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected *RuntimeError, got=%T(%v)", err, err)
+	}
+	if err.Error() != "<eval>:2:1: type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong error message. got=%q", err.Error())
+	}
+	var errObj *object.Error
+	if !errors.As(err, &errObj) || errObj.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("RuntimeError does not unwrap to *object.Error: %v", errObj)
+	}
+}
+
+func TestDefine(t *testing.T) {
+	in := New()
+	values := map[string]interface{}{
+		"n":     7,
+		"f":     1.5,
+		"s":     "gorilla",
+		"b":     true,
+		"none":  nil,
+		"list":  []int{1, 2, 3},
+		"hash":  map[string]interface{}{"a": 1, "b": []string{"x"}},
+		"bytes": uint8(200),
+	}
+	for name, value := range values {
+		if err := in.Define(name, value); err != nil {
+			t.Fatalf("Define(%q) returned error: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`n * 2`, int64(14)},
+		{`f + 1.0`, 2.5},
+		{`s.upper()`, "GORILLA"},
+		{`!b`, false},
+		{`none`, nil},
+		{`list[2]`, int64(3)},
+		{`hash["b"][0]`, "x"},
+		{`bytes`, int64(200)},
+	}
+	for _, tt := range tests {
+		result, err := in.Eval(tt.input)
+		if err != nil {
+			t.Errorf("Eval(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got := FromObject(result); got != tt.expected {
+			t.Errorf("Eval(%q) wrong result. expected=%v, got=%v", tt.input, tt.expected, got)
+		}
+	}
+
+	if err := in.Define("ch", make(chan int)); err == nil {
+		t.Errorf("expected an error defining a channel")
+	}
+}
+
+func TestRegisterFunc(t *testing.T) {
+	in := New()
+	funcs := map[string]interface{}{
+		"double": func(n int) int { return n * 2 },
+		"join":   func(parts []string, sep string) string { return strings.Join(parts, sep) },
+		"sum": func(ns ...float64) float64 {
+			total := 0.0
+			for _, n := range ns {
+				total += n
+			}
+			return total
+		},
+		"check": func(n int) (int, error) {
+			if n < 0 {
+				return 0, fmt.Errorf("negative: %d", n)
+			}
+			return n, nil
+		},
+		"keys": func(h map[string]int) int { return len(h) },
+		"raw":  func(o object.Object) string { return string(o.Type()) },
+		"any":  func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"boom": func() int { panic("boom") },
+	}
+	for name, fn := range funcs {
+		if err := in.RegisterFunc(name, fn); err != nil {
+			t.Fatalf("RegisterFunc(%q) returned error: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`double(21)`, int64(42)},
+		{`join(["a", "b"], "-")`, "a-b"},
+		{`sum(1, 2.5, 3)`, 6.5},
+		{`sum()`, 0.0},
+		{`check(3)`, int64(3)},
+		{`try { check(-1) } catch (e) { e.message }`, "negative: -1"},
+		{`keys({"a": 1, "b": 2})`, int64(2)},
+		{`raw([1])`, "ARRAY"},
+		{`any(1)`, "int64"},
+		{`any([1])`, "[]interface {}"},
+		{`try { boom() } catch (e) { e.message }`, "panic in `boom`: boom"},
+	}
+	for _, tt := range tests {
+		result, err := in.Eval(tt.input)
+		if err != nil {
+			t.Errorf("Eval(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got := FromObject(result); got != tt.expected {
+			t.Errorf("Eval(%q) wrong result. expected=%v, got=%v", tt.input, tt.expected, got)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`double("x")`, "argument 1 to `double`: cannot use STRING as int"},
+		{`double(1, 2)`, "wrong number of arguments to `double`. got=2, want=1"},
+		{`check(-2)`, "negative: -2"},
+		{`boom()`, "panic in `boom`: boom"},
+	}
+	for _, tt := range errorTests {
+		_, err := in.Eval(tt.input)
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			t.Errorf("Eval(%q) expected *RuntimeError, got=%T(%v)", tt.input, err, err)
+			continue
+		}
+		if runtimeErr.Err.Message != tt.expected {
+			t.Errorf("Eval(%q) wrong error. expected=%q, got=%q", tt.input, tt.expected, runtimeErr.Err.Message)
+		}
+	}
+
+	if err := in.RegisterFunc("bad", 5); err == nil {
+		t.Errorf("expected an error registering a non-function")
+	}
+	if err := in.RegisterFunc("bad", func() (int, int) { return 0, 0 }); err == nil {
+		t.Errorf("expected an error registering a function with two non-error results")
+	}
+}
+
+func TestCall(t *testing.T) {
+	in := New()
+	if _, err := in.Eval(`let add = fn(a, b) { a + b }; let fail = fn() { 1 / 0 };`); err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+
+	result, err := in.Call("add", 1, 2)
+	if err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+	if got := FromObject(result); got != int64(3) {
+		t.Errorf("wrong result. got=%v", got)
+	}
+
+	result, err = in.Call("len", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+	if got := FromObject(result); got != int64(2) {
+		t.Errorf("wrong result. got=%v", got)
+	}
+
+	if _, err := in.Call("fail"); err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Errorf("expected a division by zero error, got=%v", err)
+	}
+	if _, err := in.Call("add", 1); err == nil || !strings.Contains(err.Error(), "wrong number of arguments: want=2, got=1") {
+		t.Errorf("expected a wrong number of arguments error, got=%v", err)
+	}
+	if _, err := in.Call("missing"); err == nil || err.Error() != "function not found: missing" {
+		t.Errorf("wrong error for a missing function: %v", err)
+	}
+}
+
+func TestFromObject(t *testing.T) {
+	in := New()
+	result, err := in.Eval(`{"a": [1, 2.5, "x", true], "b": {}}`)
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+	expected := map[interface{}]interface{}{
+		"a": []interface{}{int64(1), 2.5, "x", true},
+		"b": map[interface{}]interface{}{},
+	}
+	if got := FromObject(result); !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong conversion. expected=%#v, got=%#v", expected, got)
+	}
+}
+
+func TestArrayKeys(t *testing.T) {
+	in := New()
+	if err := in.RegisterFunc("lookup", func(grid map[[2]int64]string, x, y int64) string { return grid[[2]int64{x, y}] }); err != nil {
+		t.Fatalf("RegisterFunc returned error: %v", err)
+	}
+	if err := in.RegisterFunc("count", func(m map[interface{}]interface{}) int { return len(m) }); err != nil {
+		t.Fatalf("RegisterFunc returned error: %v", err)
+	}
+
+	result, err := in.Eval(`{[1, 2]: 3, [1, [2, "a"]]: 4, "k": 5}`)
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+	expected := map[interface{}]interface{}{
+		[2]interface{}{int64(1), int64(2)}:                      int64(3),
+		[2]interface{}{int64(1), [2]interface{}{int64(2), "a"}}: int64(4),
+		"k": int64(5),
+	}
+	if got := FromObject(result); !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong result. expected=%v, got=%v", expected, got)
+	}
+
+	result, err = in.Eval(`count({[1]: 1, [2]: 2}) + len(lookup({[0, 1]: "a", [1, 0]: "b"}, 1, 0))`)
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+	if got := FromObject(result); got != int64(3) {
+		t.Errorf("wrong result. got=%v", got)
+	}
+}
+
+func TestBigIntegers(t *testing.T) {
+	in := New()
+	if err := in.RegisterFunc("half", func(n *big.Int) *big.Int { return new(big.Int).Rsh(n, 1) }); err != nil {
+		t.Fatalf("RegisterFunc returned error: %v", err)
+	}
+	if err := in.RegisterFunc("small", func(n int64) int64 { return n }); err != nil {
+		t.Fatalf("RegisterFunc returned error: %v", err)
+	}
+	if err := in.Define("max", uint64(1<<64-1)); err != nil {
+		t.Fatalf("Define returned error: %v", err)
+	}
+
+	result, err := in.Eval(`half(max + 1)`)
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+	expected := new(big.Int).Lsh(big.NewInt(1), 63)
+	if got, ok := FromObject(result).(*big.Int); !ok || got.Cmp(expected) != 0 {
+		t.Errorf("wrong result. expected=%s, got=%v", expected, FromObject(result))
+	}
+	if _, err := in.Eval(`small(max)`); err == nil || !strings.Contains(err.Error(), "overflows int64") {
+		t.Errorf("expected an overflow error, got %v", err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	in := New()
+	in.Limits.MaxSteps = 500
+
+	_, err := in.Eval(`while (true) { }`)
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.LIMIT_ERROR {
+		t.Fatalf("expected a LimitExceeded error, got=%v", err)
+	}
+
+	if _, err := in.Eval(`let spin = fn() { while (true) { } };`); err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+	in.Limits.MaxSteps = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = in.CallContext(ctx, "spin")
+	if err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("expected a cancellation error, got=%v", err)
+	}
+}
+
+func TestStrictIntegers(t *testing.T) {
+	strict, lenient := New(), New()
+	strict.Limits.StrictIntegers = true
+
+	if _, err := strict.Eval(`9223372036854775807 + 1`); err == nil || !strings.Contains(err.Error(), "integer overflow") {
+		t.Errorf("expected an integer overflow error, got=%v", err)
+	}
+	result, err := lenient.Eval(`9223372036854775807 + 1`)
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+	if _, ok := result.(*object.BigInt); !ok {
+		t.Errorf("expected a BigInt, got=%T (%v)", result, result)
+	}
+}
@@ -0,0 +1,112 @@
+package lexer
+
+import (
+	"go-rilla/diag"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// escape es una secuencia de escape leída de un string.
+type escape struct {
+	value string // el texto que representa
+	size  int    // bytes que ocupa, desde la barra
+
+	// Si la secuencia es inválida, el diagnóstico que le corresponde.
+	code, msg, hint string
+}
+
+const escapeHint = `Use \" \\ \n \t \r \$, \xNN or \u{NNNN}`
+
+// scanEscape lee la secuencia de escape al comienzo de s, que empieza con
+// una barra. Una secuencia inválida ocupa hasta el primer carácter que no le
+// corresponde, para no consumir la comilla de cierre.
+func scanEscape(s string) escape {
+	if len(s) < 2 {
+		return escape{size: 1, code: diag.CodeInvalidEscape, msg: "Invalid escape sequence", hint: escapeHint}
+	}
+	switch s[1] {
+	case '"':
+		return escape{value: `"`, size: 2}
+	case '\\':
+		return escape{value: `\`, size: 2}
+	case '$':
+		return escape{value: "$", size: 2}
+	case 'n':
+		return escape{value: "\n", size: 2}
+	case 't':
+		return escape{value: "\t", size: 2}
+	case 'r':
+		return escape{value: "\r", size: 2}
+	case 'x':
+		digits := hexDigits(s[2:], 2)
+		if digits < 2 {
+			return escape{size: 2 + digits, code: diag.CodeInvalidEscape, msg: "Invalid escape sequence",
+				hint: `Write exactly two hexadecimal digits, as in \x41`}
+		}
+		value, _ := strconv.ParseUint(s[2:4], 16, 8)
+		if value > 0x7F {
+			return escape{size: 4, code: diag.CodeEscapeRange, msg: "Escape sequence out of range",
+				hint: `\x only covers ASCII (\x00 to \x7F); use \u{...} for other characters`}
+		}
+		return escape{value: string(rune(value)), size: 4}
+	case 'u':
+		if len(s) < 3 || s[2] != '{' {
+			return escape{size: 2, code: diag.CodeInvalidEscape, msg: "Invalid escape sequence",
+				hint: `Write the code point in braces, as in \u{1F98D}`}
+		}
+		digits := hexDigits(s[3:], 6)
+		end := 3 + digits
+		if digits == 0 || end >= len(s) || s[end] != '}' {
+			return escape{size: end, code: diag.CodeInvalidEscape, msg: "Invalid escape sequence",
+				hint: `Write one to six hexadecimal digits and a closing brace, as in \u{1F98D}`}
+		}
+		value, err := strconv.ParseUint(s[3:end], 16, 32)
+		if err != nil || value > utf8.MaxRune || (value >= 0xD800 && value <= 0xDFFF) {
+			return escape{size: end + 1, code: diag.CodeEscapeRange, msg: "Escape sequence out of range",
+				hint: `Code points go from \u{0} to \u{10FFFF}, except the surrogates \u{D800} to \u{DFFF}`}
+		}
+		return escape{value: string(rune(value)), size: end + 1}
+	default:
+		_, w := utf8.DecodeRuneInString(s[1:])
+		return escape{size: 1 + w, code: diag.CodeInvalidEscape, msg: "Invalid escape sequence", hint: escapeHint}
+	}
+}
+
+// hexDigits cuenta los dígitos hexadecimales al comienzo de s, hasta max.
+func hexDigits(s string, max int) int {
+	n := 0
+	for n < len(s) && n < max && isHexDigit(s[n]) {
+		n++
+	}
+	return n
+}
+
+func isHexDigit(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
+
+// Unescape devuelve el valor del contenido de un string (sin las comillas),
+// con las secuencias de escape decodificadas. Las secuencias inválidas, que
+// el lexer ya reportó, quedan como están escritas.
+func Unescape(literal string) string {
+	if !strings.Contains(literal, `\`) {
+		return literal
+	}
+	var b strings.Builder
+	for i := 0; i < len(literal); {
+		if literal[i] != '\\' {
+			b.WriteByte(literal[i])
+			i++
+			continue
+		}
+		esc := scanEscape(literal[i:])
+		if esc.code != "" {
+			b.WriteString(literal[i : i+esc.size])
+		} else {
+			b.WriteString(esc.value)
+		}
+		i += esc.size
+	}
+	return b.String()
+}
@@ -16,6 +16,15 @@ type Lexer struct {
 	position          source.Position // posición del carácter actual (línea y columna)
 	diagnostics       []diag.Diagnostic
 	lastDecodeInvalid bool
+
+	// interpolations son los ${ abiertos, del más externo al más interno.
+	interpolations []interpolation
+}
+
+// interpolation es un ${ de un string cuyo } todavía no se leyó.
+type interpolation struct {
+	start  source.Position // la comilla de apertura del string
+	braces int             // llaves abiertas dentro de la expresión
 }
 
 // Diagnostics devuelve los diagnósticos léxicos acumulados.
@@ -83,9 +92,17 @@ func (l *Lexer) peekCharacter() rune {
 	return r
 }
 
+// NextToken devuelve el siguiente token, con los comentarios que lo
+// preceden adjuntos como trivia.
 func (l *Lexer) NextToken() token.Token {
+	comments := l.skipTrivia()
+	tok := l.nextToken()
+	tok.Comments = comments
+	return tok
+}
+
+func (l *Lexer) nextToken() token.Token {
 	var tok token.Token
-	l.skipWhitespace()
 	start := l.currentStart()
 
 	switch l.character {
@@ -111,10 +128,22 @@ func (l *Lexer) NextToken() token.Token {
 		l.readCharacter()
 		return tok
 	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1].braces++
+		}
 		tok = newToken(token.LEFT_BRACE, l.character, start, l.afterCurrent())
 		l.readCharacter()
 		return tok
 	case '}':
+		if n := len(l.interpolations); n > 0 {
+			if l.interpolations[n-1].braces == 0 {
+				// Cierra la interpolación: sigue el texto del string.
+				open := l.interpolations[n-1]
+				l.interpolations = l.interpolations[:n-1]
+				return l.stringPart(open.start, start, false)
+			}
+			l.interpolations[n-1].braces--
+		}
 		tok = newToken(token.RIGHT_BRACE, l.character, start, l.afterCurrent())
 		l.readCharacter()
 		return tok
@@ -145,6 +174,10 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.COLON, l.character, start, l.afterCurrent())
 		l.readCharacter()
 		return tok
+	case '?':
+		tok = newToken(token.QUESTION, l.character, start, l.afterCurrent())
+		l.readCharacter()
+		return tok
 	case '.':
 		tok = newToken(token.DOT, l.character, start, l.afterCurrent())
 		l.readCharacter()
@@ -158,19 +191,19 @@ func (l *Lexer) NextToken() token.Token {
 		l.readCharacter()
 		return tok
 	case '"':
-		s, closed := l.readString()
-		end := l.currentStart()
-		if !closed {
-			l.addDiag(diag.Error, "LEX003", "String without closing quote", "Missing closing quote '\"'", start, end)
-			return token.Token{Type: token.ILLEGAL, Literal: s, Range: source.Range{Start: start, End: end}}
-		}
-		l.readCharacter() // consumir comilla de cierre
-		return token.Token{Type: token.STRING, Literal: s, Range: source.Range{Start: start, End: end}}
+		return l.stringPart(start, start, true)
 	case '%':
 		tok = newToken(token.PERCENT, l.character, start, l.afterCurrent())
 		l.readCharacter()
 		return tok
 	case 0:
+		if len(l.interpolations) > 0 {
+			open := l.interpolations[0]
+			l.interpolations = nil
+			l.addDiag(diag.Error, diag.CodeUnterminatedInterpolation, "Unterminated string interpolation",
+				"Missing closing '}' and '\"'", open.start, start)
+			return token.Token{Type: token.ILLEGAL, Literal: "", Range: source.Range{Start: open.start, End: start}}
+		}
 		return token.Token{Type: token.EOF, Literal: "", Range: source.Range{Start: start, End: start}}
 	default:
 		if isLetter(l.character) {
@@ -189,12 +222,12 @@ func (l *Lexer) NextToken() token.Token {
 				// si no hay dígito tras el punto, no es un float válido
 				literal := intPart + string(l.character)
 				end := l.afterCurrent()
-				l.addDiag(diag.Error, "LEX002", "Malformed float literal", "At least one digit is expected after the decimal point", start, end)
+				l.addDiag(diag.Error, diag.CodeMalformedFloat, "Malformed float literal", "At least one digit is expected after the decimal point", start, end)
 				l.readCharacter()
 				return token.Token{Type: token.ILLEGAL, Literal: literal, Range: source.Range{Start: start, End: end}}
 			}
 			if isLetter(l.character) {
-				l.addDiag(diag.Error, "LEX006", "Malformed number literal", "Unexpected character after number", start, l.currentStart())
+				l.addDiag(diag.Error, diag.CodeMalformedNumber, "Malformed number literal", "Unexpected character after number", start, l.currentStart())
 				return token.Token{Type: token.ILLEGAL, Literal: intPart, Range: source.Range{Start: start, End: l.currentStart()}}
 			}
 			return token.Token{Type: token.INTEGER, Literal: intPart, Range: source.Range{Start: start, End: l.currentStart()}}
@@ -202,14 +235,14 @@ func (l *Lexer) NextToken() token.Token {
 		if l.lastDecodeInvalid {
 			end := l.afterCurrent()
 			tok := token.Token{Type: token.ILLEGAL, Literal: string(l.character), Range: source.Range{Start: start, End: end}}
-			l.addDiag(diag.Error, "LEX005", "Invalid UTF-8 byte", "carácter no decodificable", start, end)
+			l.addDiag(diag.Error, diag.CodeInvalidUTF8, "Invalid UTF-8 byte", "carácter no decodificable", start, end)
 			l.readCharacter()
 			return tok
 		}
 
 		end := l.afterCurrent()
 		tok := newToken(token.ILLEGAL, l.character, start, end)
-		l.addDiag(diag.Error, "LEX001", "Illegal Character", "Character not recognized by the language", start, l.afterCurrent())
+		l.addDiag(diag.Error, diag.CodeIllegalCharacter, "Illegal Character", "Character not recognized by the language", start, l.afterCurrent())
 		l.readCharacter()
 		return tok
 	}
@@ -225,6 +258,61 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
+// skipTrivia salta espacios y comentarios, devolviendo los comentarios
+// encontrados en orden.
+func (l *Lexer) skipTrivia() []token.Comment {
+	var comments []token.Comment
+	for {
+		l.skipWhitespace()
+		if l.character != '/' {
+			return comments
+		}
+		switch l.peekCharacter() {
+		case '/':
+			comments = append(comments, l.readLineComment())
+		case '*':
+			comments = append(comments, l.readBlockComment())
+		default:
+			return comments
+		}
+	}
+}
+
+// readLineComment lee un comentario // hasta el fin de línea (sin incluirlo).
+func (l *Lexer) readLineComment() token.Comment {
+	start := l.currentStart()
+	for l.character != '\n' && l.character != 0 {
+		l.readCharacter()
+	}
+	end := l.currentStart()
+	return token.Comment{Text: l.input[start.Offset:end.Offset], Range: source.Range{Start: start, End: end}}
+}
+
+// readBlockComment lee un comentario /* */. Los comentarios de bloque se
+// pueden anidar, por lo que cada /* interno necesita su propio */.
+func (l *Lexer) readBlockComment() token.Comment {
+	start := l.currentStart()
+	l.readCharacter()
+	l.readCharacter() // consumir "/*"
+	depth := 1
+	for depth > 0 && l.character != 0 {
+		switch {
+		case l.character == '/' && l.peekCharacter() == '*':
+			depth++
+			l.readCharacter()
+		case l.character == '*' && l.peekCharacter() == '/':
+			depth--
+			l.readCharacter()
+		}
+		l.readCharacter()
+	}
+	end := l.currentStart()
+	if depth > 0 {
+		l.addDiag(diag.Error, diag.CodeUnterminatedComment, "Unterminated block comment", "Missing closing '*/'", start, end)
+	}
+	return token.Comment{Text: l.input[start.Offset:end.Offset], Range: source.Range{Start: start, End: end}}
+}
+
 func (l *Lexer) read(isValid func(rune) bool) string {
 	offset := l.offset
 	for isValid(l.character) {
@@ -233,29 +321,62 @@ func (l *Lexer) read(isValid func(rune) bool) string {
 	return l.input[offset:l.offset]
 }
 
-func (l *Lexer) readString() (string, bool) {
-	l.readCharacter() // consumir la comilla de apertura
+// stringPart lee el texto de un string desde el carácter actual, que es la
+// comilla de apertura (first) o la } que cierra una interpolación, hasta la
+// comilla de cierre o el siguiente ${. quote es la comilla de apertura del
+// string y start, el comienzo de la parte.
+func (l *Lexer) stringPart(quote, start source.Position, first bool) token.Token {
+	text, stop := l.readString()
+	end := l.currentStart()
+	switch {
+	case stop == 0:
+		// Si el string estaba dentro de una interpolación, ésta tampoco se
+		// cierra: basta con un error.
+		l.interpolations = nil
+		l.addDiag(diag.Error, diag.CodeUnterminatedString, "String without closing quote", "Missing closing quote '\"'", quote, end)
+		return token.Token{Type: token.ILLEGAL, Literal: text, Range: source.Range{Start: start, End: end}}
+	case stop == '{':
+		l.readCharacter() // consumir "$"
+		l.readCharacter() // consumir "{"
+		l.interpolations = append(l.interpolations, interpolation{start: quote})
+		tokenType := token.TokenType(token.STRING_MIDDLE)
+		if first {
+			tokenType = token.STRING_HEAD
+		}
+		return token.Token{Type: tokenType, Literal: text, Range: source.Range{Start: start, End: l.currentStart()}}
+	case first:
+		l.readCharacter() // consumir comilla de cierre
+		return token.Token{Type: token.STRING, Literal: text, Range: source.Range{Start: start, End: end}}
+	default:
+		l.readCharacter() // consumir comilla de cierre
+		return token.Token{Type: token.STRING_TAIL, Literal: text, Range: source.Range{Start: start, End: l.currentStart()}}
+	}
+}
+
+// readString lee el texto de un string a partir del carácter siguiente al
+// actual. Devuelve también dónde se detuvo: '"' en la comilla de cierre,
+// '{' en un ${ (sin consumir ninguno de los dos) o 0 al final del archivo.
+func (l *Lexer) readString() (string, rune) {
+	l.readCharacter() // consumir la comilla de apertura o la }
 	startContent := l.offset
 	for l.character != '"' && l.character != 0 {
+		if l.character == '$' && l.peekCharacter() == '{' {
+			return l.input[startContent:l.offset], '{'
+		}
 		if l.character == '\\' {
 			escStart := l.currentStart()
-			next := l.peekCharacter()
-			switch next {
-			case '"', '\\', 'n', 't', 'r':
+			esc := scanEscape(l.input[l.offset:])
+			for l.offset < escStart.Offset+esc.size {
 				l.readCharacter()
-			default:
-				escEnd := l.afterCurrent()
-				l.addDiag(diag.Error, "LEX004", "Invalid escape sequence",
-					"Use \\\" \\\\ \\n \\t or \\r", escStart, escEnd)
-				if next != 0 {
-					l.readCharacter()
-				}
 			}
+			if esc.code != "" {
+				l.addDiag(diag.Error, esc.code, esc.msg, esc.hint, escStart, l.currentStart())
+			}
+			continue
 		}
 		l.readCharacter()
 	}
-	closed := (l.character == '"')
-	return l.input[startContent:l.offset], closed
+	return l.input[startContent:l.offset], l.character
 }
 
 func (l *Lexer) currentStart() source.Position {
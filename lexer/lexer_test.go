@@ -7,7 +7,7 @@ import (
 )
 
 func TestNextToken(t *testing.T) {
-	input := `!-/*>=<=¿==
+	input := `!-/ *>=<=¿==
 
 let five = 5;
 let ten = 10;
@@ -249,6 +249,124 @@ func TestStringWithEscapedQuoteNoDiag(t *testing.T) {
 	}
 }
 
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		literal  string
+		expected string
+	}{
+		{`plain`, "plain"},
+		{`a\nb`, "a\nb"},
+		{`\"q\" \\ \t \r`, "\"q\" \\ \t \r"},
+		{`\x41\x7e`, "A~"},
+		{`\u{1F98D}`, "🦍"},
+		{`\u{f1}and\u{FA}`, "ñandú"},
+		{`\u{0}`, "\x00"},
+		{`bad \z`, `bad \z`},
+		{`\u{110000}`, `\u{110000}`},
+	}
+	for _, tt := range tests {
+		if got := Unescape(tt.literal); got != tt.expected {
+			t.Errorf("Unescape(%q) wrong. expected=%q, got=%q", tt.literal, tt.expected, got)
+		}
+	}
+}
+
+func TestEscapeDiagnostics(t *testing.T) {
+	tests := []struct {
+		input      string
+		code       string
+		start, end int // offsets
+	}{
+		{`"\x4"`, "LEX004", 1, 4},
+		{`"\xZZ"`, "LEX004", 1, 3},
+		{`"\x80"`, "LEX008", 1, 5},
+		{`"\u41"`, "LEX004", 1, 3},
+		{`"\u{}"`, "LEX004", 1, 4},
+		{`"\u{41"`, "LEX004", 1, 6},
+		{`"\u{110000}"`, "LEX008", 1, 11},
+		{`"\u{0000041}"`, "LEX004", 1, 10},
+		{`"\u{D800}"`, "LEX008", 1, 9},
+		{`"a\ñ"`, "LEX004", 2, 5},
+	}
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != token.STRING || tok.Literal != tt.input[1:len(tt.input)-1] {
+			t.Errorf("%s: expected the STRING token to keep the literal, got %s %q", tt.input, tok.Type, tok.Literal)
+		}
+		ds := l.Diagnostics()
+		if len(ds) != 1 {
+			t.Errorf("%s: expected 1 diagnostic, got %d: %#v", tt.input, len(ds), ds)
+			continue
+		}
+		d := ds[0]
+		if d.Code != tt.code || d.Range.Start.Offset != tt.start || d.Range.End.Offset != tt.end {
+			t.Errorf("%s: expected %s at [%d,%d), got %s at [%d,%d)", tt.input, tt.code, tt.start, tt.end,
+				d.Code, d.Range.Start.Offset, d.Range.End.Offset)
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	input := `"a ${x + {"k": "${y}"}["k"]} b ${z}"`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING_HEAD, "a "},
+		{token.IDENTIFIER, "x"},
+		{token.PLUS, "+"},
+		{token.LEFT_BRACE, "{"},
+		{token.STRING, "k"},
+		{token.COLON, ":"},
+		{token.STRING_HEAD, ""},
+		{token.IDENTIFIER, "y"},
+		{token.STRING_TAIL, ""},
+		{token.RIGHT_BRACE, "}"},
+		{token.LEFT_BRACKET, "["},
+		{token.STRING, "k"},
+		{token.RIGHT_BRACKET, "]"},
+		{token.STRING_MIDDLE, " b "},
+		{token.IDENTIFIER, "z"},
+		{token.STRING_TAIL, ""},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %s %q, got %s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+	if ds := l.Diagnostics(); len(ds) != 0 {
+		t.Fatalf("expected 0 diagnostics, got %#v", ds)
+	}
+
+	l = New(`"a ${x} b"`)
+	head, _, tail := l.NextToken(), l.NextToken(), l.NextToken()
+	if head.Range.Start.Offset != 0 || head.Range.End.Offset != 5 || tail.Range.Start.Offset != 6 || tail.Range.End.Offset != 10 {
+		t.Errorf("wrong ranges: head=[%d,%d) tail=[%d,%d)", head.Range.Start.Offset, head.Range.End.Offset,
+			tail.Range.Start.Offset, tail.Range.End.Offset)
+	}
+}
+
+func TestUnterminatedInterpolation(t *testing.T) {
+	l := New(`"a ${x + 1`)
+	var tok token.Token
+	for tok = l.NextToken(); tok.Type != token.EOF && tok.Type != token.ILLEGAL; tok = l.NextToken() {
+	}
+	if tok.Type != token.ILLEGAL {
+		t.Fatalf("expected ILLEGAL, got %s", tok.Type)
+	}
+	ds := l.Diagnostics()
+	if len(ds) != 1 || ds[0].Code != "LEX009" || ds[0].Range.Start.Offset != 0 {
+		t.Fatalf("expected LEX009 from the opening quote, got %#v", ds)
+	}
+	if tok := l.NextToken(); tok.Type != token.EOF {
+		t.Fatalf("expected EOF after the error, got %s", tok.Type)
+	}
+}
+
 func TestMalformedFloat(t *testing.T) {
 	l := New("3.")
 	tok := l.NextToken()
@@ -331,11 +449,79 @@ func TestInvalidUTF8(t *testing.T) {
 	}
 }
 
+func TestComments(t *testing.T) {
+	input := `// header
+let x = 1; // trailing
+/* block /* nested */ still comment */ x / 2;
+`
+	tests := []struct {
+		expectedType     token.TokenType
+		expectedLiteral  string
+		expectedComments []string
+	}{
+		{token.LET, "let", []string{"// header"}},
+		{token.IDENTIFIER, "x", nil},
+		{token.ASSIGN, "=", nil},
+		{token.INTEGER, "1", nil},
+		{token.SEMICOLON, ";", nil},
+		{token.IDENTIFIER, "x", []string{"// trailing", "/* block /* nested */ still comment */"}},
+		{token.SLASH, "/", nil},
+		{token.INTEGER, "2", nil},
+		{token.SEMICOLON, ";", nil},
+		{token.EOF, "", nil},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if len(tok.Comments) != len(tt.expectedComments) {
+			t.Fatalf("tests[%d] - wrong number of comments. expected=%d, got=%d (%+v)",
+				i, len(tt.expectedComments), len(tok.Comments), tok.Comments)
+		}
+		for j, text := range tt.expectedComments {
+			comment := tok.Comments[j]
+			if comment.Text != text {
+				t.Errorf("tests[%d] - comment[%d] wrong. expected=%q, got=%q", i, j, text, comment.Text)
+			}
+			if input[comment.Range.Start.Offset:comment.Range.End.Offset] != text {
+				t.Errorf("tests[%d] - comment[%d] range does not cover %q", i, j, text)
+			}
+		}
+	}
+	if ds := l.Diagnostics(); len(ds) != 0 {
+		t.Fatalf("expected no diagnostics, got %+v", ds)
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	input := "x /* open /* nested */"
+	l := New(input)
+	l.NextToken()
+	tok := l.NextToken()
+	if tok.Type != token.EOF {
+		t.Fatalf("expected EOF, got %s", tok.Type)
+	}
+	if len(tok.Comments) != 1 || tok.Comments[0].Text != "/* open /* nested */" {
+		t.Fatalf("unexpected comments: %+v", tok.Comments)
+	}
+	ds := l.Diagnostics()
+	if len(ds) != 1 || ds[0].Code != "LEX007" {
+		t.Fatalf("expected LEX007, got %+v", ds)
+	}
+	if ds[0].Range.Start.Offset != 2 || ds[0].Range.End.Offset != len(input) {
+		t.Fatalf("wrong range: %+v", ds[0].Range)
+	}
+}
+
 func FuzzLexerNeverPanicsAndRangesAreMonotonic(f *testing.F) {
 	seeds := []string{
 		``, `a`, `3.`, `"x`, `"foo\"bar"`, "¿",
 		"let x = 1;", `import "math" m;`, "!;",
-		string([]byte{0xff}),
+		string([]byte{0xff}), "// c\nx", "/* /* */", "/* a */ b",
 	}
 	for _, s := range seeds {
 		f.Add(s)
@@ -0,0 +1,175 @@
+// Package lint busca en un programa errores probables que no impiden
+// ejecutarlo: nombres sin usar u ocultos, código inalcanzable y llamadas a
+// funciones inexistentes o a Built-In con argumentos de más o de menos.
+// Todos se reportan como diag.Warning.
+package lint
+
+import (
+	"fmt"
+	"go-rilla/ast"
+	"go-rilla/diag"
+	"go-rilla/evaluator"
+	"go-rilla/scope"
+	"go-rilla/source"
+	"sort"
+	"strings"
+)
+
+// Códigos de los warnings del linter.
+const (
+	CodeUnused      = diag.CodeUnused
+	CodeShadowed    = diag.CodeShadowed
+	CodeUnreachable = diag.CodeUnreachable
+	CodeUndefined   = diag.CodeUndefined
+	CodeArity       = diag.CodeArity
+)
+
+// Check analiza program y devuelve sus warnings ordenados por posición.
+func Check(program *ast.Program) []diag.Diagnostic {
+	info := scope.Resolve(program)
+	c := &checker{}
+	c.unused(info)
+	c.shadowed(info)
+	c.calls(program, info)
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Program:
+			c.unreachable(n.Statements)
+		case *ast.BlockStatement:
+			c.unreachable(n.Statements)
+		}
+		return true
+	})
+
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		return c.diagnostics[i].Range.Start.Offset < c.diagnostics[j].Range.Start.Offset
+	})
+	return c.diagnostics
+}
+
+type checker struct {
+	diagnostics []diag.Diagnostic
+}
+
+func (c *checker) warn(code string, r source.Range, msg, hint string, related ...diag.Related) {
+	c.diagnostics = append(c.diagnostics, diag.Diagnostic{
+		Level:   diag.Warning,
+		Code:    code,
+		Message: msg,
+		Hint:    hint,
+		Range:   r,
+		Related: related,
+	})
+}
+
+// unused reporta los let que nunca se leen. Se omiten los exportados, que
+// usa quien importa el módulo, y los que empiezan con "_".
+func (c *checker) unused(info *scope.Info) {
+	for _, def := range info.Definitions {
+		if def.Kind != scope.Let || def.Exported || strings.HasPrefix(def.Name, "_") {
+			continue
+		}
+		read := false
+		for _, use := range def.Uses {
+			if !use.Assign {
+				read = true
+				break
+			}
+		}
+		if !read {
+			c.warn(CodeUnused, def.Ident.Token.Range,
+				fmt.Sprintf("%s is declared but never used", def.Name),
+				"Remove the declaration or rename it to _"+def.Name)
+		}
+	}
+}
+
+// shadowed reporta las declaraciones dentro de una función que ocultan un
+// nombre de un ámbito exterior.
+func (c *checker) shadowed(info *scope.Info) {
+	for _, def := range info.Definitions {
+		if def.Scope.Parent == nil {
+			continue
+		}
+		outer := def.Scope.Parent.Lookup(def.Name, def.Ident.Token.Range.Start.Offset)
+		if outer == nil {
+			continue
+		}
+		c.warn(CodeShadowed, def.Ident.Token.Range,
+			fmt.Sprintf("%s %s shadows a declaration in an outer scope", def.Kind, def.Name),
+			"Rename one of them to avoid confusion",
+			diag.Related{Message: fmt.Sprintf("%s is declared here", def.Name), Range: outer.Ident.Token.Range})
+	}
+}
+
+// unreachable reporta, una vez por bloque, las sentencias que siguen a un
+// return, break, continue o throw.
+func (c *checker) unreachable(stmts []ast.Statement) {
+	for i, stmt := range stmts[:max(len(stmts)-1, 0)] {
+		var keyword string
+		switch stmt.(type) {
+		case *ast.ReturnStatement:
+			keyword = "return"
+		case *ast.BreakStatement:
+			keyword = "break"
+		case *ast.ContinueStatement:
+			keyword = "continue"
+		case *ast.ThrowStatement:
+			keyword = "throw"
+		default:
+			continue
+		}
+		r := source.Range{
+			Start: ast.NodeRange(stmts[i+1]).Start,
+			End:   ast.NodeRange(stmts[len(stmts)-1]).End,
+		}
+		c.warn(CodeUnreachable, r, "unreachable code after "+keyword, "Remove the code or the "+keyword)
+		return
+	}
+}
+
+// calls revisa las llamadas a nombres sin declarar: si es un Built-In, que
+// la cantidad de argumentos sea válida, y si no, que el nombre exista.
+func (c *checker) calls(program *ast.Program, info *scope.Info) {
+	defs := map[*ast.Identifier]*scope.Definition{}
+	for _, use := range info.Uses {
+		defs[use.Ident] = use.Def
+	}
+
+	ast.Inspect(program, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpression)
+		if !ok {
+			return true
+		}
+		ident, ok := call.Function.(*ast.Identifier)
+		if !ok || defs[ident] != nil {
+			return true
+		}
+
+		builtin, ok := evaluator.LookupBuiltinInfo(ident.Value)
+		if !ok {
+			c.warn(CodeUndefined, ident.Token.Range,
+				fmt.Sprintf("call to undefined function %s", ident.Value),
+				"Declare it with let before calling it")
+			return true
+		}
+		got := len(call.Arguments)
+		if got < builtin.MinArgs || (builtin.MaxArgs >= 0 && got > builtin.MaxArgs) {
+			c.warn(CodeArity, ast.NodeRange(call),
+				fmt.Sprintf("wrong number of arguments to %s. got=%d, want=%s", ident.Value, got, arity(builtin)),
+				"The signature is "+builtin.Signature)
+		}
+		return true
+	})
+}
+
+func arity(info evaluator.BuiltinInfo) string {
+	switch {
+	case info.MaxArgs < 0:
+		return fmt.Sprintf("at least %d", info.MinArgs)
+	case info.MinArgs == info.MaxArgs:
+		return fmt.Sprint(info.MinArgs)
+	default:
+		return fmt.Sprintf("%d to %d", info.MinArgs, info.MaxArgs)
+	}
+}
@@ -0,0 +1,76 @@
+package lint
+
+import (
+	"fmt"
+	"go-rilla/diag"
+	"go-rilla/lexer"
+	"go-rilla/parser"
+	"strings"
+	"testing"
+)
+
+func check(t *testing.T, input string) []diag.Diagnostic {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return Check(program)
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string // "CODE line: message"
+	}{
+		{"let x = 1;", []string{"LNT001 1: x is declared but never used"}},
+		{"let x = 1; x = 2;", []string{"LNT001 1: x is declared but never used"}},
+		{"let x = 1; x += 2;", nil},
+		{"let _x = 1; export let y = 2;", nil},
+		{"let f = fn(a) { a };\nf(1);", nil},
+		{"let a = 1;\nlet f = fn(a) { a };\nf(a);",
+			[]string{"LNT002 2: parameter a shadows a declaration in an outer scope"}},
+		{"let a = 1;\nlet f = fn() {\n    let a = 2;\n    a\n};\nf(a);",
+			[]string{"LNT002 3: let a shadows a declaration in an outer scope"}},
+		// Las declaraciones posteriores no quedan ocultas.
+		{"let f = fn() { let a = 2; a };\nlet a = 1;\nf(a);", nil},
+		{"let f = fn() {\n    return 1;\n    print(2);\n    print(3);\n};\nf();",
+			[]string{"LNT003 3: unreachable code after return"}},
+		{"while (true) {\n    break;\n    print(1);\n}",
+			[]string{"LNT003 3: unreachable code after break"}},
+		{"for (let i = 0; i < 3; i++) {\n    continue;\n    i;\n}",
+			[]string{"LNT003 3: unreachable code after continue"}},
+		{"let f = fn() { throw 1; 2 };\nf();", []string{"LNT003 1: unreachable code after throw"}},
+		{"if (true) { return 1; }\nprint(2);", nil},
+		{"foo(1);", []string{"LNT004 1: call to undefined function foo"}},
+		{"let f = fn() { g() };\nlet g = fn() { 1 };\nf();", nil},
+		{"len(1, 2);", []string{"LNT005 1: wrong number of arguments to len. got=2, want=1"}},
+		{"push([1]);", []string{"LNT005 1: wrong number of arguments to push. got=1, want=2"}},
+		{"print(); print(1, 2, 3);", nil},
+		{"let len = fn(a, b) { a };\nlen(1, 2);", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, d := range check(t, tt.input) {
+			if d.Level != diag.Warning {
+				t.Errorf("%q: %s is not a warning", tt.input, d.Code)
+			}
+			got = append(got, fmt.Sprintf("%s %d: %s", d.Code, d.Range.Start.Line, d.Message))
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("%q: warnings wrong.\nexpected=%q\ngot=     %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestShadowedRelated(t *testing.T) {
+	ds := check(t, "let a = 1;\nlet f = fn(a) { a };\nf(a);")
+	if len(ds) != 1 || len(ds[0].Related) != 1 {
+		t.Fatalf("expected one warning with a note, got %+v", ds)
+	}
+	if ds[0].Related[0].Range.Start.Line != 1 {
+		t.Errorf("note should point to line 1, got %d", ds[0].Related[0].Range.Start.Line)
+	}
+}
@@ -0,0 +1,91 @@
+package lsp
+
+import (
+	"go-rilla/ast"
+	"go-rilla/diag"
+	"go-rilla/lexer"
+	"go-rilla/lint"
+	"go-rilla/parser"
+	"go-rilla/scope"
+	"go-rilla/source"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// document es un archivo abierto en el editor, ya analizado.
+type document struct {
+	uri         string
+	text        string
+	program     *ast.Program
+	diagnostics []diag.Diagnostic
+	info        *scope.Info
+	lines       []int // offset del comienzo de cada línea
+}
+
+func newDocument(uri, text string) *document {
+	l := lexer.New(text)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	doc := &document{
+		uri:         uri,
+		text:        text,
+		program:     program,
+		diagnostics: append(l.Diagnostics(), p.Diagnostics()...),
+		info:        scope.Resolve(program),
+		lines:       []int{0},
+	}
+	if len(doc.diagnostics) == 0 {
+		// Sobre un programa con errores de sintaxis los warnings serían
+		// ruido.
+		doc.diagnostics = lint.Check(program)
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			doc.lines = append(doc.lines, i+1)
+		}
+	}
+	return doc
+}
+
+// position convierte un offset en bytes en una posición de LSP, cuyas
+// columnas se cuentan en unidades UTF-16.
+func (d *document) position(offset int) Position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	line := 0
+	for line+1 < len(d.lines) && d.lines[line+1] <= offset {
+		line++
+	}
+	character := 0
+	for _, r := range d.text[d.lines[line]:offset] {
+		character += len(utf16.Encode([]rune{r}))
+	}
+	return Position{Line: line, Character: character}
+}
+
+// offset convierte una posición de LSP en un offset en bytes.
+func (d *document) offset(pos Position) int {
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	offset := d.lines[pos.Line]
+	for character := 0; character < pos.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		character += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+func (d *document) rangeOf(r source.Range) Range {
+	end := r.End.Offset
+	if end < r.Start.Offset {
+		end = r.Start.Offset
+	}
+	return Range{Start: d.position(r.Start.Offset), End: d.position(end)}
+}
@@ -0,0 +1,172 @@
+package lsp
+
+import (
+	"fmt"
+	"go-rilla/ast"
+	"go-rilla/diag"
+	"go-rilla/evaluator"
+	"go-rilla/scope"
+	"sort"
+	"strings"
+)
+
+func (d *document) lspDiagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, dg := range d.diagnostics {
+		severity := SeverityError
+		switch dg.Level {
+		case diag.Warning:
+			severity = SeverityWarning
+		case diag.Note:
+			severity = SeverityInformation
+		}
+		message := dg.Message
+		if dg.Hint != "" {
+			message += "\nhint: " + dg.Hint
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    d.rangeOf(dg.Range),
+			Severity: severity,
+			Code:     dg.Code,
+			Source:   "go-rilla",
+			Message:  message,
+		})
+	}
+	return diagnostics
+}
+
+// definition devuelve dónde se declara el nombre en offset, o nil si no es
+// un nombre declarado en el documento.
+func (d *document) definition(offset int) *Location {
+	def := d.info.DefinitionAt(offset)
+	if def == nil {
+		return nil
+	}
+	return &Location{URI: d.uri, Range: d.rangeOf(def.Ident.Token.Range)}
+}
+
+// hover describe el nombre en offset: su declaración o, si es un Built-In,
+// su firma.
+func (d *document) hover(offset int) *Hover {
+	ident := d.info.IdentAt(offset)
+	if ident == nil {
+		return nil
+	}
+	r := d.rangeOf(ident.Token.Range)
+
+	if def := d.info.DefinitionAt(offset); def != nil {
+		return &Hover{Contents: markdownCode(describe(def)), Range: &r}
+	}
+	if info, ok := evaluator.LookupBuiltinInfo(ident.Value); ok {
+		contents := markdownCode("builtin " + info.Signature)
+		contents.Value += "\n" + info.Doc
+		return &Hover{Contents: contents, Range: &r}
+	}
+	return nil
+}
+
+func markdownCode(code string) MarkupContent {
+	return MarkupContent{Kind: "markdown", Value: "```monkey\n" + code + "\n```"}
+}
+
+// describe devuelve la declaración de def como se mostraría en el código;
+// para las funciones, con sus parámetros.
+func describe(def *scope.Definition) string {
+	switch def.Kind {
+	case scope.Param:
+		return "parameter " + def.Name
+	case scope.CatchParam:
+		return "catch (" + def.Name + ")"
+	}
+
+	prefix := "let "
+	if def.Exported {
+		prefix = "export let "
+	}
+	if fn := functionValue(def); fn != nil {
+		return prefix + def.Name + " = " + signature(fn)
+	}
+	return prefix + def.Name
+}
+
+func signature(fn *ast.FunctionLiteral) string {
+	params := make([]string, len(fn.Parameters))
+	for i, param := range fn.Parameters {
+		params[i] = param.Value
+	}
+	return "fn(" + strings.Join(params, ", ") + ")"
+}
+
+// functionValue devuelve la función asignada por el let de def, si lo es.
+func functionValue(def *scope.Definition) *ast.FunctionLiteral {
+	let, ok := def.Node.(*ast.LetStatement)
+	if !ok {
+		return nil
+	}
+	fn, _ := let.Value.(*ast.FunctionLiteral)
+	return fn
+}
+
+// symbols devuelve los let del programa; los de cada función aparecen como
+// hijos de ella.
+func (d *document) symbols() []DocumentSymbol {
+	return d.scopeSymbols(d.info.Root)
+}
+
+func (d *document) scopeSymbols(s *scope.Scope) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, def := range s.Definitions {
+		if def.Kind != scope.Let {
+			continue
+		}
+		symbol := DocumentSymbol{
+			Name:           def.Name,
+			Kind:           SymbolVariable,
+			Range:          d.rangeOf(ast.NodeRange(def.Node)),
+			SelectionRange: d.rangeOf(def.Ident.Token.Range),
+		}
+		if fn := functionValue(def); fn != nil {
+			symbol.Kind = SymbolFunction
+			symbol.Detail = signature(fn)
+			for _, child := range s.Children {
+				if child.Node == fn {
+					symbol.Children = d.scopeSymbols(child)
+				}
+			}
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// completion propone los nombres visibles en offset y los Built-In.
+func (d *document) completion(offset int) []CompletionItem {
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+	for _, def := range d.info.ScopeAt(offset).Visible(offset) {
+		// Se omite el nombre que se está escribiendo.
+		if def.Ident.Token.Range.Start.Offset <= offset && offset <= def.Ident.Token.Range.End.Offset {
+			continue
+		}
+		seen[def.Name] = true
+		item := CompletionItem{Label: def.Name, Kind: CompletionVariable, Detail: describe(def)}
+		if functionValue(def) != nil {
+			item.Kind = CompletionFunction
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+
+	for _, info := range evaluator.Builtins() {
+		if seen[info.Name] {
+			continue
+		}
+		items = append(items, CompletionItem{
+			Label:         info.Name,
+			Kind:          CompletionFunction,
+			Detail:        fmt.Sprintf("builtin %s", info.Signature),
+			Documentation: &MarkupContent{Kind: "markdown", Value: info.Doc},
+		})
+	}
+	return items
+}
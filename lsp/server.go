@@ -0,0 +1,224 @@
+// Package lsp implementa un servidor del Language Server Protocol para
+// archivos .monkey. Se comunica por JSON-RPC sobre la entrada y salida
+// estándar y ofrece diagnósticos, ir a la definición, hover, símbolos del
+// documento y autocompletado.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Server atiende a un cliente LSP. Los documentos se sincronizan completos
+// en cada cambio.
+type Server struct {
+	in   *bufio.Reader
+	out  io.Writer
+	docs map[string]*document
+
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer crea un servidor que lee mensajes de in y escribe en out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: map[string]*document{},
+	}
+}
+
+// Run atiende mensajes hasta recibir exit. Devuelve nil si antes se recibió
+// shutdown, como pide el protocolo, y un error si no o si la entrada se
+// cerró o tiene un formato inválido.
+func (s *Server) Run() error {
+	for {
+		msg, err := s.read()
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// read lee un mensaje: encabezados con Content-Length, una línea vacía y el
+// cuerpo JSON.
+func (s *Server) read() (*message, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+
+	msg := &message{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		s.reply(nil, nil, &responseError{Code: parseError, Message: err.Error()})
+		return &message{}, nil
+	}
+	return msg, nil
+}
+
+func (s *Server) write(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rerr *responseError) error {
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+	if rerr != nil {
+		resp["error"] = rerr
+	} else {
+		resp["result"] = result
+	}
+	return s.write(resp)
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return s.write(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// handle responde a una petición o procesa una notificación. Las
+// notificaciones desconocidas se ignoran.
+func (s *Server) handle(msg *message) error {
+	isRequest := msg.ID != nil
+	if msg.Method == "" {
+		if isRequest {
+			return s.reply(msg.ID, nil, &responseError{Code: invalidRequest, Message: "missing method"})
+		}
+		return nil
+	}
+	if !s.initialized && msg.Method != "initialize" {
+		if isRequest {
+			return s.reply(msg.ID, nil, &responseError{Code: serverNotInitialized, Message: "server not initialized"})
+		}
+		return nil
+	}
+
+	result, rerr := s.dispatch(msg)
+	if !isRequest {
+		return nil
+	}
+	return s.reply(msg.ID, result, rerr)
+}
+
+func (s *Server) dispatch(msg *message) (interface{}, *responseError) {
+	switch msg.Method {
+	case "initialize":
+		s.initialized = true
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":       1, // documentos completos
+				"definitionProvider":     true,
+				"hoverProvider":          true,
+				"documentSymbolProvider": true,
+				"completionProvider":     map[string]interface{}{},
+			},
+			"serverInfo": map[string]string{"name": "go-rilla"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, paramsError(err)
+		}
+		s.open(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, paramsError(err)
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.open(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, paramsError(err)
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI: params.TextDocument.URI, Diagnostics: []Diagnostic{},
+		})
+		return nil, nil
+
+	case "textDocument/definition", "textDocument/hover", "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, paramsError(err)
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		offset := doc.offset(params.Position)
+		switch msg.Method {
+		case "textDocument/definition":
+			return doc.definition(offset), nil
+		case "textDocument/hover":
+			return doc.hover(offset), nil
+		default:
+			return doc.completion(offset), nil
+		}
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, paramsError(err)
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return []DocumentSymbol{}, nil
+		}
+		return doc.symbols(), nil
+	}
+
+	if strings.HasPrefix(msg.Method, "$/") {
+		return nil, nil
+	}
+	return nil, &responseError{Code: methodNotFound, Message: "method not found: " + msg.Method}
+}
+
+func paramsError(err error) *responseError {
+	return &responseError{Code: invalidParams, Message: err.Error()}
+}
+
+// open analiza el texto del documento y publica sus diagnósticos.
+func (s *Server) open(uri, text string) {
+	doc := newDocument(uri, text)
+	s.docs[uri] = doc
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI: uri, Diagnostics: doc.lspDiagnostics(),
+	})
+}
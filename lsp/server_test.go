@@ -0,0 +1,239 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testURI = "file:///test.monkey"
+
+// session envía requests al servidor y devuelve los mensajes que escribió.
+func session(t *testing.T, requests ...map[string]interface{}) []map[string]json.RawMessage {
+	t.Helper()
+	var in bytes.Buffer
+	for _, r := range requests {
+		r["jsonrpc"] = "2.0"
+		body, _ := json.Marshal(r)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+
+	var out bytes.Buffer
+	if err := NewServer(&in, &out).Run(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	var messages []map[string]json.RawMessage
+	reader := bufio.NewReader(&out)
+	for {
+		header, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err == io.EOF {
+			return messages
+		}
+		if err != nil {
+			t.Fatalf("reading response header: %v", err)
+		}
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, length)
+		io.ReadFull(reader, body)
+		msg := map[string]json.RawMessage{}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("invalid response %q: %v", body, err)
+		}
+		messages = append(messages, msg)
+	}
+}
+
+// run abre text y hace la petición method; devuelve su resultado y las
+// notificaciones de diagnósticos.
+func run(t *testing.T, text, method string, params map[string]interface{}) (json.RawMessage, []PublishDiagnosticsParams) {
+	t.Helper()
+	params["textDocument"] = map[string]interface{}{"uri": testURI}
+	messages := session(t,
+		map[string]interface{}{"id": 1, "method": "initialize", "params": map[string]interface{}{}},
+		map[string]interface{}{"method": "initialized", "params": map[string]interface{}{}},
+		map[string]interface{}{"method": "textDocument/didOpen", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testURI, "version": 1, "text": text},
+		}},
+		map[string]interface{}{"id": 2, "method": method, "params": params},
+		map[string]interface{}{"id": 3, "method": "shutdown"},
+		map[string]interface{}{"method": "exit"},
+	)
+
+	var result json.RawMessage
+	var published []PublishDiagnosticsParams
+	for _, msg := range messages {
+		if string(msg["method"]) == `"textDocument/publishDiagnostics"` {
+			var p PublishDiagnosticsParams
+			json.Unmarshal(msg["params"], &p)
+			published = append(published, p)
+		}
+		if string(msg["id"]) == "2" {
+			if msg["error"] != nil {
+				t.Fatalf("%s returned error: %s", method, msg["error"])
+			}
+			result = msg["result"]
+		}
+	}
+	return result, published
+}
+
+func at(line, character int) map[string]interface{} {
+	return map[string]interface{}{"position": Position{Line: line, Character: character}}
+}
+
+func TestDiagnostics(t *testing.T) {
+	_, published := run(t, "let x = ;\nlet y = 1..2;\n", "textDocument/documentSymbol", map[string]interface{}{})
+	if len(published) != 1 {
+		t.Fatalf("expected 1 publishDiagnostics, got %d", len(published))
+	}
+
+	got := published[0].Diagnostics
+	if len(got) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %+v", got)
+	}
+	codes := got[0].Code + "," + got[1].Code
+	if codes != "LEX002,PAR002" {
+		t.Errorf("diagnostic codes wrong. got=%s", codes)
+	}
+	want := Range{Start: Position{Line: 0, Character: 8}, End: Position{Line: 0, Character: 9}}
+	if got[1].Range != want || got[1].Severity != SeverityError {
+		t.Errorf("PAR002 diagnostic wrong. got=%+v", got[1])
+	}
+}
+
+func TestLintDiagnostics(t *testing.T) {
+	_, published := run(t, "let unused = 1;\n", "textDocument/documentSymbol", map[string]interface{}{})
+	got := published[0].Diagnostics
+	if len(got) != 1 || got[0].Code != "LNT001" || got[0].Severity != SeverityWarning {
+		t.Errorf("expected an LNT001 warning, got %+v", got)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	// Las columnas de LSP cuentan unidades UTF-16: el emoji ocupa dos.
+	text := "let add = fn(a, b) { a + b };\nlet s = \"😄\"; let total = add(1, 2);\ntotal;"
+	result, _ := run(t, text, "textDocument/definition", at(2, 3))
+
+	var loc Location
+	if err := json.Unmarshal(result, &loc); err != nil {
+		t.Fatalf("invalid location %s: %v", result, err)
+	}
+	want := Range{Start: Position{Line: 1, Character: 18}, End: Position{Line: 1, Character: 23}}
+	if loc.URI != testURI || loc.Range != want {
+		t.Errorf("definition wrong. got=%+v", loc)
+	}
+
+	result, _ = run(t, text, "textDocument/definition", at(0, 21))
+	if err := json.Unmarshal(result, &loc); err != nil || loc.Range.Start != (Position{Line: 0, Character: 13}) {
+		t.Errorf("parameter definition wrong. got=%s", result)
+	}
+}
+
+func TestHover(t *testing.T) {
+	text := "let add = fn(a, b) { a + b };\nprint(add(1, 2));"
+	tests := []struct {
+		position map[string]interface{}
+		contains string
+	}{
+		{at(1, 2), "builtin print(values...)"},
+		{at(1, 7), "let add = fn(a, b)"},
+	}
+
+	for _, tt := range tests {
+		result, _ := run(t, text, "textDocument/hover", tt.position)
+		var hover Hover
+		if err := json.Unmarshal(result, &hover); err != nil {
+			t.Fatalf("invalid hover %s: %v", result, err)
+		}
+		if !strings.Contains(hover.Contents.Value, tt.contains) {
+			t.Errorf("hover wrong. want %q in %q", tt.contains, hover.Contents.Value)
+		}
+	}
+
+	result, _ := run(t, text, "textDocument/hover", at(1, 14))
+	if string(result) != "null" {
+		t.Errorf("hover on a literal should be null, got %s", result)
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	text := "let f = fn(x) {\n    let inner = x;\n    inner\n};\nlet n = 1;"
+	result, _ := run(t, text, "textDocument/documentSymbol", map[string]interface{}{})
+
+	var symbols []DocumentSymbol
+	if err := json.Unmarshal(result, &symbols); err != nil {
+		t.Fatalf("invalid symbols %s: %v", result, err)
+	}
+	if len(symbols) != 2 || symbols[0].Name != "f" || symbols[1].Name != "n" {
+		t.Fatalf("symbols wrong. got=%+v", symbols)
+	}
+	if symbols[0].Kind != SymbolFunction || symbols[1].Kind != SymbolVariable {
+		t.Errorf("symbol kinds wrong. got=%d, %d", symbols[0].Kind, symbols[1].Kind)
+	}
+	if len(symbols[0].Children) != 1 || symbols[0].Children[0].Name != "inner" {
+		t.Errorf("children of f wrong. got=%+v", symbols[0].Children)
+	}
+	if symbols[0].Range.End.Line != 3 {
+		t.Errorf("range of f wrong. got=%+v", symbols[0].Range)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	text := "let outer = 1;\nlet f = fn(param) {\n    let local = 2;\n    \n};\nlet later = 3;"
+	result, _ := run(t, text, "textDocument/completion", at(3, 4))
+
+	var items []CompletionItem
+	if err := json.Unmarshal(result, &items); err != nil {
+		t.Fatalf("invalid completion %s: %v", result, err)
+	}
+	labels := map[string]bool{}
+	for _, item := range items {
+		labels[item.Label] = true
+	}
+	for _, want := range []string{"outer", "f", "param", "local", "later", "len", "print"} {
+		if !labels[want] {
+			t.Errorf("completion is missing %q", want)
+		}
+	}
+
+	result, _ = run(t, text, "textDocument/completion", at(1, 0))
+	items = nil
+	json.Unmarshal(result, &items)
+	for _, item := range items {
+		if item.Label == "param" || item.Label == "local" || item.Label == "later" {
+			t.Errorf("completion outside f should not offer %q", item.Label)
+		}
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	messages := session(t,
+		map[string]interface{}{"id": 1, "method": "textDocument/hover", "params": map[string]interface{}{}},
+		map[string]interface{}{"id": 2, "method": "initialize", "params": map[string]interface{}{}},
+		map[string]interface{}{"id": 3, "method": "unknown/method"},
+		map[string]interface{}{"id": 4, "method": "shutdown"},
+		map[string]interface{}{"method": "exit"},
+	)
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 responses, got %d", len(messages))
+	}
+	if !strings.Contains(string(messages[0]["error"]), "-32002") {
+		t.Errorf("request before initialize should fail, got %s", messages[0]["error"])
+	}
+	if !strings.Contains(string(messages[2]["error"]), "-32601") {
+		t.Errorf("unknown method should fail, got %s", messages[2]["error"])
+	}
+
+	var in bytes.Buffer
+	fmt.Fprintf(&in, "Content-Length: 33\r\n\r\n{\"jsonrpc\":\"2.0\",\"method\":\"exit\"}")
+	if err := NewServer(&in, io.Discard).Run(); err == nil {
+		t.Errorf("exit without shutdown should return an error")
+	}
+}
@@ -1,21 +1,67 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"go-rilla/diag"
+	"go-rilla/evaluator"
+	"go-rilla/format"
+	"go-rilla/internal/diagprint"
+	"go-rilla/lexer"
+	"go-rilla/lint"
+	"go-rilla/lsp"
+	"go-rilla/parser"
 	"go-rilla/repl"
+	"io"
 	"os"
 	"os/user"
 	"strings"
 )
 
 func main() {
-	mode := flag.String("mode", string(repl.ModeEvaluator), "execution mode: evaluator, scanner or parser")
+	mode := flag.String("mode", string(repl.ModeEvaluator), "execution mode: evaluator, scanner, parser, format, lint or lsp")
 	file := flag.String("file", "", "Monkey source file (.monkey) to execute")
+	engine := flag.String("engine", string(repl.EngineTree), "evaluation backend: tree or vm")
+	diagnostics := flag.String("diagnostics", string(diagprint.Color), "diagnostics format: plain, color (colored when stdout is a terminal and NO_COLOR is unset), json (one object per line) or sarif (SARIF 2.1.0 log)")
+	check := flag.Bool("check", false, "with -mode format, list the files that are not formatted instead of rewriting them")
+	strictIntegers := flag.Bool("strict-integers", false, "make integer overflow an error instead of promoting the result to an arbitrary-precision integer")
+	explain := flag.String("explain", "", "print the explanation of a diagnostic code, such as LEX004, and exit")
 	flag.Parse()
 
+	if *explain != "" {
+		os.Exit(explainCode(*explain))
+	}
+
+	diagFormat, err := diagprint.ParseFormat(*diagnostics)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	repl.SetDiagnosticsFormat(diagFormat)
+	repl.SetLimits(evaluator.Limits{StrictIntegers: *strictIntegers})
+
 	selectedMode := repl.ModeParser
 	switch strings.ToLower(*mode) {
+	case "format", "fmt":
+		files := flag.Args()
+		if *file != "" {
+			files = append([]string{*file}, files...)
+		}
+		os.Exit(formatFiles(files, *check))
+	case "lint":
+		files := flag.Args()
+		if *file != "" {
+			files = append([]string{*file}, files...)
+		}
+		os.Exit(lintFiles(files, diagFormat))
+	case "lsp":
+		// Los mensajes van por stdin y stdout; los errores, a stderr.
+		if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "lsp: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	case string(repl.ModeParser):
 		selectedMode = repl.ModeParser
 	case string(repl.ModeScanner):
@@ -23,12 +69,23 @@ func main() {
 	case string(repl.ModeEvaluator):
 		selectedMode = repl.ModeEvaluator
 	default:
-		fmt.Fprintf(os.Stderr, "unknown mode %q; valid values are %q, %q or %q\n", *mode, repl.ModeParser, repl.ModeScanner, repl.ModeEvaluator)
+		fmt.Fprintf(os.Stderr, "unknown mode %q; valid values are %q, %q, %q, %q, %q or %q\n", *mode, repl.ModeParser, repl.ModeScanner, repl.ModeEvaluator, "format", "lint", "lsp")
+		os.Exit(2)
+	}
+
+	var selectedEngine repl.Engine
+	switch strings.ToLower(*engine) {
+	case string(repl.EngineTree):
+		selectedEngine = repl.EngineTree
+	case string(repl.EngineVM):
+		selectedEngine = repl.EngineVM
+	default:
+		fmt.Fprintf(os.Stderr, "unknown engine %q; valid values are %q or %q\n", *engine, repl.EngineTree, repl.EngineVM)
 		os.Exit(2)
 	}
 
 	if *file != "" {
-		if err := runFile(selectedMode, *file); err != nil {
+		if err := runFile(selectedMode, selectedEngine, *file); err != nil {
 			fmt.Fprintf(os.Stderr, "could not read %q: %v\n", *file, err)
 			os.Exit(1)
 		}
@@ -50,18 +107,129 @@ func main() {
 	case repl.ModeParser:
 		repl.StartParser(os.Stdin, os.Stdout)
 	case repl.ModeEvaluator:
-		repl.StartEvaluator(os.Stdin, os.Stdout)
+		repl.StartEvaluator(os.Stdin, os.Stdout, selectedEngine)
 	default:
-		repl.StartEvaluator(os.Stdin, os.Stdout)
+		repl.StartEvaluator(os.Stdin, os.Stdout, selectedEngine)
 	}
 }
 
-func runFile(mode repl.Mode, path string) error {
+func runFile(mode repl.Mode, engine repl.Engine, path string) error {
 	source, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
 
-	repl.RunScript(mode, path, string(source), os.Stdout)
+	repl.RunScript(mode, engine, path, string(source), os.Stdout)
 	return nil
 }
+
+// formatFiles reescribe cada archivo con su formato canónico. Con check no
+// modifica nada: lista los archivos que cambiarían y termina con 1 si hay
+// alguno, para usarlo en CI. Sin archivos formatea la entrada estándar.
+func formatFiles(files []string, check bool) int {
+	if len(files) == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not read stdin: %v\n", err)
+			return 1
+		}
+		formatted, err := format.Source(source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "<stdin>:%v\n", err)
+			return 1
+		}
+		if check {
+			if !bytes.Equal(source, formatted) {
+				fmt.Println("<stdin>")
+				return 1
+			}
+			return 0
+		}
+		os.Stdout.Write(formatted)
+		return 0
+	}
+
+	status := 0
+	for _, path := range files {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not read %q: %v\n", path, err)
+			status = 1
+			continue
+		}
+		formatted, err := format.Source(source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:%v\n", path, err)
+			status = 1
+			continue
+		}
+		if bytes.Equal(source, formatted) {
+			continue
+		}
+		if check {
+			fmt.Println(path)
+			status = 1
+			continue
+		}
+		if err := os.WriteFile(path, formatted, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write %q: %v\n", path, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// explainCode imprime la entrada del catálogo de code.
+func explainCode(code string) int {
+	entry, ok := diag.Lookup(code)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown diagnostic code %q\n", code)
+		return 2
+	}
+	fmt.Print(entry)
+	return 0
+}
+
+// lintFiles imprime los errores de sintaxis y los warnings de cada archivo.
+// Termina con 1 si encontró alguno, para usarlo en CI. En SARIF imprime un
+// solo log con los resultados de todos los archivos.
+func lintFiles(files []string, diagFormat diagprint.Format) int {
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "lint: no files given")
+		return 2
+	}
+
+	status := 0
+	var results []diagprint.File
+	for _, path := range files {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not read %q: %v\n", path, err)
+			status = 1
+			continue
+		}
+
+		l := lexer.New(string(source))
+		p := parser.New(l)
+		program := p.ParseProgram()
+		diagnostics := append(l.Diagnostics(), p.Diagnostics()...)
+		if len(diagnostics) == 0 {
+			// Sobre un programa con errores de sintaxis los warnings
+			// serían ruido.
+			diagnostics = lint.Check(program)
+		}
+		if len(diagnostics) == 0 {
+			continue
+		}
+		status = 1
+		if diagFormat == diagprint.SARIF {
+			results = append(results, diagprint.File{Name: path, Source: string(source), Diagnostics: diagnostics})
+		} else {
+			fmt.Print(diagprint.Render(diagFormat, path, string(source), diagnostics))
+		}
+	}
+	if diagFormat == diagprint.SARIF {
+		fmt.Print(diagprint.RenderSARIF(results))
+	}
+	return status
+}
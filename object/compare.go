@@ -0,0 +1,143 @@
+package object
+
+import (
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Equal informa si a y b son iguales por su valor. Los números se comparan
+// entre sí sin importar su tipo; los arrays, elemento a elemento, y los
+// hashes, clave a clave. El resto de los valores (funciones, módulos, ...)
+// sólo es igual a sí mismo. Un array o hash que se contiene a sí mismo no
+// hace que la comparación se repita sin fin.
+func Equal(a, b Object) bool {
+	return equal(a, b, map[[2]Object]bool{})
+}
+
+// Compare ordena a y b: devuelve un número negativo si a < b, cero si son
+// iguales y uno positivo si a > b. Se pueden ordenar los números entre sí,
+// los strings (byte a byte) y los arrays, de forma lexicográfica, si sus
+// elementos se pueden ordenar. ok es false si a y b no se pueden ordenar.
+func Compare(a, b Object) (result int, ok bool) {
+	return compare(a, b, map[[2]Object]bool{})
+}
+
+// equal y compare llevan en visiting los pares de contenedores que se
+// están comparando. Si un par vuelve a aparecer, es un ciclo: se lo
+// considera igual, y la respuesta la deciden los demás elementos.
+func equal(a, b Object, visiting map[[2]Object]bool) bool {
+	if a == b {
+		return true
+	}
+	if isNumber(a) && isNumber(b) {
+		result, ok := compareNumbers(a, b)
+		return ok && result == 0
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a := a.(type) {
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *String:
+		return a.Value == b.(*String).Value
+	case *Null:
+		return true
+	case *Array:
+		b := b.(*Array)
+		if len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		if visiting[[2]Object{a, b}] {
+			return true
+		}
+		visiting[[2]Object{a, b}] = true
+		defer delete(visiting, [2]Object{a, b})
+		for i := range a.Elements {
+			if !equal(a.Elements[i], b.Elements[i], visiting) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b := b.(*Hash)
+		if a.Len() != b.Len() {
+			return false
+		}
+		if visiting[[2]Object{a, b}] {
+			return true
+		}
+		visiting[[2]Object{a, b}] = true
+		defer delete(visiting, [2]Object{a, b})
+		for key, pair := range a.Pairs {
+			other, ok := b.Get(key)
+			if !ok || !equal(pair.Value, other.Value, visiting) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func compare(a, b Object, visiting map[[2]Object]bool) (int, bool) {
+	if isNumber(a) && isNumber(b) {
+		return compareNumbers(a, b)
+	}
+	if a.Type() != b.Type() {
+		return 0, false
+	}
+	switch a := a.(type) {
+	case *String:
+		return strings.Compare(a.Value, b.(*String).Value), true
+	case *Array:
+		b := b.(*Array)
+		if visiting[[2]Object{a, b}] {
+			return 0, true
+		}
+		visiting[[2]Object{a, b}] = true
+		defer delete(visiting, [2]Object{a, b})
+		for i := 0; i < len(a.Elements) && i < len(b.Elements); i++ {
+			if result, ok := compare(a.Elements[i], b.Elements[i], visiting); !ok || result != 0 {
+				return result, ok
+			}
+		}
+		return len(a.Elements) - len(b.Elements), true
+	}
+	return 0, false
+}
+
+func isNumber(obj Object) bool {
+	switch obj.Type() {
+	case INTEGER_OBJ, BIGINT_OBJ, FLOAT_OBJ:
+		return true
+	}
+	return false
+}
+
+// compareNumbers compara dos números de forma exacta, aun si uno es un
+// BigInt y el otro un Float. NaN no se puede ordenar ni es igual a nada.
+func compareNumbers(a, b Object) (int, bool) {
+	x, xok := bigFloat(a)
+	y, yok := bigFloat(b)
+	if !xok || !yok {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
+
+func bigFloat(obj Object) (*big.Float, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return new(big.Float).SetInt64(obj.Value), true
+	case *BigInt:
+		return new(big.Float).SetInt(obj.Value), true
+	case *Float:
+		if math.IsNaN(obj.Value) {
+			return nil, false
+		}
+		return new(big.Float).SetFloat64(obj.Value), true
+	}
+	return nil, false
+}
@@ -1,30 +1,91 @@
 package object
 
 func NewEnvironment() *Environment {
-	s := make(map[string]Object)
+	s := make(map[string]*Binding)
 	return &Environment{store: s, outer: nil}
 }
 
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store  map[string]*Binding
+	outer  *Environment
+	module *Module
+	block  bool // ver NewBlockEnvironment
+}
+
+// Binding es el lugar donde un Environment guarda una variable. La VM
+// resuelve las variables globales a su Binding al empezar a ejecutar, y
+// las lee y escribe sin buscarlas por nombre. Un Binding sin valor (Value
+// nil) es una variable que todavía no se definió.
+type Binding struct {
+	Value Object
+}
+
+// NewModuleEnvironment crea el entorno global de module. outer (que puede
+// ser nil) contiene los nombres compartidos por todos los módulos.
+func NewModuleEnvironment(module *Module, outer *Environment) *Environment {
+	env := NewEnclosedEnvironment(outer)
+	env.module = module
+	module.Env = env
+	return env
+}
+
+// Module devuelve el módulo al que pertenece el entorno, o nil si se creó
+// fuera de un módulo.
+func (e *Environment) Module() *Module {
+	for env := e; env != nil; env = env.outer {
+		if env.module != nil {
+			return env.module
+		}
+	}
+	return nil
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
-	if !ok && e.outer != nil {
-		obj, ok = e.outer.Get(name)
+	if binding, ok := e.store[name]; ok && binding.Value != nil {
+		return binding.Value, true
 	}
-	return obj, ok
+	if e.outer != nil {
+		return e.outer.Get(name)
+	}
+	return nil, false
 }
 
 func (e *Environment) Set(name string, val Object) Object {
-	e.store[name] = val
+	if _, ok := e.store[name]; !ok && e.block {
+		return e.outer.Set(name, val)
+	}
+	e.Binding(name).Value = val
 	return val
 }
 
+// Binding devuelve el Binding de name en este entorno (no en los
+// exteriores), y lo crea vacío si name todavía no se definió.
+func (e *Environment) Binding(name string) *Binding {
+	binding, ok := e.store[name]
+	if !ok {
+		binding = &Binding{}
+		e.store[name] = binding
+	}
+	return binding
+}
+
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
 	return env
 }
+
+// NewBlockEnvironment crea el entorno de un bloque que define nombres
+// propios, como el parámetro de un catch, sin ser una función: los nombres
+// se definen con Define y asignar cualquier otro lo asigna en outer.
+func NewBlockEnvironment(outer *Environment) *Environment {
+	env := NewEnclosedEnvironment(outer)
+	env.block = true
+	return env
+}
+
+// Define crea name en este entorno, aun si es un bloque.
+func (e *Environment) Define(name string, val Object) Object {
+	e.Binding(name).Value = val
+	return val
+}
@@ -0,0 +1,92 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ToJSON codifica obj como JSON. Los hashes se escriben como objetos con
+// las claves en orden de inserción, y sólo se pueden codificar si todas sus
+// claves son strings: {1: "a", "1": "b"} no tiene un objeto JSON que lo
+// represente. Tampoco se pueden codificar las funciones, los módulos ni los
+// valores que se contienen a sí mismos.
+func ToJSON(obj Object) ([]byte, error) {
+	var out bytes.Buffer
+	if err := writeJSON(&out, obj, map[Object]bool{}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func writeJSON(out *bytes.Buffer, obj Object, visiting map[Object]bool) error {
+	switch obj := obj.(type) {
+	case *Null:
+		out.WriteString("null")
+	case *Boolean:
+		out.WriteString(strconv.FormatBool(obj.Value))
+	case *Integer:
+		out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *BigInt:
+		out.WriteString(obj.Value.String())
+	case *Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return fmt.Errorf("cannot convert %s to JSON", obj.Inspect())
+		}
+		out.WriteString(strconv.FormatFloat(obj.Value, 'g', -1, 64))
+	case *String:
+		writeJSONString(out, obj.Value)
+	case *Array:
+		if visiting[obj] {
+			return fmt.Errorf("cannot convert a cyclic value to JSON")
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+		out.WriteByte('[')
+		for i, element := range obj.Elements {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := writeJSON(out, element, visiting); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case *Hash:
+		if visiting[obj] {
+			return fmt.Errorf("cannot convert a cyclic value to JSON")
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+		out.WriteByte('{')
+		for i, pair := range obj.OrderedPairs() {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			key, ok := pair.Key.(*String)
+			if !ok {
+				return fmt.Errorf("cannot convert a hash key of type %s to JSON", pair.Key.Type())
+			}
+			writeJSONString(out, key.Value)
+			out.WriteByte(':')
+			if err := writeJSON(out, pair.Value, visiting); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	default:
+		return fmt.Errorf("cannot convert %s to JSON", obj.Type())
+	}
+	return nil
+}
+
+func writeJSONString(out *bytes.Buffer, s string) {
+	// Como json.Marshal, pero sin escapar <, > y &, que no hace falta
+	// fuera de HTML. Encode agrega un salto de línea que se descarta.
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	out.Truncate(out.Len() - 1)
+}
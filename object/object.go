@@ -4,14 +4,21 @@ import (
 	"bytes"
 	"fmt"
 	"go-rilla/ast"
+	"go-rilla/code"
+	"go-rilla/diag"
+	"go-rilla/source"
 	"hash/fnv"
+	"math"
+	"math/big"
 	"strings"
+	"sync/atomic"
 )
 
 type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	BIGINT_OBJ       = "BIGINT"
 	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
@@ -25,6 +32,10 @@ const (
 	WHILE_OBJ        = "WHILE"
 	BREAK_OBJ        = "BREAK"
 	CONTINUE_OBJ     = "CONTINUE"
+	ERROR_VALUE_OBJ  = "ERROR_VALUE"
+	MODULE_OBJ       = "MODULE"
+
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
 )
 
 type Object interface {
@@ -41,6 +52,17 @@ func (i *Integer) Inspect() string { return fmt.Sprintf("%d", i.Value) }
 
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 
+// BigInt es un entero que no entra en 64 bits. Las operaciones entre
+// enteros lo producen al desbordar y vuelven a un Integer cuando el
+// resultado entra, así que un BigInt nunca es igual a un Integer.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Inspect() string { return b.Value.String() }
+
+func (b *BigInt) Type() ObjectType { return BIGINT_OBJ }
+
 // Float object
 type Float struct {
 	Value float64
@@ -72,14 +94,146 @@ type ReturnValue struct {
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
+// Tipos (kind) de error visibles desde los scripts.
+const (
+	RUNTIME_ERROR = "RuntimeError"  // errores producidos por el intérprete
+	THROWN_ERROR  = "Error"         // valores lanzados con throw sin un kind propio
+	LIMIT_ERROR   = "LimitExceeded" // límites de ejecución superados; no se puede capturar
+)
+
 // Error object
 type Error struct {
 	Message string
+	Kind    string       // vacío equivale a RUNTIME_ERROR
+	Code    string       // código del diagnóstico; vacío equivale a RUN001
+	Value   Object       // valor lanzado con throw (nil si lo produjo el intérprete)
+	Range   source.Range // nodo que produjo el error (cero si se desconoce)
+	File    string       // módulo al que pertenece Range ("" para el REPL)
+	Stack   []StackFrame // llamadas activas, de la más interna a la más externa
+}
+
+// KindName devuelve el tipo del error, RUNTIME_ERROR si no tiene uno propio.
+func (e *Error) KindName() string {
+	if e.Kind == "" {
+		return RUNTIME_ERROR
+	}
+	return e.Kind
+}
+
+// StackFrame es una llamada a función activa cuando ocurrió un error.
+type StackFrame struct {
+	Function string       // nombre de la función llamada
+	Range    source.Range // ubicación de la llamada
+	File     string       // módulo que contiene la llamada
+}
+
+// HasRange indica si el error ya tiene asignada una ubicación.
+func (e *Error) HasRange() bool { return e.Range.Start.Line > 0 }
+
+// maxStackNotes es la cantidad máxima de notas con llamadas en el
+// diagnóstico de un error; las restantes se resumen en una última nota.
+const maxStackNotes = 20
+
+// Diagnostic convierte el error en un diagnóstico de tiempo de ejecución,
+// con una nota por cada llamada de la pila. Las llamadas iguales seguidas,
+// como las de una recursión, comparten una nota.
+func (e *Error) Diagnostic() diag.Diagnostic {
+	d := diag.Diagnostic{
+		Level:   diag.Error,
+		Code:    diag.CodeRuntime,
+		Message: e.Message,
+		Range:   e.Range,
+	}
+	if e.Code != "" {
+		d.Code = e.Code
+	}
+	switch e.KindName() {
+	case RUNTIME_ERROR:
+	case LIMIT_ERROR:
+		d.Message = fmt.Sprintf("%s: %s", LIMIT_ERROR, e.Message)
+	default:
+		d.Message = fmt.Sprintf("uncaught %s: %s", e.KindName(), e.Message)
+	}
+	for i := 0; i < len(e.Stack); {
+		frame := e.Stack[i]
+		if len(d.Related) == maxStackNotes {
+			d.Related = append(d.Related, diag.Related{
+				Message: fmt.Sprintf("... %d more frames", len(e.Stack)-i),
+				Range:   frame.Range,
+				File:    frame.File,
+			})
+			break
+		}
+		repeated := 1
+		for i+repeated < len(e.Stack) && e.Stack[i+repeated] == frame {
+			repeated++
+		}
+		message := fmt.Sprintf("in call to %s", frame.Function)
+		if repeated > 1 {
+			message += fmt.Sprintf(" (repeated %d times)", repeated)
+		}
+		d.Related = append(d.Related, diag.Related{Message: message, Range: frame.Range, File: frame.File})
+		i += repeated
+	}
+	return d
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
 
+// Error permite devolver un *Error donde se espera un error de Go.
+func (e *Error) Error() string { return e.Message }
+
+// ErrorValue es un error capturado por un catch. A diferencia de *Error es
+// un valor común: no interrumpe la evaluación y expone sus datos (message,
+// kind, line, column, value) como miembros.
+type ErrorValue struct {
+	Err *Error
+}
+
+func (ev *ErrorValue) Type() ObjectType { return ERROR_VALUE_OBJ }
+func (ev *ErrorValue) Inspect() string {
+	return ev.Err.KindName() + ": " + ev.Err.Message
+}
+
+// Module es un archivo cargado con import. Se evalúa una sola vez en su
+// propio entorno y sólo expone los nombres declarados con export.
+type Module struct {
+	Path     string       // ruta del archivo ("" para el REPL)
+	Env      *Environment // entorno global del módulo
+	Exports  []string     // nombres exportados, en orden de declaración
+	Importer Importer     // carga los módulos que este importa
+}
+
+// Importer resuelve y carga un módulo importado desde from. Devuelve el
+// *Module o un *Error.
+type Importer interface {
+	Import(from *Module, path string) Object
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return "<module " + m.Path + ">" }
+
+// Export marca name como exportado.
+func (m *Module) Export(name string) {
+	for _, exported := range m.Exports {
+		if exported == name {
+			return
+		}
+	}
+	m.Exports = append(m.Exports, name)
+}
+
+// Member devuelve el valor exportado con name.
+func (m *Module) Member(name string) (Object, bool) {
+	for _, exported := range m.Exports {
+		if exported == name {
+			return m.Env.Get(name)
+		}
+	}
+	return nil, false
+}
+
 // Function object
 type Function struct {
 	Parameters []*ast.Identifier
@@ -103,9 +257,66 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
+// CompiledFunction object: cuerpo de una función ya compilado a bytecode.
+// Conserva los nodos del AST para enlazar parámetros e imprimir la función,
+// la tabla de posiciones para ubicar errores y el pool de constantes que
+// referencian sus instrucciones.
+type CompiledFunction struct {
+	Instructions code.Instructions
+	Positions    code.Positions
+	Constants    []Object
+	Parameters   []*ast.Identifier
+	Body         *ast.BlockStatement
+
+	// Locals nombra cada variable local por su índice; los parámetros
+	// ocupan los primeros. Globals nombra las variables globales que
+	// referencian sus instrucciones, también por índice.
+	Locals  []string
+	Globals []string
+	// Captured indica que el cuerpo crea funciones que pueden leer sus
+	// variables locales, que entonces no pueden vivir en la pila de la VM.
+	Captured bool
+}
+
+func (cf *CompiledFunction) Type() ObjectType { return COMPILED_FUNCTION_OBJ }
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Closure object: función compilada junto a las variables que ve. Env es
+// el entorno global del módulo donde fue creada, Globals los Binding de
+// Env que nombra Fn.Globals y Scope las variables locales de las funciones
+// que la encierran (nil en el nivel superior). Para el programa es
+// indistinguible de un Function del evaluador.
+type Closure struct {
+	Fn      *CompiledFunction
+	Env     *Environment
+	Globals []*Binding
+	Scope   *Scope
+}
+
+// Scope guarda las variables locales de una llamada de la VM cuando un
+// closure creado en ella puede leerlas. Outer es el Scope de la función
+// que encierra a la llamada.
+type Scope struct {
+	Values []Object
+	Names  []string
+	Outer  *Scope
+}
+
+func (c *Closure) Type() ObjectType { return FUNCTION_OBJ }
+func (c *Closure) Inspect() string {
+	fn := &Function{Parameters: c.Fn.Parameters, Body: c.Fn.Body}
+	return fn.Inspect()
+}
+
 // String object
 type String struct {
 	Value string
+
+	// hashKey guarda la HashKey una vez calculada; por eso un String no
+	// se modifica después de crearlo.
+	hashKey atomic.Pointer[HashKey]
 }
 
 func (s *String) Type() ObjectType { return STRING_OBJ }
@@ -122,8 +333,11 @@ func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
 func (b *Builtin) Inspect() string  { return "builtin function" }
 
 // Array object
+// Un Array con Frozen es inmutable: no se pueden reemplazar, agregar ni
+// quitar elementos. Así son las claves array de un hash (ver Hash.Set).
 type Array struct {
 	Elements []Object
+	Frozen   bool
 }
 
 func (ao *Array) Type() ObjectType { return ARRAY_OBJ }
@@ -139,9 +353,14 @@ func (ao *Array) Inspect() string {
 	return out.String()
 }
 
+// HashKey identifica una clave de un hash. Value es un resumen de la clave y
+// Exact, lo que hace falta para distinguir dos claves con el mismo Value (el
+// texto de un string, los dígitos de un BigInt, ...): dos HashKey son
+// iguales sólo si las claves lo son, sin colisiones.
 type HashKey struct {
 	Type  ObjectType
 	Value uint64
+	Exact string
 }
 
 func (b *Boolean) HashKey() HashKey {
@@ -157,10 +376,38 @@ func (b *Boolean) HashKey() HashKey {
 func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
+func (b *BigInt) HashKey() HashKey {
+	return HashKey{Type: b.Type(), Value: b.Value.Uint64(), Exact: b.Value.Text(16)}
+}
+
+// La HashKey de un Float sin parte decimal es la del entero del mismo valor,
+// para que 1 y 1.0 sean la misma clave, como con ==. Todos los NaN comparten
+// una misma clave.
+func (f *Float) HashKey() HashKey {
+	value := f.Value
+	switch {
+	case math.IsNaN(value):
+		value = math.NaN()
+	case math.IsInf(value, 0) || value != math.Trunc(value):
+		// Tiene su propia clave.
+	case value >= math.MinInt64 && value < math.MaxInt64:
+		return (&Integer{Value: int64(value)}).HashKey()
+	default:
+		integer, _ := new(big.Float).SetFloat64(value).Int(nil)
+		return (&BigInt{Value: integer}).HashKey()
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(value)}
+}
+
 func (s *String) HashKey() HashKey {
+	if key := s.hashKey.Load(); key != nil {
+		return *key
+	}
 	h := fnv.New64a()
 	h.Write([]byte(s.Value))
-	return HashKey{Type: s.Type(), Value: h.Sum64()}
+	key := HashKey{Type: s.Type(), Value: h.Sum64(), Exact: s.Value}
+	s.hashKey.Store(&key)
+	return key
 }
 
 type HashPair struct {
@@ -168,8 +415,51 @@ type HashPair struct {
 	Value Object
 }
 
+// Hash guarda sus pares en Pairs, para buscarlos por HashKey, y recuerda en
+// Order el orden en que se insertaron las claves, que es el orden en que se
+// recorren. Hay que modificarlo con Set para que ambos coincidan.
 type Hash struct {
 	Pairs map[HashKey]HashPair
+	Order []HashKey
+}
+
+// NewHash devuelve un hash vacío con lugar para size pares.
+func NewHash(size int) *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair, size), Order: make([]HashKey, 0, size)}
+}
+
+// Get devuelve el par de la clave key.
+func (h *Hash) Get(key HashKey) (HashPair, bool) {
+	pair, ok := h.Pairs[key]
+	return pair, ok
+}
+
+// Set guarda pair con la clave key. Una clave nueva va al final del orden;
+// una existente conserva su lugar y su objeto. Una clave array se guarda
+// congelada (ver Freeze), para que no pueda cambiar dentro del hash.
+func (h *Hash) Set(key HashKey, pair HashPair) {
+	if h.Pairs == nil {
+		h.Pairs = map[HashKey]HashPair{}
+	}
+	if existing, ok := h.Pairs[key]; ok {
+		pair.Key = existing.Key
+	} else {
+		pair.Key = Freeze(pair.Key)
+		h.Order = append(h.Order, key)
+	}
+	h.Pairs[key] = pair
+}
+
+// Len devuelve la cantidad de pares.
+func (h *Hash) Len() int { return len(h.Pairs) }
+
+// OrderedPairs devuelve los pares en orden de inserción.
+func (h *Hash) OrderedPairs() []HashPair {
+	pairs := make([]HashPair, len(h.Order))
+	for i, key := range h.Order {
+		pairs[i] = h.Pairs[key]
+	}
+	return pairs
 }
 
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
@@ -177,7 +467,7 @@ func (h *Hash) Type() ObjectType { return HASH_OBJ }
 func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for _, pair := range h.Pairs {
+	for _, pair := range h.OrderedPairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s",
 			pair.Key.Inspect(), pair.Value.Inspect()))
 	}
@@ -191,6 +481,62 @@ type Hashable interface {
 	HashKey() HashKey
 }
 
+// HashKeyOf devuelve la HashKey con que obj se guarda en un hash. Además de
+// los Hashable, sirve un array cuyos elementos lo sean, que se identifica por
+// las claves de sus elementos. ok es false si obj no puede ser una clave.
+func HashKeyOf(obj Object) (key HashKey, ok bool) {
+	return hashKeyOf(obj, map[*Array]bool{})
+}
+
+func hashKeyOf(obj Object, visiting map[*Array]bool) (HashKey, bool) {
+	switch obj := obj.(type) {
+	case Hashable:
+		return obj.HashKey(), true
+	case *Array:
+		if visiting[obj] {
+			return HashKey{}, false
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+		var exact strings.Builder
+		for _, element := range obj.Elements {
+			key, ok := hashKeyOf(element, visiting)
+			if !ok {
+				return HashKey{}, false
+			}
+			// El largo de Exact separa los elementos sin ambigüedad.
+			fmt.Fprintf(&exact, "%s:%d:%d:%s", key.Type, key.Value, len(key.Exact), key.Exact)
+		}
+		return HashKey{Type: obj.Type(), Value: uint64(len(obj.Elements)), Exact: exact.String()}, true
+	}
+	return HashKey{}, false
+}
+
+// Freeze devuelve una copia inmutable de un array, con sus arrays internos
+// también congelados. Cualquier otro valor, o un array ya congelado, se
+// devuelve tal cual.
+func Freeze(obj Object) Object {
+	return freeze(obj, map[*Array]*Array{})
+}
+
+// freeze lleva en copies las copias ya hechas, para que un array que se
+// contiene a sí mismo se copie una sola vez.
+func freeze(obj Object, copies map[*Array]*Array) Object {
+	array, ok := obj.(*Array)
+	if !ok || array.Frozen {
+		return obj
+	}
+	if frozen, ok := copies[array]; ok {
+		return frozen
+	}
+	frozen := &Array{Elements: make([]Object, len(array.Elements)), Frozen: true}
+	copies[array] = frozen
+	for i, element := range array.Elements {
+		frozen.Elements[i] = freeze(element, copies)
+	}
+	return frozen
+}
+
 // While Loop object
 type WhileLoop struct {
 	Condition ast.Expression
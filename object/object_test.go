@@ -1,6 +1,11 @@
 package object
 
-import "testing"
+import (
+	"go-rilla/source"
+	"math"
+	"math/big"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -37,6 +42,22 @@ func TestBooleanHashKey(t *testing.T) {
 	}
 }
 
+func TestBigIntHashKey(t *testing.T) {
+	big1 := &BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 70)}
+	big2 := &BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 70)}
+	negative := &BigInt{Value: new(big.Int).Neg(big1.Value)}
+
+	if big1.HashKey() != big2.HashKey() {
+		t.Errorf("big integers with same value have different hash keys")
+	}
+	if big1.HashKey() == negative.HashKey() {
+		t.Errorf("big integers with different sign have same hash keys")
+	}
+	if big1.Inspect() != "1180591620717411303424" || negative.Inspect() != "-1180591620717411303424" {
+		t.Errorf("wrong Inspect. got=%s and %s", big1.Inspect(), negative.Inspect())
+	}
+}
+
 func TestIntegerHashKey(t *testing.T) {
 	one1 := &Integer{Value: 1}
 	one2 := &Integer{Value: 1}
@@ -55,3 +76,210 @@ func TestIntegerHashKey(t *testing.T) {
 		t.Errorf("integers with twoerent content have same hash keys")
 	}
 }
+
+func TestFloatHashKey(t *testing.T) {
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{&Float{Value: 1.5}, &Float{Value: 1.5}, true},
+		{&Float{Value: 1.5}, &Float{Value: 2.5}, false},
+		{&Float{Value: 2}, &Integer{Value: 2}, true},
+		{&Float{Value: math.Copysign(0, -1)}, &Integer{Value: 0}, true},
+		{&Float{Value: 1 << 70}, &BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 70)}, true},
+		{&Float{Value: math.NaN()}, &Float{Value: -math.NaN()}, true},
+		{&Float{Value: math.Inf(1)}, &Float{Value: math.Inf(-1)}, false},
+	}
+	for i, tt := range tests {
+		if got := tt.a.(Hashable).HashKey() == tt.b.(Hashable).HashKey(); got != tt.expected {
+			t.Errorf("tests[%d]: same key for %s and %s = %t, want %t", i, tt.a.Inspect(), tt.b.Inspect(), got, tt.expected)
+		}
+	}
+}
+
+func TestHashKeyOf(t *testing.T) {
+	array := func(elements ...Object) *Array { return &Array{Elements: elements} }
+	key := func(obj Object) HashKey {
+		k, ok := HashKeyOf(obj)
+		if !ok {
+			t.Fatalf("HashKeyOf(%s) not ok", obj.Inspect())
+		}
+		return k
+	}
+
+	if key(array(&Integer{Value: 1}, &String{Value: "a"})) != key(array(&Float{Value: 1}, &String{Value: "a"})) {
+		t.Errorf("arrays with equal elements have different keys")
+	}
+	if key(array(&String{Value: "a"}, &String{Value: "b"})) == key(array(&String{Value: "ab"})) {
+		t.Errorf("arrays with different elements have the same key")
+	}
+	if _, ok := HashKeyOf(array(&Hash{})); ok {
+		t.Errorf("array with a hash element is hashable")
+	}
+	cyclic := array(&Integer{Value: 1})
+	cyclic.Elements = append(cyclic.Elements, cyclic)
+	if _, ok := HashKeyOf(cyclic); ok {
+		t.Errorf("cyclic array is hashable")
+	}
+
+	// Dos claves con el mismo Value no se pisan.
+	hash := NewHash(2)
+	hash.Set(HashKey{Type: STRING_OBJ, Value: 1, Exact: "a"}, HashPair{Key: &String{Value: "a"}, Value: &Integer{Value: 1}})
+	hash.Set(HashKey{Type: STRING_OBJ, Value: 1, Exact: "b"}, HashPair{Key: &String{Value: "b"}, Value: &Integer{Value: 2}})
+	if hash.Len() != 2 {
+		t.Errorf("colliding keys overwrote each other: %s", hash.Inspect())
+	}
+
+	s := &String{Value: "cached"}
+	if s.HashKey() != s.HashKey() || s.HashKey() != (&String{Value: "cached"}).HashKey() {
+		t.Errorf("cached string key differs")
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	inner := &Array{Elements: []Object{&Integer{Value: 1}}}
+	outer := &Array{Elements: []Object{inner}}
+	frozen := Freeze(outer).(*Array)
+	if !frozen.Frozen || !frozen.Elements[0].(*Array).Frozen || outer.Frozen || inner.Frozen {
+		t.Errorf("Freeze did not copy. got=%+v", frozen)
+	}
+	if Freeze(frozen) != frozen {
+		t.Errorf("Freeze copied a frozen array")
+	}
+
+	cyclic := &Array{Elements: []Object{&Integer{Value: 1}}}
+	cyclic.Elements = append(cyclic.Elements, cyclic)
+	frozenCyclic := Freeze(cyclic).(*Array)
+	if frozenCyclic.Elements[1] != frozenCyclic {
+		t.Errorf("Freeze did not keep the cycle")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	one := &Integer{Value: 1}
+	cyclic := func() *Array {
+		a := &Array{Elements: []Object{one}}
+		a.Elements = append(a.Elements, a)
+		return a
+	}
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{one, &Integer{Value: 1}, true},
+		{one, &Float{Value: 1}, true},
+		{&BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 70)}, &Float{Value: 1 << 70}, true},
+		{&Float{Value: math.NaN()}, &Float{Value: math.NaN()}, false},
+		{one, &String{Value: "1"}, false},
+		{&Null{}, &Null{}, true},
+		{&Array{Elements: []Object{one, &String{Value: "a"}}}, &Array{Elements: []Object{one, &String{Value: "a"}}}, true},
+		{&Array{Elements: []Object{one}}, &Array{Elements: []Object{one, one}}, false},
+		{cyclic(), cyclic(), true},
+	}
+	for i, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.expected {
+			t.Errorf("tests[%d]: Equal(%s, %s) = %t, want %t", i, tt.a.Inspect(), tt.b.Inspect(), got, tt.expected)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	array := func(elements ...Object) *Array { return &Array{Elements: elements} }
+	tests := []struct {
+		a, b     Object
+		expected int
+		ok       bool
+	}{
+		{&Integer{Value: 1}, &Float{Value: 1.5}, -1, true},
+		{&String{Value: "b"}, &String{Value: "a"}, 1, true},
+		{array(&Integer{Value: 1}), array(&Integer{Value: 1}, &Integer{Value: 0}), -1, true},
+		{array(&String{Value: "x"}), array(&String{Value: "x"}), 0, true},
+		{array(&Integer{Value: 1}), array(&String{Value: "x"}), 0, false},
+		{&Boolean{Value: true}, &Boolean{Value: false}, 0, false},
+		{&Float{Value: math.NaN()}, &Integer{Value: 1}, 0, false},
+	}
+	for i, tt := range tests {
+		got, ok := Compare(tt.a, tt.b)
+		if got > 0 {
+			got = 1
+		} else if got < 0 {
+			got = -1
+		}
+		if ok != tt.ok || got != tt.expected {
+			t.Errorf("tests[%d]: Compare(%s, %s) = %d, %t, want %d, %t", i, tt.a.Inspect(), tt.b.Inspect(), got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestErrorDiagnosticStack(t *testing.T) {
+	frame := func(name string, line int) StackFrame {
+		return StackFrame{Function: name, Range: source.Range{Start: source.Position{Line: line, Column: 1}}}
+	}
+
+	recursion := &Error{Message: "boom"}
+	for i := 0; i < 1000; i++ {
+		recursion.Stack = append(recursion.Stack, frame("f", 2))
+	}
+	recursion.Stack = append(recursion.Stack, frame("f", 5))
+	related := recursion.Diagnostic().Related
+	if len(related) != 2 ||
+		related[0].Message != "in call to f (repeated 1000 times)" ||
+		related[1].Message != "in call to f" || related[1].Range.Start.Line != 5 {
+		t.Errorf("recursion notes wrong. got=%+v", related)
+	}
+
+	mutual := &Error{Message: "boom"}
+	for i := 0; i < 50; i++ {
+		mutual.Stack = append(mutual.Stack, frame("f", 2), frame("g", 3))
+	}
+	related = mutual.Diagnostic().Related
+	if len(related) != maxStackNotes+1 || related[maxStackNotes].Message != "... 80 more frames" {
+		t.Errorf("expected %d notes ending in a summary, got %d: %+v", maxStackNotes+1, len(related), related[len(related)-1])
+	}
+}
+
+func TestHashSet(t *testing.T) {
+	hash := NewHash(0)
+	for _, key := range []string{"b", "a", "b", "c"} {
+		k := &String{Value: key}
+		hash.Set(k.HashKey(), HashPair{Key: k, Value: &Integer{Value: int64(hash.Len())}})
+	}
+
+	if hash.Len() != 3 || len(hash.Order) != 3 {
+		t.Fatalf("wrong length. got=%d, order=%d", hash.Len(), len(hash.Order))
+	}
+	if hash.Inspect() != "{b: 2, a: 1, c: 2}" {
+		t.Errorf("wrong Inspect. got=%s", hash.Inspect())
+	}
+	if pair, ok := hash.Get((&String{Value: "a"}).HashKey()); !ok || pair.Value.Inspect() != "1" {
+		t.Errorf("Get(a) wrong. got=%v, %t", pair, ok)
+	}
+
+	var zero Hash
+	zero.Set((&Integer{Value: 1}).HashKey(), HashPair{Key: &Integer{Value: 1}, Value: &Null{}})
+	if zero.Inspect() != "{1: null}" {
+		t.Errorf("wrong Inspect for zero hash. got=%s", zero.Inspect())
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	hash := NewHash(2)
+	for _, key := range []Object{&String{Value: "z"}, &String{Value: "1"}} {
+		hash.Set(key.(Hashable).HashKey(), HashPair{Key: key, Value: &Array{Elements: []Object{&Float{Value: 0.5}, &Boolean{Value: false}}}})
+	}
+	encoded, err := ToJSON(hash)
+	if err != nil {
+		t.Fatalf("ToJSON returned error: %v", err)
+	}
+	if string(encoded) != `{"z":[0.5,false],"1":[0.5,false]}` {
+		t.Errorf("wrong JSON. got=%s", encoded)
+	}
+
+	if _, err := ToJSON(&Float{Value: math.Inf(1)}); err == nil {
+		t.Errorf("expected an error for +Inf")
+	}
+	hash.Set((&Integer{Value: 1}).HashKey(), HashPair{Key: &Integer{Value: 1}, Value: &Null{}})
+	if _, err := ToJSON(hash); err == nil || err.Error() != "cannot convert a hash key of type INTEGER to JSON" {
+		t.Errorf("expected an error for an integer key, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"go-rilla/ast"
 	"go-rilla/diag"
 	"go-rilla/lexer"
+	"go-rilla/source"
 	"go-rilla/token"
 	"strconv"
 )
@@ -13,11 +14,17 @@ type Parser struct {
 	l               *lexer.Lexer
 	currentToken    token.Token
 	peekToken       token.Token
-	errors          []string
 	prefixParseFns  map[token.TokenType]prefixParseFn
 	infixParseFns   map[token.TokenType]infixParseFn
 	postfixParseFns map[token.TokenType]postfixParseFn
 	diagnostics     []diag.Diagnostic
+
+	// depth es la cantidad de llaves abiertas antes de currentToken.
+	depth int
+	// recovering indica que la sentencia actual ya tuvo un error. Los
+	// siguientes suelen ser consecuencia del primero, así que se descartan
+	// hasta que synchronize encuentra el comienzo de otra sentencia.
+	recovering bool
 }
 
 func (p *Parser) Diagnostics() []diag.Diagnostic { return p.diagnostics }
@@ -26,8 +33,36 @@ func (p *Parser) addDiag(d diag.Diagnostic) {
 	p.diagnostics = append(p.diagnostics, d)
 }
 
+// addError registra un error de sintaxis, salvo que la sentencia actual ya
+// tenga uno.
+func (p *Parser) addError(code, msg, hint string, r source.Range) {
+	if p.recovering {
+		return
+	}
+	p.recovering = true
+	p.addDiag(diag.Diagnostic{
+		Level:   diag.Error,
+		Code:    code,
+		Message: msg,
+		Hint:    hint,
+		Range:   r,
+	})
+}
+
+// lexerReported informa si el lexer ya emitió un diagnóstico que abarca el
+// token inválido tok. No lo hace para todos: un '&' o '|' suelto es ILLEGAL
+// sin diagnóstico, y entonces el error lo reporta el parser.
+func (p *Parser) lexerReported(tok token.Token) bool {
+	for _, d := range p.l.Diagnostics() {
+		if d.Range.Start.Offset <= tok.Range.Start.Offset && tok.Range.End.Offset <= d.Range.End.Offset {
+			return true
+		}
+	}
+	return false
+}
+
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{}}
+	p := &Parser{l: l}
 
 	p.postfixParseFns = make(map[token.TokenType]postfixParseFn)
 	p.registerPostfix(token.PLUS_PLUS, p.parseIncrementExpression)
@@ -38,6 +73,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.INTEGER, p.parseIntegerLiteral)
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.STRING_HEAD, p.parseInterpolatedString)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
@@ -49,6 +85,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LEFT_BRACE, p.parseHashLiteral)
 	p.registerPrefix(token.WHILE, p.parseWhileLoop)
 	p.registerPrefix(token.FOR, p.parseForLoop)
+	p.registerPrefix(token.TRY, p.parseTryExpression)
+	p.registerPrefix(token.IMPORT, p.parseImportExpression)
 	p.registerPrefix(token.PLUS_PLUS, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS_MINUS, p.parsePrefixExpression)
 
@@ -57,21 +95,23 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.STAR, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
-	p.registerInfix(token.ASSIGN, p.parseInfixExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignmentExpression)
 	p.registerInfix(token.EQUALS, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.LESS_THAN, p.parseInfixExpression)
 	p.registerInfix(token.GREATER_THAN, p.parseInfixExpression)
 	p.registerInfix(token.LESS_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.GREATER_EQUAL, p.parseInfixExpression)
-	p.registerInfix(token.SUM_ASSIGN, p.parseInfixExpression)
-	p.registerInfix(token.SUB_ASSIGN, p.parseInfixExpression)
+	p.registerInfix(token.SUM_ASSIGN, p.parseAssignmentExpression)
+	p.registerInfix(token.SUB_ASSIGN, p.parseAssignmentExpression)
 	p.registerInfix(token.AND, p.parseInfixExpression)
 	p.registerInfix(token.OR, p.parseInfixExpression)
 	p.registerInfix(token.PERCENT, p.parseInfixExpression)
 	p.registerInfix(token.LEFT_PARENTHESIS, p.parseCallExpression)
 	p.registerInfix(token.LEFT_BRACKET, p.parseIndexExpression)
 	p.registerInfix(token.STAR_STAR, p.parseExponentiationExpression)
+	p.registerInfix(token.QUESTION, p.parseConditionalExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 
 	// Leer dos tokens, para inicializar currentToken y peekToken
 	p.nextToken()
@@ -84,9 +124,26 @@ func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
 }
 
-func (p *Parser) Errors() []string { return p.errors }
+// Errors devuelve los mensajes de los errores de Diagnostics, en orden.
+func (p *Parser) Errors() []string {
+	errors := []string{}
+	for _, d := range p.diagnostics {
+		if d.Level == diag.Error {
+			errors = append(errors, d.Message)
+		}
+	}
+	return errors
+}
 
 func (p *Parser) nextToken() {
+	switch p.currentToken.Type {
+	case token.LEFT_BRACE:
+		p.depth++
+	case token.RIGHT_BRACE:
+		if p.depth > 0 {
+			p.depth--
+		}
+	}
 	p.currentToken = p.peekToken
 	p.peekToken = p.l.NextToken()
 }
@@ -96,13 +153,58 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for !p.currentTokenIs(token.EOF) {
+		depth := p.depth
 		stmt := p.parseStatement()
 		program.Statements = append(program.Statements, stmt)
+		if p.recovering {
+			p.synchronize(depth)
+			// Fuera de un bloque, una "}" no cierra nada: ya se reportó.
+			if p.currentTokenIs(token.RIGHT_BRACE) {
+				p.nextToken()
+			}
+			continue
+		}
 		p.nextToken()
 	}
 	return program
 }
 
+// synchronize descarta los tokens que quedan de una sentencia con errores,
+// que empezó con depth llaves abiertas. Se detiene en el comienzo de la
+// sentencia siguiente: después de un ";" o de la "}" de un bloque de la
+// sentencia, en una palabra clave que sólo puede empezar una sentencia, o en
+// la "}" que cierra el bloque que la contiene.
+func (p *Parser) synchronize(depth int) {
+	p.recovering = false
+	for !p.currentTokenIs(token.EOF) && p.depth >= depth {
+		if p.depth == depth {
+			switch p.currentToken.Type {
+			case token.RIGHT_BRACE:
+				return
+			case token.SEMICOLON:
+				p.nextToken()
+				return
+			}
+		}
+
+		closesBlock := p.currentTokenIs(token.RIGHT_BRACE) && p.depth == depth+1
+		p.nextToken()
+		if p.depth != depth {
+			continue
+		}
+		switch p.currentToken.Type {
+		case token.LET, token.RETURN, token.THROW, token.BREAK, token.CONTINUE, token.EXPORT:
+			return
+		case token.ELSE, token.CATCH, token.FINALLY:
+			// El bloque anterior es parte de un if o un try.
+		default:
+			if closesBlock {
+				return
+			}
+		}
+	}
+}
+
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.currentToken.Type {
 	case token.LET:
@@ -113,6 +215,10 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseBreakStatement()
 	case token.CONTINUE:
 		return p.parseContinueStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -148,15 +254,12 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 }
 
 func (p *Parser) peekError(t token.TokenType) {
+	if p.peekTokenIs(token.ILLEGAL) && p.lexerReported(p.peekToken) {
+		p.recovering = true
+		return
+	}
 	msg := fmt.Sprintf("Expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
-	p.addDiag(diag.Diagnostic{
-		Level:   diag.Error,
-		Code:    "PAR001",
-		Message: msg,
-		Hint:    "Check the previous expression or a possible missing ';'",
-		Range:   p.peekToken.Range,
-	})
+	p.addError(diag.CodeExpectedToken, msg, "Check the previous expression or a possible missing ';'", p.peekToken.Range)
 }
 
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
@@ -169,6 +272,28 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.currentToken}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseExportStatement() ast.Statement {
+	tok := p.currentToken
+	if !p.expectPeek(token.LET) {
+		return nil
+	}
+	let := p.parseLetStatement()
+	if let == nil {
+		return nil
+	}
+	return &ast.ExportStatement{Token: tok, Statement: let}
+}
+
 func (p *Parser) parseBreakStatement() *ast.BreakStatement {
 	stmt := &ast.BreakStatement{Token: p.currentToken}
 	if p.peekTokenIs(token.SEMICOLON) {
@@ -215,9 +340,11 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 const (
 	_ int = iota
 	LOWEST
-	EQUALS      // ==, !=, &&, ||, =
+	ASSIGN      // =, +=, -=
+	TERNARY     // c ? a : b
+	EQUALS      // ==, !=, &&, ||
 	LESSGREATER // >, <, <=, >=
-	SUM         // +, -, +=, -=
+	SUM         // +, -
 	PRODUCT     // *, /
 	EXP         // **
 	PREFIX      // -X or !X
@@ -230,6 +357,10 @@ const (
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.currentToken.Type]
 	if prefix == nil {
+		if p.currentTokenIs(token.ILLEGAL) && p.lexerReported(p.currentToken) {
+			p.recovering = true
+			return nil
+		}
 		p.noPrefixParseFnError(p.currentToken.Type)
 		return nil
 	}
@@ -257,14 +388,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.currentToken.Literal, 0, 64)
 	if err != nil {
 		msg := fmt.Sprintf("Could not parse %q as integer", p.currentToken.Literal)
-		p.errors = append(p.errors, msg)
-		p.addDiag(diag.Diagnostic{
-			Level:   diag.Error,
-			Code:    "LIT001",
-			Message: msg,
-			Hint:    "Value out of range or invalid format",
-			Range:   p.currentToken.Range,
-		})
+		p.addError(diag.CodeIntegerLiteral, msg, "Value out of range or invalid format", p.currentToken.Range)
 		return nil
 	}
 	lit.Value = value
@@ -276,14 +400,7 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	value, err := strconv.ParseFloat(p.currentToken.Literal, 64)
 	if err != nil {
 		msg := fmt.Sprintf("Could not parse %q as float", p.currentToken.Literal)
-		p.errors = append(p.errors, msg)
-		p.addDiag(diag.Diagnostic{
-			Level:   diag.Error,
-			Code:    "LIT002",
-			Message: msg,
-			Hint:    "Value out of range or invalid format",
-			Range:   p.currentToken.Range,
-		})
+		p.addError(diag.CodeFloatLiteral, msg, "Value out of range or invalid format", p.currentToken.Range)
 		return nil
 	}
 	lit.Value = value
@@ -291,19 +408,53 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 }
 
 func (p *Parser) parseStringLiteral() ast.Expression {
-	return &ast.StringLiteral{Token: p.currentToken, Value: p.currentToken.Literal}
+	return &ast.StringLiteral{Token: p.currentToken, Value: lexer.Unescape(p.currentToken.Literal)}
+}
+
+// parseInterpolatedString parsea "texto ${expr} texto ...": el lexer ya
+// separó el texto en STRING_HEAD, STRING_MIDDLE y STRING_TAIL.
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	expression := &ast.InterpolatedString{Token: p.currentToken}
+	expression.Parts = append(expression.Parts, p.stringPart())
+
+	for {
+		if p.peekTokenIs(token.STRING_MIDDLE) || p.peekTokenIs(token.STRING_TAIL) {
+			// "${}": el rango va del $ a la }.
+			start, end := p.currentToken.Range.End, p.peekToken.Range.Start
+			start.Offset, start.Column = start.Offset-2, start.Column-2
+			end.Offset, end.Column = end.Offset+1, end.Column+1
+			p.addError(diag.CodeEmptyInterpolation, "Empty string interpolation",
+				"Write an expression between ${ and }", source.Range{Start: start, End: end})
+			return nil
+		}
+		p.nextToken()
+		expression.Parts = append(expression.Parts, p.parseExpression(LOWEST))
+
+		switch {
+		case p.peekTokenIs(token.STRING_MIDDLE):
+			p.nextToken()
+			expression.Parts = append(expression.Parts, p.stringPart())
+		case p.peekTokenIs(token.STRING_TAIL):
+			p.nextToken()
+			expression.Parts = append(expression.Parts, p.stringPart())
+			expression.EndToken = p.currentToken
+			return expression
+		default:
+			p.peekError(token.RIGHT_BRACE)
+			return nil
+		}
+	}
+}
+
+// stringPart devuelve el texto del token actual, una parte de un string con
+// interpolaciones.
+func (p *Parser) stringPart() *ast.StringLiteral {
+	return &ast.StringLiteral{Token: p.currentToken, Value: lexer.Unescape(p.currentToken.Literal)}
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	msg := fmt.Sprintf("No prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
-	p.addDiag(diag.Diagnostic{
-		Level:   diag.Error,
-		Code:    "PAR002",
-		Message: msg,
-		Hint:    "Unexpected token at the beginning of an expression",
-		Range:   p.currentToken.Range,
-	})
+	p.addError(diag.CodeNoPrefix, msg, "Unexpected token at the beginning of an expression", p.currentToken.Range)
 }
 
 func (p *Parser) parsePrefixExpression() ast.Expression {
@@ -314,19 +465,20 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 }
 
 var precedences = map[token.TokenType]int{
+	token.QUESTION:         TERNARY,
 	token.EQUALS:           EQUALS,
 	token.NOT_EQUAL:        EQUALS,
 	token.AND:              EQUALS,
 	token.OR:               EQUALS,
-	token.ASSIGN:           EQUALS,
+	token.ASSIGN:           ASSIGN,
 	token.LESS_THAN:        LESSGREATER,
 	token.GREATER_THAN:     LESSGREATER,
 	token.LESS_EQUAL:       LESSGREATER,
 	token.GREATER_EQUAL:    LESSGREATER,
 	token.PLUS:             SUM,
 	token.MINUS:            SUM,
-	token.SUM_ASSIGN:       SUM,
-	token.SUB_ASSIGN:       SUM,
+	token.SUM_ASSIGN:       ASSIGN,
+	token.SUB_ASSIGN:       ASSIGN,
 	token.STAR:             PRODUCT,
 	token.SLASH:            PRODUCT,
 	token.PERCENT:          PRODUCT,
@@ -338,6 +490,15 @@ var precedences = map[token.TokenType]int{
 	token.MINUS_MINUS:      POSTFIX,
 }
 
+// Precedence devuelve la precedencia del operador infijo o postfijo t, o
+// LOWEST si t no es un operador.
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
+}
+
 func (p *Parser) peekPrecedence() int {
 	if p, ok := precedences[p.peekToken.Type]; ok {
 		return p
@@ -368,6 +529,16 @@ func (p *Parser) parseExponentiationExpression(left ast.Expression) ast.Expressi
 	return expression
 }
 
+// parseAssignmentExpression parsea =, += y -=, que tienen la menor
+// precedencia y asocian a derecha: `x = c ? 1 : 2` asigna el resultado del
+// ternario y `a = b = 1` asigna 1 a ambos.
+func (p *Parser) parseAssignmentExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{Token: p.currentToken, Operator: infixOperatorLiteral(p.currentToken), Left: left}
+	p.nextToken()
+	expression.Right = p.parseExpression(ASSIGN - 1)
+	return expression
+}
+
 func infixOperatorLiteral(tok token.Token) string {
 	switch tok.Type {
 	case token.SUM_ASSIGN:
@@ -408,6 +579,15 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	expression.Consequence = p.parseBlockStatement()
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			elseIf, ok := p.parseIfExpression().(*ast.IfExpression)
+			if !ok {
+				return nil
+			}
+			expression.ElseIf = elseIf
+			return expression
+		}
 		if !p.expectPeek(token.LEFT_BRACE) {
 			return nil
 		}
@@ -416,15 +596,44 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	expression := &ast.MemberExpression{Token: p.currentToken, Object: object}
+	if !p.expectPeek(token.IDENTIFIER) {
+		return nil
+	}
+	expression.Property = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+	return expression
+}
+
+// parseConditionalExpression parsea el ternario cond ? a : b. Es asociativo
+// a derecha: a ? b : c ? d : e equivale a a ? b : (c ? d : e).
+func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	expression := &ast.ConditionalExpression{Token: p.currentToken, Condition: condition}
+	p.nextToken()
+	expression.Consequence = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+	p.nextToken()
+	expression.Alternative = p.parseExpression(TERNARY - 1)
+	return expression
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.currentToken}
 	block.Statements = []ast.Statement{}
 	p.nextToken()
 	for !p.currentTokenIs(token.RIGHT_BRACE) && !p.currentTokenIs(token.EOF) {
+		depth := p.depth
 		stmt := p.parseStatement()
 		block.Statements = append(block.Statements, stmt)
+		if p.recovering {
+			p.synchronize(depth)
+			continue
+		}
 		p.nextToken()
 	}
+	block.EndToken = p.currentToken
 	return block
 }
 
@@ -465,12 +674,14 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.currentToken, Function: function}
 	exp.Arguments = p.parseExpressionList(token.RIGHT_PARENTHESIS)
+	exp.EndToken = p.currentToken
 	return exp
 }
 
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.currentToken}
 	array.Elements = p.parseExpressionList(token.RIGHT_BRACKET)
+	array.EndToken = p.currentToken
 	return array
 }
 
@@ -503,6 +714,7 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	if !p.expectPeek(token.RIGHT_BRACKET) {
 		return nil
 	}
+	exp.EndToken = p.currentToken
 	return exp
 }
 
@@ -518,6 +730,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 		if !p.peekTokenIs(token.RIGHT_BRACE) && !p.expectPeek(token.COMMA) {
 			return nil
 		}
@@ -525,6 +738,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 	if !p.expectPeek(token.RIGHT_BRACE) {
 		return nil
 	}
+	hash.EndToken = p.currentToken
 	return hash
 }
 
@@ -545,6 +759,47 @@ func (p *Parser) parseWhileLoop() ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseTryExpression() ast.Expression {
+	expression := &ast.TryExpression{Token: p.currentToken}
+	if !p.expectPeek(token.LEFT_BRACE) {
+		return nil
+	}
+	expression.Block = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+		if p.peekTokenIs(token.LEFT_PARENTHESIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENTIFIER) {
+				return nil
+			}
+			expression.CatchParameter = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+			if !p.expectPeek(token.RIGHT_PARENTHESIS) {
+				return nil
+			}
+		}
+		if !p.expectPeek(token.LEFT_BRACE) {
+			return nil
+		}
+		expression.Catch = p.parseBlockStatement()
+	}
+
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+		if !p.expectPeek(token.LEFT_BRACE) {
+			return nil
+		}
+		expression.Finally = p.parseBlockStatement()
+	}
+
+	if expression.Catch == nil && expression.Finally == nil {
+		msg := "try without catch or finally"
+		p.addError(diag.CodeTryHandler, msg, "Add a catch (e) { } or finally { } block", expression.Token.Range)
+		return nil
+	}
+	return expression
+}
+
 func (p *Parser) parseForLoop() ast.Expression {
 	expression := &ast.WhileExpression{Token: p.currentToken}
 
@@ -606,3 +861,12 @@ func (p *Parser) parseDecrementExpression(left ast.Expression) ast.Expression {
 	expression := &ast.PostfixExpression{Token: p.currentToken, Operator: p.currentToken.Literal, Left: left}
 	return expression
 }
+
+func (p *Parser) parseImportExpression() ast.Expression {
+	expression := &ast.ImportExpression{Token: p.currentToken}
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	expression.Path = &ast.StringLiteral{Token: p.currentToken, Value: lexer.Unescape(p.currentToken.Literal)}
+	return expression
+}
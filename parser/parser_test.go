@@ -550,6 +550,50 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a == b ? x + 1 : y * 2",
+			"((a == b) ? (x + 1) : (y * 2))",
+		},
+		{
+			"a || b ? 1 : 2",
+			"((a || b) ? 1 : 2)",
+		},
+		{
+			"a ? b : c ? d : e",
+			"(a ? b : (c ? d : e))",
+		},
+		{
+			"x = c ? 1 : 2",
+			"(x = (c ? 1 : 2))",
+		},
+		{
+			"x = a ? b : c ? d : e",
+			"(x = (a ? b : (c ? d : e)))",
+		},
+		{
+			"h[k] += a == b ? 1 + 2 : 3",
+			"((h[k]) + ((a == b) ? (1 + 2) : 3))",
+		},
+		{
+			"a = b = 1",
+			"(a = (b = 1))",
+		},
+		{
+			"a ? b ? c : d : e",
+			"(a ? (b ? c : d) : e)",
+		},
+		{
+			"add(a ? 1 : 2, b)",
+			"add((a ? 1 : 2), b)",
+		},
+		{
+			"a.b.c(1)[0] + -x.y",
+			"((a.b.c(1)[0]) + (-x.y))",
+		},
+		{
+			`"abc".upper().len()`,
+			"abc.upper().len()",
+		},
 	}
 
 	for _, tt := range tests {
@@ -770,6 +814,266 @@ func TestIfElseExpression(t *testing.T) {
 	}
 }
 
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < y) { x } else if (x > y) { y } else { z }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Body does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+	if exp.Alternative != nil {
+		t.Errorf("exp.Alternative should be nil with an else if. got=%+v", exp.Alternative)
+	}
+
+	elseIf := exp.ElseIf
+	if elseIf == nil {
+		t.Fatalf("exp.ElseIf is nil")
+	}
+	if !testInfixExpression(t, elseIf.Condition, "x", ">", "y") {
+		return
+	}
+	if elseIf.Alternative == nil || len(elseIf.Alternative.Statements) != 1 {
+		t.Fatalf("elseIf.Alternative does not contain 1 statement. got=%+v", elseIf.Alternative)
+	}
+	alternative, ok := elseIf.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T",
+			elseIf.Alternative.Statements[0])
+	}
+	testIdentifier(t, alternative.Expression, "z")
+}
+
+func TestConditionalExpression(t *testing.T) {
+	input := `x < y ? x : y`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Body does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.ConditionalExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.ConditionalExpression. got=%T", stmt.Expression)
+	}
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+	if !testIdentifier(t, exp.Consequence, "x") {
+		return
+	}
+	testIdentifier(t, exp.Alternative, "y")
+}
+
+func TestMemberExpression(t *testing.T) {
+	input := "config.name;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+	member, ok := stmt.Expression.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MemberExpression. got=%T", stmt.Expression)
+	}
+	if !testIdentifier(t, member.Object, "config") {
+		return
+	}
+	testIdentifier(t, member.Property, "name")
+}
+
+func TestMemberExpressionMissingProperty(t *testing.T) {
+	l := lexer.New("config.1")
+	p := New(l)
+	p.ParseProgram()
+
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+	if p.Diagnostics()[0].Code != "PAR001" {
+		t.Fatalf("expected PAR001, got %s", p.Diagnostics()[0].Code)
+	}
+}
+
+func TestTryExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		catchParameter string
+		hasCatch       bool
+		hasFinally     bool
+	}{
+		{"try { x } catch (e) { y }", "e", true, false},
+		{"try { x } catch { y }", "", true, false},
+		{"try { x } finally { y }", "", false, true},
+		{"try { x } catch (err) { y } finally { z }", "err", true, true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+				program.Statements[0])
+		}
+		exp, ok := stmt.Expression.(*ast.TryExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.TryExpression. got=%T", stmt.Expression)
+		}
+		if len(exp.Block.Statements) != 1 {
+			t.Errorf("try block does not contain 1 statement. got=%d", len(exp.Block.Statements))
+		}
+		if (exp.Catch != nil) != tt.hasCatch {
+			t.Errorf("%q: catch presence wrong. want=%t", tt.input, tt.hasCatch)
+		}
+		if (exp.Finally != nil) != tt.hasFinally {
+			t.Errorf("%q: finally presence wrong. want=%t", tt.input, tt.hasFinally)
+		}
+		if tt.catchParameter == "" {
+			if exp.CatchParameter != nil {
+				t.Errorf("%q: unexpected catch parameter %q", tt.input, exp.CatchParameter.Value)
+			}
+		} else if exp.CatchParameter == nil || exp.CatchParameter.Value != tt.catchParameter {
+			t.Errorf("%q: catch parameter wrong. want=%q, got=%+v", tt.input, tt.catchParameter, exp.CatchParameter)
+		}
+	}
+}
+
+func TestThrowStatement(t *testing.T) {
+	l := lexer.New(`throw "bad" + x;`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ThrowStatement. got=%T", program.Statements[0])
+	}
+	if stmt.String() != "throw (bad + x);" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+func TestImportExpression(t *testing.T) {
+	l := lexer.New(`let m = import "lib/math.monkey";`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.LetStatement)
+	imp, ok := stmt.Value.(*ast.ImportExpression)
+	if !ok {
+		t.Fatalf("stmt.Value is not ast.ImportExpression. got=%T", stmt.Value)
+	}
+	if imp.Path.Value != "lib/math.monkey" {
+		t.Errorf("imp.Path.Value wrong. got=%q", imp.Path.Value)
+	}
+
+	p = New(lexer.New("import math"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected an error for an import without a string path")
+	}
+}
+
+func TestExportStatement(t *testing.T) {
+	l := lexer.New(`export let square = fn(x) { x * x };`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExportStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExportStatement. got=%T", program.Statements[0])
+	}
+	if !testLetStatement(t, stmt.Statement, "square") {
+		return
+	}
+
+	p = New(lexer.New("export 5;"))
+	p.ParseProgram()
+	ds := p.Diagnostics()
+	if len(ds) == 0 || ds[0].Code != "PAR001" {
+		t.Errorf("expected PAR001 for export without let, got %+v", ds)
+	}
+}
+
+func TestTryWithoutCatchOrFinally(t *testing.T) {
+	l := lexer.New("try { x }")
+	p := New(l)
+	p.ParseProgram()
+
+	ds := p.Diagnostics()
+	if len(ds) == 0 || ds[0].Code != "PAR003" {
+		t.Fatalf("expected PAR003, got %+v", ds)
+	}
+}
+
+func TestConditionalStringRoundTrip(t *testing.T) {
+	tests := []string{
+		"if (x) { a } else if (y) { b } else if (z) { c } else { d }",
+		"if (x < y) { x }",
+		"let max = a > b ? a : b;",
+		"c ? [1, 2][0] : -a",
+		"try { a } catch (e) { b } finally { c }",
+		"try { a } catch { b }",
+		`let m = import "lib/math.monkey"; export let x = m.pi;`,
+	}
+
+	for _, input := range tests {
+		first := New(lexer.New(input))
+		program := first.ParseProgram()
+		checkParserErrors(t, first)
+
+		second := New(lexer.New(program.String()))
+		reparsed := second.ParseProgram()
+		checkParserErrors(t, second)
+
+		if reparsed.String() != program.String() {
+			t.Errorf("String() does not round-trip for %q. first=%q, second=%q",
+				input, program.String(), reparsed.String())
+		}
+	}
+}
+
 func testInfixExpression(t *testing.T, exp ast.Expression, left interface{},
 	operator string, right interface{}) bool {
 
@@ -970,6 +1274,81 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestStringLiteralEscapes(t *testing.T) {
+	input := `"a\tb\u{1F98D}\x21";`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	literal, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral. got=%T", program.Statements[0])
+	}
+	if literal.Value != "a\tb🦍!" {
+		t.Errorf("literal.Value wrong. got=%q", literal.Value)
+	}
+	if literal.TokenLiteral() != `a\tb\u{1F98D}\x21` {
+		t.Errorf("literal.TokenLiteral() should keep the escapes. got=%q", literal.TokenLiteral())
+	}
+}
+
+func TestInterpolatedString(t *testing.T) {
+	input := `"Hello ${name}, you are ${age + 1}!";`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	str, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString. got=%T", program.Statements[0])
+	}
+	if len(str.Parts) != 5 {
+		t.Fatalf("wrong number of parts. got=%d", len(str.Parts))
+	}
+	for i, text := range []string{"Hello ", ", you are ", "!"} {
+		testStringPart(t, str.Parts[2*i], text)
+	}
+	testIdentifier(t, str.Parts[1], "name")
+	testInfixExpression(t, str.Parts[3], "age", "+", 1)
+	if str.String() != "Hello ${name}, you are ${(age + 1)}!" {
+		t.Errorf("str.String() wrong. got=%q", str.String())
+	}
+
+	// Los rangos de las expresiones son los del código fuente.
+	r := ast.NodeRange(str.Parts[3])
+	if got := input[r.Start.Offset:r.End.Offset]; got != "age + 1" {
+		t.Errorf("wrong range for the interpolated expression. got=%q", got)
+	}
+	if r := ast.NodeRange(str); r.Start.Offset != 0 || r.End.Offset != len(input)-1 {
+		t.Errorf("wrong range for the string. got=[%d,%d)", r.Start.Offset, r.End.Offset)
+	}
+}
+
+func testStringPart(t *testing.T, exp ast.Expression, value string) {
+	t.Helper()
+	part, ok := exp.(*ast.StringLiteral)
+	if !ok || part.Value != value {
+		t.Errorf("part is not the text %q. got=%T (%v)", value, exp, exp)
+	}
+}
+
+func TestInterpolatedStringErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		code  string
+		start int
+	}{
+		{`"a ${} b";`, "PAR004", 3},
+		{`"a ${x y} b";`, "PAR001", 7},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		ds := p.Diagnostics()
+		if len(ds) == 0 || ds[0].Code != tt.code || ds[0].Range.Start.Offset != tt.start {
+			t.Errorf("%s: expected %s at offset %d, got %#v", tt.input, tt.code, tt.start, ds)
+		}
+	}
+}
+
 func TestNoPrefixFn(t *testing.T) {
 	input := "!;"
 	l := lexer.New(input)
@@ -1078,6 +1457,9 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		"two":   2,
 		"three": 3,
 	}
+	if len(hash.Keys) != 3 || hash.Keys[0].String() != "one" || hash.Keys[1].String() != "two" || hash.Keys[2].String() != "three" {
+		t.Errorf("hash.Keys not in source order. got=%v", hash.Keys)
+	}
 	for key, value := range hash.Pairs {
 		literal, ok := key.(*ast.StringLiteral)
 		if !ok {
@@ -1412,3 +1794,63 @@ func TestPercentOperator(t *testing.T) {
 		return
 	}
 }
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input string
+		codes []string
+		lines []int
+	}{
+		{"if (true {\n    print(1);\n}", []string{"PAR001"}, []int{1}},
+		{"let x = ;\nlet y = 1;\nlet = 2;", []string{"PAR002", "PAR001"}, []int{1, 3}},
+		{"let f = fn() {\n    let = 1;\n    a + ;\n    return a;\n};\nlet ok = 1;",
+			[]string{"PAR001", "PAR002"}, []int{2, 3}},
+		{"let a = [1, 2;\nprint(a)\n}\nlet b = 1 +;", []string{"PAR001", "PAR002", "PAR002"}, []int{1, 3, 4}},
+		{"if (x) { 1 } else { let = 2 }\nlet y = ;", []string{"PAR001", "PAR002"}, []int{1, 2}},
+		// El token inválido ya lo reporta el lexer.
+		{"let pi = 3..14;\nlet x = ;", []string{"PAR002"}, []int{2}},
+		// Un '&' o '|' suelto es ILLEGAL, pero el lexer no lo reporta.
+		{"let x = 1 | 2;\nprint(x);", []string{"PAR002"}, []int{1}},
+		{"let x = 1 & 2;\nprint(x);", []string{"PAR002"}, []int{1}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		diagnostics := p.Diagnostics()
+		if len(diagnostics) != len(tt.codes) {
+			t.Errorf("%q: wrong number of diagnostics. want=%d, got=%d (%v)",
+				tt.input, len(tt.codes), len(diagnostics), p.Errors())
+			continue
+		}
+		for i, d := range diagnostics {
+			if d.Code != tt.codes[i] || d.Range.Start.Line != tt.lines[i] {
+				t.Errorf("%q: diagnostic %d wrong. want=%s at line %d, got=%s at line %d",
+					tt.input, i, tt.codes[i], tt.lines[i], d.Code, d.Range.Start.Line)
+			}
+		}
+
+		errors := p.Errors()
+		for i, d := range diagnostics {
+			if errors[i] != d.Message {
+				t.Errorf("%q: Errors()[%d] = %q, want %q", tt.input, i, errors[i], d.Message)
+			}
+		}
+	}
+}
+
+func TestErrorRecoveryKeepsParsing(t *testing.T) {
+	p := New(lexer.New("let a = ;\nlet b = fn(x) { x * 2 };\nb(a);"))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 1 {
+		t.Fatalf("expected 1 error, got %v", p.Errors())
+	}
+	if len(program.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(program.Statements))
+	}
+	if program.Statements[1].String() != "let b = fn(x) (x * 2);" {
+		t.Errorf("statement after the error wrong. got=%q", program.Statements[1].String())
+	}
+}
@@ -2,15 +2,20 @@ package repl
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"go-rilla/ast"
+	"go-rilla/compiler"
+	"go-rilla/diag"
 	"go-rilla/evaluator"
 	"go-rilla/internal/diagprint"
 	"go-rilla/lexer"
 	"go-rilla/object"
 	"go-rilla/parser"
 	"go-rilla/token"
+	"go-rilla/vm"
 	"io"
+	"os"
 )
 
 type Mode string
@@ -21,6 +26,14 @@ const (
 	ModeScanner   Mode = "scanner"
 )
 
+// Engine selecciona el backend que ejecuta el programa en modo evaluator.
+type Engine string
+
+const (
+	EngineTree Engine = "tree" // evaluator.Eval recorriendo el AST
+	EngineVM   Engine = "vm"   // compilación a bytecode + máquina virtual
+)
+
 const PROMPT = ">> "
 const GORILLA_FACE = `
             __,__
@@ -37,14 +50,31 @@ const GORILLA_FACE = `
 `
 const defaultSourceName = "<repl>"
 
-func StartEvaluator(in io.Reader, out io.Writer) { startRepl(ModeEvaluator, in, out) }
-func StartParser(in io.Reader, out io.Writer)    { startRepl(ModeParser, in, out) }
-func StartScanner(in io.Reader, out io.Writer)   { startRepl(ModeScanner, in, out) }
+// diagnostics es el formato con el que se imprimen los diagnósticos.
+var diagnostics = diagprint.Plain
+
+// SetDiagnosticsFormat elige el formato de los diagnósticos del REPL y de
+// RunScript.
+func SetDiagnosticsFormat(f diagprint.Format) { diagnostics = f }
+
+// limits configura las ejecuciones del REPL y de RunScript, con cualquiera
+// de los dos backends.
+var limits evaluator.Limits
+
+// SetLimits elige los límites (y el modo de enteros estricto) del REPL y de
+// RunScript.
+func SetLimits(l evaluator.Limits) { limits = l }
 
-func RunScript(mode Mode, sourceName, source string, out io.Writer) {
+func StartEvaluator(in io.Reader, out io.Writer, engine Engine) {
+	startRepl(ModeEvaluator, engine, in, out)
+}
+func StartParser(in io.Reader, out io.Writer)  { startRepl(ModeParser, EngineTree, in, out) }
+func StartScanner(in io.Reader, out io.Writer) { startRepl(ModeScanner, EngineTree, in, out) }
+
+func RunScript(mode Mode, engine Engine, sourceName, source string, out io.Writer) {
 	var env *object.Environment
 	if mode == ModeEvaluator {
-		env = object.NewEnvironment()
+		env = newLoader(engine).NewEnvironment(sourceName)
 	}
 
 	switch mode {
@@ -53,17 +83,17 @@ func RunScript(mode Mode, sourceName, source string, out io.Writer) {
 	case ModeParser:
 		runParser(source, sourceName, out)
 	case ModeEvaluator:
-		runEvaluator(source, sourceName, out, env)
+		runEvaluator(source, sourceName, out, env, engine)
 	default:
-		runEvaluator(source, sourceName, out, env)
+		runEvaluator(source, sourceName, out, env, engine)
 	}
 }
 
-func startRepl(mode Mode, in io.Reader, out io.Writer) {
+func startRepl(mode Mode, engine Engine, in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 	var env *object.Environment
 	if mode == ModeEvaluator {
-		env = object.NewEnvironment()
+		env = newLoader(engine).NewEnvironment("")
 	}
 	for {
 		fmt.Print(PROMPT)
@@ -78,20 +108,20 @@ func startRepl(mode Mode, in io.Reader, out io.Writer) {
 			return
 		}
 
-		env = processLine(mode, line, defaultSourceName, out, env)
+		env = processLine(mode, engine, line, defaultSourceName, out, env)
 	}
 }
 
-func processLine(mode Mode, line, sourceName string, out io.Writer, env *object.Environment) *object.Environment {
+func processLine(mode Mode, engine Engine, line, sourceName string, out io.Writer, env *object.Environment) *object.Environment {
 	switch mode {
 	case ModeScanner:
 		runScanner(line, sourceName, out)
 	case ModeParser:
 		runParser(line, sourceName, out)
 	case ModeEvaluator:
-		runEvaluator(line, sourceName, out, env)
+		runEvaluator(line, sourceName, out, env, engine)
 	default:
-		runEvaluator(line, sourceName, out, env)
+		runEvaluator(line, sourceName, out, env, engine)
 	}
 	return env
 }
@@ -101,8 +131,8 @@ func StartProgram(line, sourceName string, out io.Writer) (*lexer.Lexer, *parser
 	p := parser.New(l)
 	program := p.ParseProgram()
 
-	if len(p.Errors()) != 0 {
-		printParserErrors(out, p.Errors())
+	if errors := syntaxErrors(l, p); len(errors) != 0 {
+		printParserErrors(out, errors)
 		writeDiagnostics(l, p, sourceName, line, out)
 		return nil, nil, nil
 	}
@@ -110,14 +140,28 @@ func StartProgram(line, sourceName string, out io.Writer) (*lexer.Lexer, *parser
 	return l, p, program
 }
 
-func runEvaluator(line, sourceName string, out io.Writer, env *object.Environment) {
+func runEvaluator(line, sourceName string, out io.Writer, env *object.Environment, engine Engine) {
 	l, p, program := StartProgram(line, sourceName, out)
 	if l == nil || p == nil || program == nil {
 		return
 	}
 
-	evaluated := evaluator.Eval(program, env)
-	if evaluated != nil {
+	var evaluated object.Object
+	if engine == EngineVM {
+		evaluated = runVM(program, env)
+	} else {
+		evaluated = evaluator.EvalContext(context.Background(), program, env, limits)
+	}
+	if errObj, ok := evaluated.(*object.Error); ok && errObj.HasRange() {
+		name, src := sourceName, line
+		if errObj.File != "" && errObj.File != sourceName {
+			// El error ocurrió dentro de un módulo importado.
+			if data, err := os.ReadFile(errObj.File); err == nil {
+				name, src = errObj.File, string(data)
+			}
+		}
+		io.WriteString(out, diagprint.Render(diagnostics, name, src, []diag.Diagnostic{errObj.Diagnostic()}))
+	} else if evaluated != nil {
 		io.WriteString(out, evaluated.Inspect())
 		io.WriteString(out, "\n")
 	}
@@ -125,6 +169,36 @@ func runEvaluator(line, sourceName string, out io.Writer, env *object.Environmen
 	writeDiagnostics(l, p, sourceName, line, out)
 }
 
+// newLoader crea el cargador de módulos del programa; con la VM los módulos
+// importados también se compilan.
+func newLoader(engine Engine) *evaluator.Loader {
+	loader := evaluator.NewLoader()
+	loader.Limits = limits
+	if engine == EngineVM {
+		loader.Exec = runVM
+	}
+	return loader
+}
+
+// runVM compila el programa y lo ejecuta en la VM sobre env. Los errores se
+// devuelven como *object.Error para imprimirse igual que con el evaluador.
+func runVM(program *ast.Program, env *object.Environment) object.Object {
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		return &object.Error{Message: err.Error()}
+	}
+
+	machine := vm.New(comp.Bytecode(), env)
+	machine.Limits = limits
+	if err := machine.Run(); err != nil {
+		if errObj, ok := err.(*object.Error); ok {
+			return errObj
+		}
+		return &object.Error{Message: err.Error()}
+	}
+	return machine.LastPoppedStackElem()
+}
+
 func runParser(line, sourceName string, out io.Writer) {
 	l, p, program := StartProgram(line, sourceName, out)
 	if l == nil || p == nil || program == nil {
@@ -149,11 +223,28 @@ func runScanner(line, sourceName string, out io.Writer) {
 	}
 
 	if ds := l.Diagnostics(); len(ds) > 0 {
-		io.WriteString(out, diagprint.RenderPlain(sourceName, line, ds))
+		io.WriteString(out, diagprint.Render(diagnostics, sourceName, line, ds))
+	}
+}
+
+// syntaxErrors devuelve los mensajes de los errores del lexer y del parser.
+// El parser no repite los errores de los tokens inválidos del lexer.
+func syntaxErrors(l *lexer.Lexer, p *parser.Parser) []string {
+	var errors []string
+	for _, d := range l.Diagnostics() {
+		if d.Level == diag.Error {
+			errors = append(errors, d.Message)
+		}
 	}
+	return append(errors, p.Errors()...)
 }
 
 func printParserErrors(out io.Writer, errors []string) {
+	if diagnostics.Machine() {
+		// Los diagnósticos que siguen ya contienen los mismos mensajes y
+		// la salida debe poder leerla otra herramienta.
+		return
+	}
 	io.WriteString(out, GORILLA_FACE)
 	io.WriteString(out, "Woops! We ran into some gorilla business here!\n")
 	io.WriteString(out, " parser errors:\n")
@@ -162,11 +253,10 @@ func printParserErrors(out io.Writer, errors []string) {
 	}
 }
 
+// writeDiagnostics imprime juntos los diagnósticos del lexer y del parser,
+// para que en SARIF formen un único log.
 func writeDiagnostics(l *lexer.Lexer, p *parser.Parser, sourceName, source string, out io.Writer) {
-	if ds := l.Diagnostics(); len(ds) > 0 {
-		io.WriteString(out, diagprint.RenderPlain(sourceName, source, ds))
-	}
-	if pds := p.Diagnostics(); len(pds) > 0 {
-		io.WriteString(out, diagprint.RenderPlain(sourceName, source, pds))
+	if ds := append(l.Diagnostics(), p.Diagnostics()...); len(ds) > 0 {
+		io.WriteString(out, diagprint.Render(diagnostics, sourceName, source, ds))
 	}
 }
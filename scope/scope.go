@@ -0,0 +1,282 @@
+// Package scope resuelve los identificadores de un programa sin ejecutarlo:
+// qué nombres declara cada let, parámetro o catch, y a cuál de ellos se
+// refiere cada uso. Lo usan las herramientas de edición y el linter.
+//
+// Como en el evaluador, sólo las funciones crean un ámbito nuevo; los
+// bloques de if, bucles y try declaran en el ámbito que los contiene.
+package scope
+
+import (
+	"go-rilla/ast"
+	"go-rilla/source"
+	"go-rilla/token"
+)
+
+// Kind es el tipo de declaración de un nombre.
+type Kind int
+
+const (
+	Let Kind = iota
+	Param
+	CatchParam
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Let:
+		return "let"
+	case Param:
+		return "parameter"
+	case CatchParam:
+		return "catch parameter"
+	default:
+		return "?"
+	}
+}
+
+// Definition es la declaración de un nombre.
+type Definition struct {
+	Name     string
+	Kind     Kind
+	Ident    *ast.Identifier // el nombre en la declaración
+	Node     ast.Node        // *ast.LetStatement, *ast.FunctionLiteral o *ast.TryExpression
+	Exported bool
+	Scope    *Scope
+	Uses     []*Use
+
+	// visible es el offset desde el que la definición es visible en su
+	// propio ámbito: el final del let, para que en "let x = x + 1" el x
+	// de la derecha se refiera al anterior.
+	visible int
+}
+
+// Use es una referencia a un nombre.
+type Use struct {
+	Ident  *ast.Identifier
+	Def    *Definition // nil si no hay una declaración visible
+	Assign bool        // el uso es el destino de "="; += y -= también leen el valor
+	Scope  *Scope
+}
+
+// Scope es el ámbito del programa o de una función.
+type Scope struct {
+	Parent      *Scope
+	Node        ast.Node // *ast.Program o *ast.FunctionLiteral
+	Range       source.Range
+	Definitions []*Definition
+	Children    []*Scope
+}
+
+// Info es el resultado de Resolve.
+type Info struct {
+	Root        *Scope
+	Definitions []*Definition // en el orden del código fuente
+	Uses        []*Use        // en el orden del código fuente
+}
+
+// Resolve analiza program. Tolera los nodos ausentes que deja el parser al
+// recuperarse de errores de sintaxis.
+func Resolve(program *ast.Program) *Info {
+	r := &resolver{info: &Info{}}
+	r.info.Root = &Scope{Node: program, Range: wholeFile}
+	r.scope = r.info.Root
+	r.walk(program)
+
+	// Los usos se resuelven al final: dentro de una función son visibles
+	// los nombres que el ámbito exterior declara después de ella.
+	for _, use := range r.info.Uses {
+		use.Def = use.Scope.lookup(use.Ident.Value, use.Ident.Token.Range.Start.Offset)
+		if use.Def != nil {
+			use.Def.Uses = append(use.Def.Uses, use)
+		}
+	}
+	return r.info
+}
+
+var wholeFile = source.Range{End: source.Position{Offset: int(^uint(0) >> 1)}}
+
+type resolver struct {
+	info  *Info
+	scope *Scope
+}
+
+func (r *resolver) define(kind Kind, ident *ast.Identifier, node ast.Node, visible int) *Definition {
+	def := &Definition{
+		Name:    ident.Value,
+		Kind:    kind,
+		Ident:   ident,
+		Node:    node,
+		Scope:   r.scope,
+		visible: visible,
+	}
+	r.scope.Definitions = append(r.scope.Definitions, def)
+	r.info.Definitions = append(r.info.Definitions, def)
+	return def
+}
+
+func (r *resolver) use(ident *ast.Identifier, assign bool) {
+	r.info.Uses = append(r.info.Uses, &Use{Ident: ident, Assign: assign, Scope: r.scope})
+}
+
+func (r *resolver) walk(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ExportStatement:
+			if n.Statement != nil {
+				r.let(n.Statement).Exported = true
+			}
+			return false
+		case *ast.LetStatement:
+			r.let(n)
+			return false
+		case *ast.Identifier:
+			r.use(n, false)
+		case *ast.InfixExpression:
+			if ident, ok := n.Left.(*ast.Identifier); ok && n.Token.Type == token.ASSIGN {
+				r.use(ident, true)
+				r.walk(n.Right)
+				return false
+			}
+		case *ast.MemberExpression:
+			// La propiedad es un nombre, no una variable.
+			r.walk(n.Object)
+			return false
+		case *ast.FunctionLiteral:
+			r.function(n)
+			return false
+		case *ast.TryExpression:
+			r.walk(n.Block)
+			if n.CatchParameter != nil && n.Catch != nil {
+				r.define(CatchParam, n.CatchParameter, n, n.Catch.Token.Range.Start.Offset)
+			}
+			r.walk(n.Catch)
+			r.walk(n.Finally)
+			return false
+		}
+		return true
+	})
+}
+
+func (r *resolver) let(stmt *ast.LetStatement) *Definition {
+	r.walk(stmt.Value)
+	if stmt.Name == nil {
+		return &Definition{}
+	}
+	return r.define(Let, stmt.Name, stmt, ast.NodeRange(stmt).End.Offset)
+}
+
+func (r *resolver) function(fn *ast.FunctionLiteral) {
+	outer := r.scope
+	r.scope = &Scope{Parent: outer, Node: fn, Range: ast.NodeRange(fn)}
+	outer.Children = append(outer.Children, r.scope)
+
+	start := r.scope.Range.Start.Offset
+	for _, param := range fn.Parameters {
+		r.define(Param, param, fn, start)
+	}
+	r.walk(fn.Body)
+	r.scope = outer
+}
+
+// lookup busca name desde offset hacia los ámbitos exteriores. En el propio
+// ámbito sólo cuentan las definiciones anteriores a offset; en los
+// exteriores, que ya terminaron de ejecutarse cuando se llama a la función,
+// también las posteriores.
+func (s *Scope) lookup(name string, offset int) *Definition {
+	for scope, inner := s, true; scope != nil; scope, inner = scope.Parent, false {
+		var found *Definition
+		for _, def := range scope.Definitions {
+			if def.Name != name {
+				continue
+			}
+			if def.visible <= offset || (!inner && found == nil) {
+				found = def
+			}
+		}
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Lookup devuelve la definición a la que se referiría name en offset.
+func (s *Scope) Lookup(name string, offset int) *Definition {
+	return s.lookup(name, offset)
+}
+
+// Visible devuelve las definiciones visibles en offset, una por nombre: la
+// del ámbito más interno y, dentro de él, la más reciente.
+func (s *Scope) Visible(offset int) []*Definition {
+	var visible []*Definition
+	seen := map[string]bool{}
+	for scope := s; scope != nil; scope = scope.Parent {
+		for i := len(scope.Definitions) - 1; i >= 0; i-- {
+			def := scope.Definitions[i]
+			if seen[def.Name] || (scope == s && def.visible > offset) {
+				continue
+			}
+			seen[def.Name] = true
+			visible = append(visible, def)
+		}
+	}
+	return visible
+}
+
+// ScopeAt devuelve el ámbito más interno que contiene offset.
+func (info *Info) ScopeAt(offset int) *Scope {
+	scope := info.Root
+	for {
+		next := scope.childAt(offset)
+		if next == nil {
+			return scope
+		}
+		scope = next
+	}
+}
+
+func (s *Scope) childAt(offset int) *Scope {
+	for _, child := range s.Children {
+		if child.Range.Start.Offset <= offset && offset <= child.Range.End.Offset {
+			return child
+		}
+	}
+	return nil
+}
+
+// DefinitionAt devuelve la definición cuyo nombre (en la declaración o en
+// un uso) contiene offset.
+func (info *Info) DefinitionAt(offset int) *Definition {
+	for _, def := range info.Definitions {
+		if contains(def.Ident.Token.Range, offset) {
+			return def
+		}
+	}
+	for _, use := range info.Uses {
+		if contains(use.Ident.Token.Range, offset) {
+			return use.Def
+		}
+	}
+	return nil
+}
+
+// IdentAt devuelve el identificador, declarado o usado, que contiene offset.
+func (info *Info) IdentAt(offset int) *ast.Identifier {
+	for _, def := range info.Definitions {
+		if contains(def.Ident.Token.Range, offset) {
+			return def.Ident
+		}
+	}
+	for _, use := range info.Uses {
+		if contains(use.Ident.Token.Range, offset) {
+			return use.Ident
+		}
+	}
+	return nil
+}
+
+// contains incluye el final del rango, para que el cursor justo después de
+// un nombre también lo señale.
+func contains(r source.Range, offset int) bool {
+	return r.Start.Offset <= offset && offset <= r.End.Offset
+}
@@ -0,0 +1,99 @@
+package scope
+
+import (
+	"go-rilla/lexer"
+	"go-rilla/parser"
+	"strings"
+	"testing"
+)
+
+func resolve(t *testing.T, input string) *Info {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return Resolve(program)
+}
+
+// defLine devuelve la línea donde se declara la definición del uso número n
+// (desde 0) de name, o 0 si no se resolvió.
+func defLine(info *Info, name string, n int) int {
+	for _, use := range info.Uses {
+		if use.Ident.Value != name {
+			continue
+		}
+		if n > 0 {
+			n--
+			continue
+		}
+		if use.Def == nil {
+			return 0
+		}
+		return use.Def.Ident.Token.Range.Start.Line
+	}
+	return -1
+}
+
+func TestResolve(t *testing.T) {
+	input := strings.Join([]string{
+		"let x = 1;",                     // 1
+		"let f = fn(x) {",                // 2
+		"    let y = x + z;",             // 3
+		"    f(y);",                      // 4
+		"};",                             // 5
+		"let z = 2;",                     // 6
+		"let x = x + 1;",                 // 7
+		"try { x } catch (e) { e; w; };", // 8
+		"print(x);",                      // 9
+	}, "\n")
+	info := resolve(t, input)
+
+	tests := []struct {
+		name string
+		n    int
+		line int
+	}{
+		{"x", 0, 2}, // el parámetro oculta al let
+		{"z", 0, 6}, // declarado después de la función
+		{"f", 0, 2}, // recursión
+		{"y", 0, 3},
+		{"x", 1, 1}, // let x = x + 1 usa el x anterior
+		{"x", 2, 7},
+		{"e", 0, 8},
+		{"w", 0, 0},
+		{"print", 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := defLine(info, tt.name, tt.n); got != tt.line {
+			t.Errorf("use %d of %s resolved to line %d, want %d", tt.n, tt.name, got, tt.line)
+		}
+	}
+}
+
+func TestResolveUndefinedBeforeDeclaration(t *testing.T) {
+	info := resolve(t, "print(a);\nlet a = 1;\na = 2;")
+	if got := defLine(info, "a", 0); got != 0 {
+		t.Errorf("use before the declaration resolved to line %d", got)
+	}
+	use := info.Uses[len(info.Uses)-1]
+	if !use.Assign || use.Def == nil {
+		t.Errorf("assignment not resolved as an assigning use: %+v", use)
+	}
+}
+
+func TestVisible(t *testing.T) {
+	input := "let a = 1;\nlet f = fn(b) {\n    let c = 2;\n    \n};\nlet d = 3;"
+	info := resolve(t, input)
+
+	offset := strings.Index(input, "    \n") + 2
+	var names []string
+	for _, def := range info.ScopeAt(offset).Visible(offset) {
+		names = append(names, def.Name)
+	}
+	if got := strings.Join(names, ","); got != "c,b,d,f,a" {
+		t.Errorf("visible names wrong. got=%q", got)
+	}
+}
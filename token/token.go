@@ -8,6 +8,17 @@ type Token struct {
 	Type    TokenType
 	Literal string
 	Range   source.Range
+
+	// Comentarios que preceden al token (trivia). No afectan al parseo, pero
+	// se conservan para herramientas que necesiten reproducir el código.
+	Comments []Comment
+}
+
+// Comment es un comentario de línea (// ...) o de bloque (/* ... */), con
+// sus delimitadores incluidos en Text.
+type Comment struct {
+	Text  string
+	Range source.Range
 }
 
 const (
@@ -20,6 +31,13 @@ const (
 	STRING     = "STRING"
 	FLOAT      = "FLOAT"
 
+	// Partes de un string con interpolaciones: "a ${x} b ${y} c" se divide
+	// en STRING_HEAD ("a ${), las expresiones, STRING_MIDDLE (} b ${) y
+	// STRING_TAIL (} c"). El Literal de cada parte es sólo su texto.
+	STRING_HEAD   = "STRING_HEAD"
+	STRING_MIDDLE = "STRING_MIDDLE"
+	STRING_TAIL   = "STRING_TAIL"
+
 	// Operadores
 	ASSIGN       = "="
 	PLUS         = "+"
@@ -48,6 +66,7 @@ const (
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	QUESTION  = "?"
 	DOT       = "."
 
 	LEFT_PARENTHESIS  = "("
@@ -73,6 +92,16 @@ const (
 	WHILE    = "WHILE"
 	BREAK    = "BREAK"
 	CONTINUE = "CONTINUE"
+
+	// Manejo de errores
+	TRY     = "TRY"
+	CATCH   = "CATCH"
+	FINALLY = "FINALLY"
+	THROW   = "THROW"
+
+	// Módulos
+	IMPORT = "IMPORT"
+	EXPORT = "EXPORT"
 )
 
 var keywords = map[string]TokenType{
@@ -87,6 +116,12 @@ var keywords = map[string]TokenType{
 	"while":    WHILE,
 	"break":    BREAK,
 	"continue": CONTINUE,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"throw":    THROW,
+	"import":   IMPORT,
+	"export":   EXPORT,
 }
 
 func LookupIdentifier(identifier string) TokenType {
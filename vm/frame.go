@@ -0,0 +1,36 @@
+package vm
+
+import (
+	"go-rilla/ast"
+	"go-rilla/code"
+	"go-rilla/object"
+)
+
+// Frame representa la ejecución de una función: su código, el puntero de
+// instrucción, la base de su porción de la pila y sus variables locales.
+// Las locales viven en la pila, justo sobre la base, salvo que un closure
+// creado en la llamada pueda leerlas: entonces viven en scope.
+type Frame struct {
+	cl          *object.Closure
+	ip          int
+	basePointer int
+	locals      []object.Object
+	scope       *object.Scope
+}
+
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, ip: -1, basePointer: basePointer}
+}
+
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
+
+func (f *Frame) Constants() []object.Object {
+	return f.cl.Fn.Constants
+}
+
+// node devuelve el nodo del AST que generó la instrucción en curso.
+func (f *Frame) node() ast.Node {
+	return f.cl.Fn.Positions.NodeAt(f.ip)
+}
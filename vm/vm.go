@@ -0,0 +1,700 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"go-rilla/ast"
+	"go-rilla/code"
+	"go-rilla/compiler"
+	"go-rilla/evaluator"
+	"go-rilla/object"
+	"strings"
+)
+
+// La pila y la lista de frames empiezan chicas y crecen a medida que hace
+// falta, hasta MaxStackSize y MaxFrames: más allá, como una recursión sin
+// fin, la ejecución termina con "stack overflow". Limits.MaxCallDepth
+// acota la profundidad antes, con el mismo error que el evaluador.
+const (
+	StackSize    = 2048
+	MaxStackSize = 1 << 24
+	MaxFrames    = 1 << 20
+)
+
+var (
+	NULL  = evaluator.NULL
+	TRUE  = evaluator.TRUE
+	FALSE = evaluator.FALSE
+)
+
+// VM ejecuta el bytecode producido por el compilador. Las variables llegan
+// resueltas a índices: las globales a los object.Binding del entorno y las
+// locales a la pila. Los operadores delegan en el evaluador, de modo que
+// ambos backends se comportan igual.
+type VM struct {
+	// Context y Limits acotan la ejecución igual que en
+	// evaluator.EvalContext. Context puede ser nil.
+	Context context.Context
+	Limits  evaluator.Limits
+	steps   int // instrucciones ejecutadas, si hay que contarlas
+
+	stack []object.Object
+	sp    int // apunta al siguiente espacio libre; el tope es stack[sp-1]
+
+	frames      []*Frame
+	framesIndex int
+
+	handlers []handler // try abiertos, el más reciente al final
+
+	// valor de la última sentencia de nivel superior, igual al resultado
+	// que devolvería evaluator.Eval para el mismo programa.
+	result object.Object
+}
+
+// handler es un try abierto: a dónde saltar si ocurre un error y el estado
+// de la pila y los frames que se restaura antes de hacerlo.
+type handler struct {
+	catchIP     int
+	framesIndex int
+	sp          int
+}
+
+// rethrow envuelve un error que ya fue ubicado y debe propagarse sin
+// cambios (p. ej. al terminar un finally).
+type rethrow struct{ err *object.Error }
+
+func (r rethrow) Error() string { return r.err.Message }
+
+// New prepara la ejecución de bytecode sobre env: sus variables globales
+// son las de env.
+func New(bytecode *compiler.Bytecode, env *object.Environment) *VM {
+	mainFn := &object.CompiledFunction{
+		Instructions: bytecode.Instructions,
+		Positions:    bytecode.Positions,
+		Constants:    bytecode.Constants,
+		Globals:      bytecode.Globals,
+	}
+	globals := make([]*object.Binding, len(bytecode.Globals))
+	for i, name := range bytecode.Globals {
+		globals[i] = env.Binding(name)
+	}
+	mainClosure := &object.Closure{Fn: mainFn, Env: env, Globals: globals}
+	mainFrame := NewFrame(mainClosure, 0)
+	stack := make([]object.Object, max(StackSize, len(bytecode.Locals)))
+	sp := 0
+	// Las locales del nivel superior son los parámetros de los catch.
+	if bytecode.Captured {
+		mainFrame.locals = make([]object.Object, len(bytecode.Locals))
+		mainFrame.scope = &object.Scope{Values: mainFrame.locals, Names: bytecode.Locals}
+	} else {
+		sp = len(bytecode.Locals)
+		mainFrame.locals = stack[:sp:sp]
+	}
+
+	return &VM{
+		stack:       stack,
+		sp:          sp,
+		frames:      []*Frame{mainFrame},
+		framesIndex: 1,
+	}
+}
+
+// LastPoppedStackElem devuelve el resultado del programa ejecutado (nil si
+// la última sentencia no produce valor, p. ej. un let).
+func (vm *VM) LastPoppedStackElem() object.Object {
+	return vm.result
+}
+
+func (vm *VM) currentFrame() *Frame { return vm.frames[vm.framesIndex-1] }
+
+// pushFrame abre un frame para ejecutar cl. Los Frame se reutilizan entre
+// llamadas, porque nada los referencia después de cerrarse.
+func (vm *VM) pushFrame(cl *object.Closure, basePointer int) (*Frame, error) {
+	if vm.framesIndex == len(vm.frames) {
+		if vm.framesIndex >= MaxFrames {
+			return nil, newError("stack overflow")
+		}
+		vm.frames = append(vm.frames, nil)
+	}
+	frame := vm.frames[vm.framesIndex]
+	if frame == nil {
+		frame = &Frame{}
+		vm.frames[vm.framesIndex] = frame
+	}
+	*frame = Frame{cl: cl, ip: -1, basePointer: basePointer}
+	vm.framesIndex++
+	return frame, nil
+}
+
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	return vm.frames[vm.framesIndex]
+}
+
+// Run ejecuta el programa. Los errores en tiempo de ejecución se devuelven
+// como *object.Error con el mismo mensaje, rango y pila de llamadas que
+// produciría el evaluador.
+//
+// Si hay un try abierto, el error se captura: se restauran la pila y los
+// frames del try y la ejecución sigue en su catch con el error en la pila.
+// Los errores de límite (ver Context y Limits) no se capturan.
+func (vm *VM) Run() error {
+	limited := vm.Context != nil || vm.Limits.MaxSteps > 0
+	for {
+		var located *object.Error
+		switch err := vm.run(limited).(type) {
+		case *object.Error:
+			located = vm.locateError(err)
+		case rethrow:
+			located = err.err
+		default:
+			return err
+		}
+
+		if len(vm.handlers) == 0 || located.Kind == object.LIMIT_ERROR {
+			return located
+		}
+		h := vm.handlers[len(vm.handlers)-1]
+		vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		vm.framesIndex = h.framesIndex
+		vm.sp = h.sp
+		vm.currentFrame().ip = h.catchIP - 1
+		if err := vm.push(&object.ErrorValue{Err: located}); err != nil {
+			return err
+		}
+	}
+}
+
+// locateError devuelve una copia de errObj ubicada en la instrucción que
+// falló y con un frame de pila por cada llamada a función en curso. Se copia
+// porque los errores de OpError son constantes compartidas.
+func (vm *VM) locateError(errObj *object.Error) *object.Error {
+	located := &object.Error{
+		Message: errObj.Message,
+		Kind:    errObj.Kind,
+		Code:    errObj.Code,
+		Value:   errObj.Value,
+		Range:   errObj.Range,
+		File:    errObj.File,
+		Stack:   append([]object.StackFrame(nil), errObj.Stack...),
+	}
+	if !located.HasRange() {
+		if node := vm.currentFrame().node(); node != nil {
+			located.Range = ast.NodeRange(node)
+			located.File = modulePath(vm.currentFrame().cl.Env)
+		}
+	}
+	for i := vm.framesIndex - 1; i > 0; i-- {
+		call, ok := vm.frames[i-1].node().(*ast.CallExpression)
+		if !ok {
+			continue
+		}
+		located.Stack = append(located.Stack, object.StackFrame{
+			Function: evaluator.CalleeName(call.Function),
+			Range:    ast.NodeRange(call),
+			File:     modulePath(vm.frames[i-1].cl.Env),
+		})
+	}
+	return located
+}
+
+// run ejecuta instrucciones hasta terminar o hasta el primer error. Con
+// limited cuenta cada instrucción como un paso de Limits.MaxSteps.
+func (vm *VM) run(limited bool) error {
+	var ip int
+	var op code.Opcode
+
+	frame := vm.currentFrame()
+	ins := frame.Instructions()
+	constants := frame.Constants()
+
+	for frame.ip < len(ins)-1 {
+		if limited {
+			vm.steps++
+			if err := vm.Limits.CheckSteps(vm.Context, vm.steps); err != nil {
+				return err
+			}
+		}
+		frame.ip++
+
+		ip = frame.ip
+		op = code.Opcode(ins[ip])
+
+		switch op {
+		case code.OpConstant:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			if err := vm.push(constants[constIndex]); err != nil {
+				return err
+			}
+
+		case code.OpPop:
+			vm.result = vm.pop()
+
+		case code.OpDup:
+			if err := vm.push(vm.stack[vm.sp-1]); err != nil {
+				return err
+			}
+
+		case code.OpDupPair:
+			if err := vm.push(vm.stack[vm.sp-2]); err != nil {
+				return err
+			}
+			if err := vm.push(vm.stack[vm.sp-2]); err != nil {
+				return err
+			}
+
+		case code.OpNull:
+			if err := vm.push(NULL); err != nil {
+				return err
+			}
+
+		case code.OpTrue:
+			if err := vm.push(TRUE); err != nil {
+				return err
+			}
+
+		case code.OpFalse:
+			if err := vm.push(FALSE); err != nil {
+				return err
+			}
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod, code.OpPow,
+			code.OpEqual, code.OpNotEqual, code.OpLessThan, code.OpGreaterThan,
+			code.OpLessEqual, code.OpGreaterEqual,
+			code.OpAddAssign, code.OpSubAssign:
+			if err := vm.executeInfixOperation(op); err != nil {
+				return err
+			}
+
+		case code.OpMinus, code.OpBang:
+			operator := "-"
+			if op == code.OpBang {
+				operator = "!"
+			}
+			right := vm.pop()
+			if err := vm.pushResult(evaluator.PrefixOperation(operator, right, vm.Limits.StrictIntegers)); err != nil {
+				return err
+			}
+
+		case code.OpIncrement, code.OpDecrement:
+			operator := "++"
+			if op == code.OpDecrement {
+				operator = "--"
+			}
+			current := vm.pop()
+			if err := vm.pushResult(evaluator.UpdateOperation(operator, current, vm.Limits.StrictIntegers)); err != nil {
+				return err
+			}
+
+		case code.OpJump:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip = pos - 1
+
+		case code.OpJumpNotTruthy:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			condition := vm.pop()
+			if !evaluator.IsTruthy(condition) {
+				frame.ip = pos - 1
+			}
+
+		case code.OpGetGlobal:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			val := frame.cl.Globals[index].Value
+			if val == nil {
+				var err error
+				if val, err = vm.lookup(frame, frame.cl.Fn.Globals[index]); err != nil {
+					return err
+				}
+			}
+			if err := vm.push(val); err != nil {
+				return err
+			}
+
+		case code.OpGetLocal:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			val := frame.locals[index]
+			if val == nil {
+				var err error
+				if val, err = vm.lookup(frame, frame.cl.Fn.Locals[index]); err != nil {
+					return err
+				}
+			}
+			if err := vm.push(val); err != nil {
+				return err
+			}
+
+		case code.OpGetOuter:
+			depth := code.ReadUint8(ins[ip+1:])
+			index := code.ReadUint16(ins[ip+2:])
+			frame.ip += 3
+			scope := frame.cl.Scope
+			for ; depth > 1; depth-- {
+				scope = scope.Outer
+			}
+			val := scope.Values[index]
+			if val == nil {
+				var err error
+				if val, err = vm.lookup(frame, scope.Names[index]); err != nil {
+					return err
+				}
+			}
+			if err := vm.push(val); err != nil {
+				return err
+			}
+
+		case code.OpSetGlobal:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			frame.cl.Globals[index].Value = vm.pop()
+			// Como en el evaluador, un let no produce valor.
+			vm.result = nil
+
+		case code.OpSetLocal:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			frame.locals[index] = vm.pop()
+			vm.result = nil
+
+		case code.OpArray:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			elements := make([]object.Object, numElements)
+			copy(elements, vm.stack[vm.sp-numElements:vm.sp])
+			vm.sp = vm.sp - numElements
+			if err := vm.push(&object.Array{Elements: elements}); err != nil {
+				return err
+			}
+
+		case code.OpInterpolate:
+			numParts := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			var out strings.Builder
+			for _, part := range vm.stack[vm.sp-numParts : vm.sp] {
+				out.WriteString(part.Inspect())
+			}
+			vm.sp = vm.sp - numParts
+			if err := vm.push(&object.String{Value: out.String()}); err != nil {
+				return err
+			}
+
+		case code.OpHash:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			pairs := make([]object.HashPair, 0, numElements/2)
+			for i := vm.sp - numElements; i < vm.sp; i += 2 {
+				pairs = append(pairs, object.HashPair{Key: vm.stack[i], Value: vm.stack[i+1]})
+			}
+			vm.sp = vm.sp - numElements
+			if err := vm.pushResult(evaluator.NewHash(pairs)); err != nil {
+				return err
+			}
+
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+			if err := vm.pushResult(evaluator.IndexOperation(left, index)); err != nil {
+				return err
+			}
+
+		case code.OpMember:
+			nameIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			name := constants[nameIndex].(*object.String).Value
+			receiver := vm.pop()
+			if err := vm.pushResult(evaluator.MemberOperation(receiver, name)); err != nil {
+				return err
+			}
+
+		case code.OpIndexTarget:
+			if err := evaluator.CheckIndexAssignment(vm.stack[vm.sp-2], vm.stack[vm.sp-1]); err != nil {
+				return err.(*object.Error)
+			}
+
+		case code.OpSetIndex:
+			value := vm.pop()
+			index := vm.pop()
+			collection := vm.pop()
+			evaluator.SetIndexOperation(collection, index, value)
+			if err := vm.push(value); err != nil {
+				return err
+			}
+
+		case code.OpIncrementIndex, code.OpDecrementIndex:
+			keepPrevious := code.ReadUint8(ins[ip+1:]) == 1
+			frame.ip += 1
+			operator := "++"
+			if op == code.OpDecrementIndex {
+				operator = "--"
+			}
+			index := vm.pop()
+			collection := vm.pop()
+			previous, updated := evaluator.IndexUpdateOperation(operator, collection, index, vm.Limits.StrictIntegers)
+			result := updated
+			if keepPrevious {
+				result = previous
+			}
+			if err := vm.pushResult(result); err != nil {
+				return err
+			}
+
+		case code.OpClosure:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			fn := constants[constIndex].(*object.CompiledFunction)
+			closure := &object.Closure{Fn: fn, Env: frame.cl.Env, Globals: frame.cl.Globals, Scope: frame.scope}
+			if err := vm.push(closure); err != nil {
+				return err
+			}
+
+		case code.OpCall:
+			numArgs := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+			if err := vm.executeCall(int(numArgs)); err != nil {
+				return err
+			}
+			frame = vm.currentFrame()
+			ins = frame.Instructions()
+			constants = frame.Constants()
+
+		case code.OpReturnValue:
+			returnValue := vm.pop()
+			if vm.framesIndex == 1 {
+				vm.result = returnValue
+				return nil
+			}
+			vm.sp = vm.popFrame().basePointer - 1
+			if err := vm.push(returnValue); err != nil {
+				return err
+			}
+			frame = vm.currentFrame()
+			ins = frame.Instructions()
+			constants = frame.Constants()
+
+		case code.OpReturn:
+			vm.sp = vm.popFrame().basePointer - 1
+			if err := vm.push(NULL); err != nil {
+				return err
+			}
+			frame = vm.currentFrame()
+			ins = frame.Instructions()
+			constants = frame.Constants()
+
+		case code.OpNoValue:
+			if err := vm.push(nil); err != nil {
+				return err
+			}
+
+		case code.OpLoopValue:
+			value := vm.pop()
+			vm.stack[vm.sp-1] = value
+
+		case code.OpError:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			return constants[constIndex].(*object.Error)
+
+		case code.OpTry:
+			catchIP := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			vm.handlers = append(vm.handlers, handler{catchIP: catchIP, framesIndex: vm.framesIndex, sp: vm.sp})
+
+		case code.OpEndTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+		case code.OpImport:
+			pathIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			path := constants[pathIndex].(*object.String).Value
+			if err := vm.pushResult(evaluator.ImportOperation(frame.cl.Env, path)); err != nil {
+				return err
+			}
+
+		case code.OpExport:
+			nameIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			name := constants[nameIndex].(*object.String).Value
+			// Una función no tiene un entorno propio que ExportOperation
+			// pueda rechazar: frame.cl.Env es siempre el global.
+			if vm.framesIndex > 1 && frame.cl.Env.Module() != nil {
+				return newError("export is only allowed at the top level of a module")
+			}
+			if err := evaluator.ExportOperation(frame.cl.Env, name); err != nil {
+				return err.(*object.Error)
+			}
+
+		case code.OpThrow:
+			return evaluator.ThrowOperation(vm.pop())
+
+		case code.OpRethrow:
+			return rethrow{err: vm.pop().(*object.ErrorValue).Err}
+		}
+	}
+	return nil
+}
+
+// infixOperators indexa por opcode el operador y su forma escrita.
+var infixOperators = [...]struct{ operator, display string }{
+	code.OpAdd:          {"+", "+"},
+	code.OpSub:          {"-", "-"},
+	code.OpMul:          {"*", "*"},
+	code.OpDiv:          {"/", "/"},
+	code.OpMod:          {"%", "%"},
+	code.OpPow:          {"**", "**"},
+	code.OpEqual:        {"==", "=="},
+	code.OpNotEqual:     {"!=", "!="},
+	code.OpLessThan:     {"<", "<"},
+	code.OpGreaterThan:  {">", ">"},
+	code.OpLessEqual:    {"<=", "<="},
+	code.OpGreaterEqual: {">=", ">="},
+	code.OpAddAssign:    {"+", "+="},
+	code.OpSubAssign:    {"-", "-="},
+}
+
+func (vm *VM) executeInfixOperation(op code.Opcode) error {
+	right := vm.pop()
+	left := vm.pop()
+	operator := infixOperators[op]
+	return vm.pushResult(evaluator.InfixOperation(operator.operator, left, right, operator.display, vm.Limits.StrictIntegers))
+}
+
+func (vm *VM) executeCall(numArgs int) error {
+	callee := vm.stack[vm.sp-1-numArgs]
+	switch callee := callee.(type) {
+	case *object.Closure:
+		return vm.callClosure(callee, numArgs)
+	case *object.Builtin:
+		args := make([]object.Object, numArgs)
+		copy(args, vm.stack[vm.sp-numArgs:vm.sp])
+		vm.sp = vm.sp - numArgs - 1
+		return vm.pushResult(callee.Fn(args...))
+	default:
+		return newError("not a function: %s", callee.Type())
+	}
+}
+
+// callClosure abre el frame de una llamada a cl. Los argumentos ya están en
+// la pila y son las primeras locales; las demás empiezan sin valor, aun si
+// sobran argumentos.
+func (vm *VM) callClosure(cl *object.Closure, numArgs int) error {
+	fn := cl.Fn
+	if numArgs < len(fn.Parameters) {
+		return newError("wrong number of arguments: want=%d, got=%d",
+			len(fn.Parameters), numArgs)
+	}
+	if err := vm.Limits.CheckCallDepth(vm.framesIndex); err != nil {
+		return err
+	}
+
+	basePointer := vm.sp - numArgs
+	top := basePointer + len(fn.Locals)
+	if !fn.Captured && top > len(vm.stack) {
+		if err := vm.growStack(top); err != nil {
+			return err
+		}
+	}
+	frame, err := vm.pushFrame(cl, basePointer)
+	if err != nil {
+		return err
+	}
+
+	if fn.Captured {
+		frame.locals = make([]object.Object, len(fn.Locals))
+		copy(frame.locals, vm.stack[basePointer:basePointer+len(fn.Parameters)])
+		frame.scope = &object.Scope{Values: frame.locals, Names: fn.Locals, Outer: cl.Scope}
+		vm.sp = basePointer
+	} else {
+		frame.locals = vm.stack[basePointer:top:top]
+		clear(frame.locals[len(fn.Parameters):])
+		vm.sp = top
+	}
+	return nil
+}
+
+// lookup busca por nombre una variable que se resolvió al compilar pero que
+// todavía no tiene valor: como en el evaluador, se usa la de afuera con ese
+// nombre, o el Built-In.
+func (vm *VM) lookup(frame *Frame, name string) (object.Object, error) {
+	for i, local := range frame.cl.Fn.Locals {
+		if local == name && frame.locals[i] != nil {
+			return frame.locals[i], nil
+		}
+	}
+	for scope := frame.cl.Scope; scope != nil; scope = scope.Outer {
+		for i, local := range scope.Names {
+			if local == name && scope.Values[i] != nil {
+				return scope.Values[i], nil
+			}
+		}
+	}
+	if val, ok := frame.cl.Env.Get(name); ok {
+		return val, nil
+	}
+	if builtin, ok := evaluator.LookupBuiltin(name); ok {
+		return builtin, nil
+	}
+	return nil, newError("identifier not found: %s", name)
+}
+
+// growStack agranda la pila para que tenga al menos size elementos. Las
+// locales de los frames que viven en la pila pasan a apuntar a la nueva.
+func (vm *VM) growStack(size int) error {
+	if size > MaxStackSize {
+		return newError("stack overflow")
+	}
+	stack := make([]object.Object, min(max(size, 2*len(vm.stack)), MaxStackSize))
+	copy(stack, vm.stack)
+	for _, frame := range vm.frames[:vm.framesIndex] {
+		if frame.scope == nil {
+			start, end := frame.basePointer, frame.basePointer+len(frame.locals)
+			frame.locals = stack[start:end:end]
+		}
+	}
+	vm.stack = stack
+	return nil
+}
+
+func (vm *VM) push(o object.Object) error {
+	if vm.sp >= len(vm.stack) {
+		if err := vm.growStack(vm.sp + 1); err != nil {
+			return err
+		}
+	}
+	if vm.Limits.MaxCollectionSize > 0 {
+		if err := vm.Limits.CheckSize(o); err != nil {
+			return err
+		}
+	}
+	vm.stack[vm.sp] = o
+	vm.sp++
+	return nil
+}
+
+// pushResult apila el resultado de una operación, o lo devuelve como error
+// si la operación falló.
+func (vm *VM) pushResult(o object.Object) error {
+	if errObj, ok := o.(*object.Error); ok {
+		return errObj
+	}
+	return vm.push(o)
+}
+
+func (vm *VM) pop() object.Object {
+	o := vm.stack[vm.sp-1]
+	vm.sp--
+	return o
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+// modulePath devuelve la ruta del módulo al que pertenece env.
+func modulePath(env *object.Environment) string {
+	if module := env.Module(); module != nil {
+		return module.Path
+	}
+	return ""
+}
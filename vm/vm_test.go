@@ -0,0 +1,123 @@
+package vm
+
+import (
+	"go-rilla/compiler"
+	"go-rilla/evaluator"
+	"go-rilla/lexer"
+	"go-rilla/object"
+	"go-rilla/parser"
+	"testing"
+)
+
+func run(t *testing.T, input string, env *object.Environment) (object.Object, error) {
+	t.Helper()
+	program := parser.New(lexer.New(input)).ParseProgram()
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+	machine := New(comp.Bytecode(), env)
+	err := machine.Run()
+	return machine.LastPoppedStackElem(), err
+}
+
+func TestEnvironmentPersistsAcrossRuns(t *testing.T) {
+	env := object.NewEnvironment()
+	if _, err := run(t, "let add = fn(a, b) { a + b }; let x = 40;", env); err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	result, err := run(t, "add(x, 2)", env)
+	if err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	integer, ok := result.(*object.Integer)
+	if !ok || integer.Value != 42 {
+		t.Fatalf("wrong result. got=%T (%+v)", result, result)
+	}
+}
+
+func TestGlobalsSharedWithEnvironment(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("base", &object.Integer{Value: 1})
+	if _, err := run(t, "let f = fn() { later * 2 + base }; base = base + 1;", env); err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	if base, ok := env.Get("base"); !ok || base.Inspect() != "2" {
+		t.Fatalf("base not updated in env. got=%v", base)
+	}
+	result, err := run(t, "let later = 20; f()", env)
+	if err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	integer, ok := result.(*object.Integer)
+	if !ok || integer.Value != 42 {
+		t.Fatalf("wrong result. got=%T (%+v)", result, result)
+	}
+}
+
+func TestLetProducesNoValue(t *testing.T) {
+	result, err := run(t, "5; let x = 1;", object.NewEnvironment())
+	if err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	if result != nil {
+		t.Fatalf("expected no value, got=%T (%+v)", result, result)
+	}
+}
+
+func TestLoopValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let i = 0; while (i < 3) { i += 1; }", 3},
+		{"let i = 0; while (true) { i += 1; if (i == 5) { break; } i * 10; }", 40},
+		{"for (let i = 0; i < 4; i++) { if (i == 3) { continue; } i; }", 2},
+	}
+	for _, tt := range tests {
+		result, err := run(t, tt.input, object.NewEnvironment())
+		if err != nil {
+			t.Fatalf("vm error for %q: %s", tt.input, err)
+		}
+		integer, ok := result.(*object.Integer)
+		if !ok || integer.Value != tt.expected {
+			t.Errorf("wrong loop value for %q. got=%T (%+v), want=%d",
+				tt.input, result, result, tt.expected)
+		}
+	}
+}
+
+func TestStackOverflow(t *testing.T) {
+	_, err := run(t, "let f = fn(n) { f(n + 1) }; f(0);", object.NewEnvironment())
+	errObj, ok := err.(*object.Error)
+	if !ok {
+		t.Fatalf("expected *object.Error, got=%T (%+v)", err, err)
+	}
+	if errObj.Message != "stack overflow" {
+		t.Fatalf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+// BenchmarkFib compara la VM con el evaluador en un programa dominado por
+// llamadas y aritmética.
+func BenchmarkFib(b *testing.B) {
+	const input = `let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(20)`
+	program := parser.New(lexer.New(input)).ParseProgram()
+
+	b.Run("tree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			evaluator.Eval(program, object.NewEnvironment())
+		}
+	})
+	b.Run("vm", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			comp := compiler.New()
+			if err := comp.Compile(program); err != nil {
+				b.Fatalf("compiler error: %s", err)
+			}
+			if err := New(comp.Bytecode(), object.NewEnvironment()).Run(); err != nil {
+				b.Fatalf("vm error: %s", err)
+			}
+		}
+	})
+}